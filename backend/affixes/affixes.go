@@ -0,0 +1,435 @@
+// Analyzes which prefixes and suffixes can roll on which base items, and how
+// likely they are.
+//
+// Items drop from loot tables, which list the bases they drop with weights,
+// and for each kind of affix (normal or rare prefix or suffix) the affix
+// tables to pick from, each with a weight and an item level range. Affix
+// tables list affixes with weights and, optionally, level ranges. How many
+// and which kinds of affixes an item gets is picked by the weights of the
+// combinations, e.g. `bothPrefixSuffix`.
+package affixes
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+
+	"github.com/kenranunderscore/grimvault/backend/database"
+	"github.com/kenranunderscore/grimvault/backend/resolve"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+// Kinds of affixes, as named in the fields of loot tables.
+const (
+	prefix = iota
+	rarePrefix
+	suffix
+	rareSuffix
+	// No affix of that side, only used for combinations.
+	none
+)
+
+var kindFields = [...]string{"prefixTable", "rarePrefixTable", "suffixTable", "rareSuffixTable"}
+
+// The weight fields of loot tables for the combinations of affix kinds.
+var combinationFields = []struct {
+	field          string
+	prefix, suffix int
+}{
+	{"noPrefixNoSuffix", none, none},
+	{"prefixOnly", prefix, none},
+	{"suffixOnly", none, suffix},
+	{"rarePrefixOnly", rarePrefix, none},
+	{"rareSuffixOnly", none, rareSuffix},
+	{"bothPrefixSuffix", prefix, suffix},
+	{"rareBothPrefixSuffix", rarePrefix, rareSuffix},
+	{"rarePrefixNormalSuffix", rarePrefix, suffix},
+	{"normalPrefixRareSuffix", prefix, rareSuffix},
+}
+
+// Something to pick with a weight within a range of item levels. A maximum
+// level of 0 means there is none.
+type choice struct {
+	record   string
+	weight   float64
+	min, max uint32
+}
+
+func (c *choice) active(level uint32) bool {
+	return level >= c.min && (c.max == 0 || level <= c.max)
+}
+
+type lootTable struct {
+	key   string
+	bases []string
+	// The affix tables by kind.
+	tables [4][]choice
+	// The weights of the combinations of affix kinds by prefix and suffix
+	// kind; nil if the table does not weight them.
+	combinations map[[2]int]float64
+}
+
+// The affix tables and loot tables of a database.
+type Index struct {
+	r      *resolve.Resolver
+	tables []*lootTable
+	// Loot tables by the bases they drop.
+	byBase map[string][]*lootTable
+	// Loot tables by the affixes they can roll.
+	byAffix map[string][]*lootTable
+	// The contents of affix tables, by table record.
+	affixTables map[string][]choice
+}
+
+// Read the choices of `entry` named by `prefix`, e.g. `prefixTableName1`,
+// `prefixTableWeight1`, `prefixTableLevelMin1` and `prefixTableLevelMax1`.
+func choices(entry *database.Entry, prefix string) []choice {
+	var res []choice
+	for _, n := range entry.Numbered(prefix + "Name") {
+		i := strconv.Itoa(n)
+		record := database.NormalizeKey(entry.String(prefix + "Name" + i))
+		weight := entry.Float(prefix + "Weight" + i)
+		if record == "" || weight <= 0 {
+			continue
+		}
+		res = append(res, choice{
+			record: record,
+			weight: weight,
+			min:    uint32(entry.Float(prefix + "LevelMin" + i)),
+			max:    uint32(entry.Float(prefix + "LevelMax" + i)),
+		})
+	}
+	return res
+}
+
+// Find the loot tables of the database of `r` and the affixes they can roll.
+func NewIndex(r *resolve.Resolver) *Index {
+	ix := &Index{
+		r:           r,
+		byBase:      make(map[string][]*lootTable),
+		byAffix:     make(map[string][]*lootTable),
+		affixTables: make(map[string][]choice),
+	}
+	for _, key := range r.DB.Keys() {
+		entry := r.DB.Entries[key]
+		bases := choices(entry, "loot")
+		if len(bases) == 0 {
+			continue
+		}
+		t := &lootTable{key: key}
+		for _, base := range bases {
+			t.bases = append(t.bases, base.record)
+		}
+		for kind, field := range kindFields {
+			t.tables[kind] = choices(entry, field)
+		}
+		for _, c := range combinationFields {
+			if weight := entry.Float(c.field); weight > 0 {
+				if t.combinations == nil {
+					t.combinations = make(map[[2]int]float64)
+				}
+				t.combinations[[2]int{c.prefix, c.suffix}] = weight
+			}
+		}
+
+		ix.tables = append(ix.tables, t)
+		for _, base := range t.bases {
+			if !slices.Contains(ix.byBase[base], t) {
+				ix.byBase[base] = append(ix.byBase[base], t)
+			}
+		}
+		for _, tables := range t.tables {
+			for _, table := range tables {
+				for _, affix := range ix.affixTable(table.record) {
+					if !slices.Contains(ix.byAffix[affix.record], t) {
+						ix.byAffix[affix.record] = append(ix.byAffix[affix.record], t)
+					}
+				}
+			}
+		}
+	}
+	return ix
+}
+
+// The affixes of the affix table `record`, e.g. `randomizerName1` with
+// `randomizerWeight1`.
+func (ix *Index) affixTable(record string) []choice {
+	affixes, ok := ix.affixTables[record]
+	if !ok {
+		if entry, ok := ix.r.DB.Get(record); ok {
+			affixes = choices(entry, "randomizer")
+		}
+		ix.affixTables[record] = affixes
+	}
+	return affixes
+}
+
+// Stands for any affix or none in chance computations.
+const anyAffix = "*"
+
+// The chance that the affix tables of `kind` of `t` yield `affix` at item
+// level `level`: tables and affixes are picked by weight among those allowed
+// at that level.
+func (ix *Index) chanceWithin(t *lootTable, kind int, affix string, level uint32) float64 {
+	if affix == anyAffix {
+		return 1
+	}
+	if kind == none {
+		if affix == "" {
+			return 1
+		}
+		return 0
+	}
+	var total float64
+	for _, table := range t.tables[kind] {
+		if table.active(level) {
+			total += table.weight
+		}
+	}
+	chance := 0.0
+	for _, table := range t.tables[kind] {
+		if !table.active(level) {
+			continue
+		}
+		var sum, weight float64
+		for _, a := range ix.affixTable(table.record) {
+			if a.active(level) {
+				sum += a.weight
+				if a.record == affix {
+					weight += a.weight
+				}
+			}
+		}
+		if sum > 0 {
+			chance += table.weight / total * weight / sum
+		}
+	}
+	return chance
+}
+
+// The chance of an item dropped from `t` at item level `level` to roll both
+// `pre` and `suf`, either of which may be "" for none or `anyAffix`.
+func (ix *Index) chanceOf(t *lootTable, pre string, suf string, level uint32) float64 {
+	combinations := t.combinations
+	if combinations == nil {
+		// Tables without combination weights are taken to roll both sides
+		// from whichever tables they have.
+		p, s := none, none
+		if len(t.tables[prefix]) > 0 {
+			p = prefix
+		}
+		if len(t.tables[suffix]) > 0 {
+			s = suffix
+		}
+		combinations = map[[2]int]float64{{p, s}: 1}
+	}
+	var total float64
+	for _, weight := range combinations {
+		total += weight
+	}
+	chance := 0.0
+	for kinds, weight := range combinations {
+		p := ix.chanceWithin(t, kinds[0], pre, level)
+		if p == 0 {
+			continue
+		}
+		chance += weight / total * p * ix.chanceWithin(t, kinds[1], suf, level)
+	}
+	return chance
+}
+
+// The item levels at which the chances of `t` change, which are the only
+// levels worth looking at.
+func (ix *Index) levels(t *lootTable) []uint32 {
+	levels := []uint32{1}
+	add := func(c *choice) {
+		levels = append(levels, max(c.min, 1))
+		if c.max > 0 {
+			levels = append(levels, c.max+1)
+		}
+	}
+	for _, tables := range t.tables {
+		for i := range tables {
+			add(&tables[i])
+			affixes := ix.affixTable(tables[i].record)
+			for j := range affixes {
+				add(&affixes[j])
+			}
+		}
+	}
+	slices.Sort(levels)
+	return slices.Compact(levels)
+}
+
+// The best chance of rolling `pre` and `suf` on items dropped from `t`, at
+// `level` or, if it is 0, at any level.
+func (ix *Index) bestChance(t *lootTable, pre string, suf string, level uint32) float64 {
+	if level > 0 {
+		return ix.chanceOf(t, pre, suf, level)
+	}
+	best := 0.0
+	for _, level := range ix.levels(t) {
+		best = max(best, ix.chanceOf(t, pre, suf, level))
+	}
+	return best
+}
+
+// The item levels at which `affix` can roll from the tables of `kind` of
+// `t`, with a maximum of 0 meaning there is none. `ok` is false if it cannot
+// roll from them at all.
+func (ix *Index) levelRange(t *lootTable, kind int, affix string) (lo uint32, hi uint32, ok bool) {
+	for _, table := range t.tables[kind] {
+		for _, a := range ix.affixTable(table.record) {
+			if a.record != affix {
+				continue
+			}
+			from, to := max(a.min, table.min), minBound(a.max, table.max)
+			if to != 0 && to < from {
+				continue
+			}
+			if !ok {
+				lo, hi, ok = from, to, true
+				continue
+			}
+			lo = min(lo, from)
+			if hi != 0 && (to == 0 || to > hi) {
+				hi = to
+			}
+		}
+	}
+	return lo, hi, ok
+}
+
+// The lower of two bounds, where a maximum level of 0 means there is none.
+func minBound(a uint32, b uint32) uint32 {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// An affix that can roll on a base.
+type Roll struct {
+	Affix string `json:"affix"`
+	Name  string `json:"name"`
+	// Whether the affix is one of the rare ones, which have their own tables.
+	Rare bool `json:"rare"`
+	// The chance of an item of the base to roll the affix, in the loot table
+	// and at the item level where it is most likely.
+	Chance float64 `json:"chance"`
+	// The item levels the affix can roll at. A maximum of 0 means there is
+	// none.
+	LevelMin uint32 `json:"levelMin"`
+	LevelMax uint32 `json:"levelMax"`
+}
+
+// The affixes that can roll on a base.
+type Pool struct {
+	Base     string `json:"base"`
+	Name     string `json:"name"`
+	Prefixes []Roll `json:"prefixes"`
+	Suffixes []Roll `json:"suffixes"`
+}
+
+func byChance(a Roll, b Roll) int {
+	return cmp.Or(cmp.Compare(b.Chance, a.Chance), cmp.Compare(a.Name, b.Name), cmp.Compare(a.Affix, b.Affix))
+}
+
+// Merge `roll` into `rolls`, keeping the best chance and the widest level
+// range of rolls of the same affix, e.g. from different loot tables.
+func merge(rolls []Roll, roll Roll) []Roll {
+	i := slices.IndexFunc(rolls, func(r Roll) bool { return r.Affix == roll.Affix })
+	if i < 0 {
+		return append(rolls, roll)
+	}
+	r := &rolls[i]
+	r.Rare = r.Rare || roll.Rare
+	r.Chance = max(r.Chance, roll.Chance)
+	r.LevelMin = min(r.LevelMin, roll.LevelMin)
+	if r.LevelMax != 0 && (roll.LevelMax == 0 || roll.LevelMax > r.LevelMax) {
+		r.LevelMax = roll.LevelMax
+	}
+	return rolls
+}
+
+func (ix *Index) affixName(record string) string {
+	if name := ix.r.Text(record, "lootRandomizerName"); name != "" {
+		return name
+	}
+	return record
+}
+
+// List the prefixes and suffixes that can roll on `base`, most likely first,
+// at item level `level` or, if it is 0, at any level.
+func (ix *Index) Pool(base string, level uint32) Pool {
+	base = database.NormalizeKey(base)
+	pool := Pool{Base: base, Name: ix.r.BaseName(base), Prefixes: []Roll{}, Suffixes: []Roll{}}
+	for _, t := range ix.byBase[base] {
+		for kind, tables := range t.tables {
+			seen := make(map[string]bool)
+			for _, table := range tables {
+				for _, a := range ix.affixTable(table.record) {
+					if seen[a.record] {
+						continue
+					}
+					seen[a.record] = true
+					lo, hi, ok := ix.levelRange(t, kind, a.record)
+					if !ok || level > 0 && (level < lo || (hi != 0 && level > hi)) {
+						continue
+					}
+					roll := Roll{Affix: a.record, Name: ix.affixName(a.record), Rare: kind == rarePrefix || kind == rareSuffix, LevelMin: lo, LevelMax: hi}
+					if kind == prefix || kind == rarePrefix {
+						roll.Chance = ix.bestChance(t, a.record, anyAffix, level)
+						pool.Prefixes = merge(pool.Prefixes, roll)
+					} else {
+						roll.Chance = ix.bestChance(t, anyAffix, a.record, level)
+						pool.Suffixes = merge(pool.Suffixes, roll)
+					}
+				}
+			}
+		}
+	}
+	slices.SortFunc(pool.Prefixes, byChance)
+	slices.SortFunc(pool.Suffixes, byChance)
+	return pool
+}
+
+// A base an affix can roll on.
+type Source struct {
+	Base string `json:"base"`
+	Name string `json:"name"`
+	Roll Roll   `json:"roll"`
+}
+
+// List the bases `affix` can roll on, those it is most likely on first.
+func (ix *Index) Bases(affix string) []Source {
+	affix = database.NormalizeKey(affix)
+	var sources []Source
+	for _, t := range ix.byAffix[affix] {
+		for _, base := range t.bases {
+			pool := ix.Pool(base, 0)
+			for _, roll := range slices.Concat(pool.Prefixes, pool.Suffixes) {
+				if roll.Affix == affix && !slices.ContainsFunc(sources, func(s Source) bool { return s.Base == base }) {
+					sources = append(sources, Source{Base: base, Name: pool.Name, Roll: roll})
+				}
+			}
+		}
+	}
+	slices.SortFunc(sources, func(a, b Source) int {
+		return cmp.Or(byChance(a.Roll, b.Roll), cmp.Compare(a.Name, b.Name), cmp.Compare(a.Base, b.Base))
+	})
+	return sources
+}
+
+// The chance that an item with the base of `item` rolls exactly its prefix
+// and suffix, including none where it has none, in the loot table and at the
+// item level where that is most likely. It is 0 if the item cannot drop like
+// this, e.g. if it is crafted, or if the loot tables are not known.
+func (ix *Index) Odds(item *stash.Item) float64 {
+	pre, suf := database.NormalizeKey(item.Prefix), database.NormalizeKey(item.Suffix)
+	best := 0.0
+	for _, t := range ix.byBase[database.NormalizeKey(item.Base)] {
+		best = max(best, ix.bestChance(t, pre, suf, 0))
+	}
+	return best
+}
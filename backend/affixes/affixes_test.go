@@ -0,0 +1,129 @@
+package affixes
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kenranunderscore/grimvault/backend/arc"
+	"github.com/kenranunderscore/grimvault/backend/database"
+	"github.com/kenranunderscore/grimvault/backend/internal/testdb"
+	"github.com/kenranunderscore/grimvault/backend/resolve"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+const (
+	ring   = "records/items/gearaccessories/rings/a001_ring.dbr"
+	amulet = "records/items/gearaccessories/necklaces/a001_necklace.dbr"
+	fiery  = "records/items/lootaffixes/prefix/a001_fire.dbr"
+	arcane = "records/items/lootaffixes/prefix/a002_arcane.dbr"
+	royal  = "records/items/lootaffixes/prefixrare/a001_royal.dbr"
+	valor  = "records/items/lootaffixes/suffix/a001_valor.dbr"
+	never  = "records/items/lootaffixes/prefix/a003_never.dbr"
+)
+
+func testIndex() *Index {
+	db := database.New([]database.Entry{
+		{Key: "records/items/loottables/tdyn_jewelry.dbr", Stats: testdb.Stats(
+			"lootName1", ring, "lootWeight1", float32(100),
+			"lootName2", amulet, "lootWeight2", float32(50),
+			"prefixTableName1", "records/items/lootaffixes/prefix/tables/early.dbr",
+			"prefixTableWeight1", float32(1), "prefixTableLevelMin1", float32(1), "prefixTableLevelMax1", float32(50),
+			"prefixTableName2", "records/items/lootaffixes/prefix/tables/late.dbr",
+			"prefixTableWeight2", float32(3), "prefixTableLevelMin2", float32(1),
+			"rarePrefixTableName1", "records/items/lootaffixes/prefixrare/tables/rare.dbr", "rarePrefixTableWeight1", float32(1),
+			"suffixTableName1", "records/items/lootaffixes/suffix/tables/all.dbr", "suffixTableWeight1", float32(1),
+			"bothPrefixSuffix", float32(2),
+			"suffixOnly", float32(1),
+			"rarePrefixOnly", float32(1),
+		)},
+		{Key: "records/items/lootaffixes/prefix/tables/early.dbr", Stats: testdb.Stats(
+			"randomizerName1", fiery, "randomizerWeight1", float32(1),
+			"randomizerName2", arcane, "randomizerWeight2", float32(1),
+			// Only from level 60, but the table is only used up to level 50.
+			"randomizerName3", never, "randomizerWeight3", float32(1), "randomizerLevelMin3", float32(60),
+		)},
+		{Key: "records/items/lootaffixes/prefix/tables/late.dbr", Stats: testdb.Stats(
+			"randomizerName1", arcane, "randomizerWeight1", float32(1), "randomizerLevelMin1", float32(40),
+		)},
+		{Key: "records/items/lootaffixes/prefixrare/tables/rare.dbr", Stats: testdb.Stats(
+			"randomizerName1", royal, "randomizerWeight1", float32(1),
+		)},
+		{Key: "records/items/lootaffixes/suffix/tables/all.dbr", Stats: testdb.Stats(
+			"randomizerName1", valor, "randomizerWeight1", float32(1),
+		)},
+		{Key: ring, Stats: testdb.Stats("itemNameTag", "tagRing")},
+		{Key: amulet, Stats: testdb.Stats("itemNameTag", "tagAmulet")},
+		{Key: fiery, Stats: testdb.Stats("lootRandomizerName", "tagFiery")},
+		{Key: arcane, Stats: testdb.Stats("lootRandomizerName", "tagArcane")},
+	})
+	tags := arc.NewTags([]arc.Tag{
+		{Tag: "tagRing", Name: "Ring"},
+		{Tag: "tagAmulet", Name: "Amulet"},
+		{Tag: "tagFiery", Name: "Fiery"},
+		{Tag: "tagArcane", Name: "Arcane"},
+	})
+	return NewIndex(resolve.New(db, tags))
+}
+
+func near(a float64, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestPool(t *testing.T) {
+	t.Parallel()
+
+	ix := testIndex()
+	pool := ix.Pool("Records\\Items\\GearAccessories\\Rings\\a001_ring.dbr", 0)
+	expected := []Roll{
+		{Affix: arcane, Name: "Arcane", Chance: 0.5, LevelMin: 1},
+		{Affix: royal, Name: royal, Rare: true, Chance: 0.25},
+		{Affix: fiery, Name: "Fiery", Chance: 0.0625, LevelMin: 1, LevelMax: 50},
+	}
+	if len(pool.Prefixes) != len(expected) {
+		t.Fatalf("expected %d prefixes, got %+v", len(expected), pool.Prefixes)
+	}
+	for i, roll := range pool.Prefixes {
+		e := expected[i]
+		if roll.Affix != e.Affix || roll.Name != e.Name || roll.Rare != e.Rare || !near(roll.Chance, e.Chance) || roll.LevelMin != e.LevelMin || roll.LevelMax != e.LevelMax {
+			t.Errorf("expected %+v, got %+v", e, roll)
+		}
+	}
+	if len(pool.Suffixes) != 1 || !near(pool.Suffixes[0].Chance, 0.75) {
+		t.Errorf("expected the suffix to roll on 3 of 4 items, got %+v", pool.Suffixes)
+	}
+
+	late := ix.Pool(ring, 60)
+	if len(late.Prefixes) != 2 || late.Prefixes[0].Affix != arcane || !near(late.Prefixes[0].Chance, 0.5) {
+		t.Errorf("expected only arcane and the rare prefix at level 60, got %+v", late.Prefixes)
+	}
+}
+
+func TestBases(t *testing.T) {
+	t.Parallel()
+
+	sources := testIndex().Bases(arcane)
+	if len(sources) != 2 || sources[0].Name != "Amulet" || sources[1].Name != "Ring" || !near(sources[1].Roll.Chance, 0.5) {
+		t.Errorf("expected both bases, got %+v", sources)
+	}
+}
+
+func TestOdds(t *testing.T) {
+	t.Parallel()
+
+	ix := testIndex()
+	cases := []struct {
+		item     stash.Item
+		expected float64
+	}{
+		{stash.Item{Base: ring, Prefix: fiery, Suffix: valor}, 0.0625},
+		{stash.Item{Base: ring, Prefix: royal}, 0.25},
+		{stash.Item{Base: ring, Prefix: arcane}, 0},
+		{stash.Item{Base: ring, Suffix: valor}, 0.25},
+		{stash.Item{Base: "records/items/unknown.dbr"}, 0},
+	}
+	for _, c := range cases {
+		if odds := ix.Odds(&c.item); !near(odds, c.expected) {
+			t.Errorf("%+v: expected %v, got %v", c.item, c.expected, odds)
+		}
+	}
+}
@@ -188,3 +188,111 @@ func ReadFile(file string) ([]Tag, error) {
 	tags := readAllTags(r, files, records)
 	return tags, nil
 }
+
+// An opened `.arc` archive, giving access to the files it contains.
+type Archive struct {
+	r       *rawreader.T
+	parts   []part
+	files   []string
+	records map[string]record
+}
+
+// Open the archive `file` and read its table of contents.
+func Open(file string) (*Archive, error) {
+	r, err := rawreader.FromFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := readHeader(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not read header: %w", err)
+	}
+
+	archive := &Archive{
+		r:       r,
+		parts:   readFileParts(r, header),
+		records: make(map[string]record, header.fileCount),
+	}
+
+	// Unlike `readRecords`, look up each name via its string offset, as the
+	// names of records without any data are missing from the string table.
+	namesStart := header.recordOffset + header.recordSize
+	r.Seek(namesStart + header.stringSize)
+	for range header.fileCount {
+		rec := readRecord(r)
+		if rec.uncompressedSize == 0 {
+			continue
+		}
+		start := namesStart + rec.stringOffset
+		name := string(r.Data[start : start+rec.stringSize])
+		archive.files = append(archive.files, name)
+		archive.records[name] = rec
+	}
+	return archive, nil
+}
+
+// The names of all files in the archive, in the order they are stored.
+func (a *Archive) Files() []string {
+	return a.files
+}
+
+// Read the uncompressed contents of the file called `name`.
+func (a *Archive) Read(name string) ([]byte, error) {
+	rec, ok := a.records[name]
+	if !ok {
+		return nil, fmt.Errorf("no file '%s' in archive", name)
+	}
+
+	data := make([]byte, rec.uncompressedSize)
+	offset := uint32(0)
+	for i := range rec.partCount {
+		part := a.parts[rec.index+i]
+		if offset+part.uncompressedSize > rec.uncompressedSize {
+			return nil, fmt.Errorf("parts of '%s' exceed its size", name)
+		}
+		compressed := a.r.Data[part.offset : part.offset+part.compressedSize]
+		dest := data[offset : offset+part.uncompressedSize]
+		if part.compressedSize == part.uncompressedSize {
+			copy(dest, compressed)
+		} else if _, err := lz4.UncompressBlock(compressed, dest); err != nil {
+			return nil, fmt.Errorf("could not uncompress '%s': %w", name, err)
+		}
+		offset += part.uncompressedSize
+	}
+	return data, nil
+}
+
+// Look up localized names by their tag.
+type Tags map[string]string
+
+// Index `tags` by their tag. Tags are case-insensitive in the game, so keys are
+// stored in lowercase.
+func NewTags(tags []Tag) Tags {
+	index := make(Tags, len(tags))
+	for _, tag := range tags {
+		index[strings.ToLower(tag.Tag)] = tag.Name
+	}
+	return index
+}
+
+// Read the tags of all `files`, later ones overriding earlier ones.
+func LoadTags(files ...string) (Tags, error) {
+	index := make(Tags)
+	for _, file := range files {
+		tags, err := ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("could not read tags from '%s': %w", file, err)
+		}
+		for tag, name := range NewTags(tags) {
+			index[tag] = name
+		}
+	}
+	return index, nil
+}
+
+// Get the localized text for `tag`.
+func (t Tags) Lookup(tag string) (string, bool) {
+	name, ok := t[strings.ToLower(tag)]
+	return name, ok
+}
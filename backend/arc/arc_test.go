@@ -32,3 +32,43 @@ func TestReadFileReturnsCorrectNumberOfTags(t *testing.T) {
 		t.Errorf("expected 11 strings, got %d\n", ntags)
 	}
 }
+
+func TestOpenListsAndReadsFiles(t *testing.T) {
+	t.Parallel()
+
+	archive, err := Open("../test_data/arc/some_german.arc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := archive.Files()
+	if len(files) != 904 {
+		t.Errorf("expected 904 files, got %d", len(files))
+	}
+
+	data, err := archive.Read("language.def")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 24 {
+		t.Errorf("expected 24 bytes, got %d", len(data))
+	}
+
+	if _, err := archive.Read("does/not/exist.txt"); err == nil {
+		t.Errorf("expected error for missing file")
+	}
+}
+
+func TestTagsLookup(t *testing.T) {
+	t.Parallel()
+
+	tags, err := LoadTags("../test_data/arc/some.arc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name, ok := tags.Lookup("TAGFEETD003")
+	if !ok || name != "Venomspine Greaves" {
+		t.Errorf("expected 'Venomspine Greaves', got %q", name)
+	}
+}
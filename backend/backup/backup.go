@@ -0,0 +1,355 @@
+package backup
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+// The layout of the timestamp that is part of every backup file name. It sorts
+// lexicographically in chronological order.
+const timeLayout = "20060102T150405.000000000Z"
+
+// The number of hex characters of the content hash kept in file names.
+const hashLength = 16
+
+// A directory containing versioned copies of stash (`.gst`) and character
+// (`.gdc`) files.
+//
+// Every backed up file gets its own subdirectory named after the file's path
+// relative to `Root`, e.g. `main/_Alice/player.gdc`, in which each version is
+// stored as `<timestamp>-<hash><ext>`.
+type Store struct {
+	Dir string
+	// The directory backed up files are named relative to, usually the save
+	// directory. Files outside of it are named by their base name.
+	Root string
+	// Which backups are kept whenever a new one is made. The zero policy
+	// keeps all of them.
+	Policy Policy
+	now    func() time.Time
+}
+
+// A single stored version of a file.
+type Backup struct {
+	Path string
+	Name string
+	Time time.Time
+	Hash string
+}
+
+// Determines which backups survive a call to `Prune`. A backup is kept if it is
+// one of the `KeepLast` most recent ones, or if it is the most recent backup of
+// one of the last `KeepDaily` days that have backups at all.
+type Policy struct {
+	KeepLast  int
+	KeepDaily int
+}
+
+var DefaultPolicy = Policy{KeepLast: 10, KeepDaily: 14}
+
+var ErrNoBackups = errors.New("no backups found")
+
+// Create a new store in `dir` for files in `root`, creating the directory if
+// necessary. Backups are pruned by `DefaultPolicy`.
+func NewStore(dir string, root string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("could not create backup directory '%s': %w", dir, err)
+	}
+	return &Store{Dir: dir, Root: root, Policy: DefaultPolicy, now: time.Now}, nil
+}
+
+func hashData(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:hashLength]
+}
+
+// The name the backups of `file` are stored under: its slash-separated path
+// relative to `Root`, or its base name if it is not within `Root`.
+func (s *Store) Name(file string) string {
+	if s.Root != "" {
+		rel, err := filepath.Rel(s.Root, file)
+		if err == nil && filepath.IsLocal(rel) {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.Base(file)
+}
+
+// The file that the backups called `name` have been made of.
+func (s *Store) Target(name string) string {
+	return filepath.Join(s.Root, filepath.FromSlash(name))
+}
+
+func (s *Store) fileDir(name string) string {
+	return filepath.Join(s.Dir, filepath.FromSlash(name))
+}
+
+// Copy `file` into the store, unless its content is identical to the latest
+// backup of that file. Returns the latest backup in both cases, and whether a
+// new one has been created. Older backups are then pruned by `Policy`.
+func (s *Store) Backup(file string) (Backup, bool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return Backup{}, false, fmt.Errorf("could not read '%s' for backup: %w", file, err)
+	}
+
+	name := s.Name(file)
+	hash := hashData(data)
+	backups, err := s.List(name)
+	if err != nil {
+		return Backup{}, false, err
+	}
+	if len(backups) > 0 && backups[0].Hash == hash {
+		return backups[0], false, nil
+	}
+
+	dir := s.fileDir(name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return Backup{}, false, fmt.Errorf("could not create backup directory '%s': %w", dir, err)
+	}
+
+	now := s.now().UTC()
+	backup := Backup{
+		Path: filepath.Join(dir, now.Format(timeLayout)+"-"+hash+filepath.Ext(name)),
+		Name: name,
+		Time: now,
+		Hash: hash,
+	}
+	if err := writeFileAtomic(backup.Path, data); err != nil {
+		return Backup{}, false, fmt.Errorf("could not write backup of '%s': %w", file, err)
+	}
+	if s.Policy != (Policy{}) {
+		if _, err := s.Prune(name, s.Policy); err != nil {
+			return backup, true, err
+		}
+	}
+	return backup, true, nil
+}
+
+func parseBackup(name string, path string) (Backup, bool) {
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(name))
+	stamp, hash, found := strings.Cut(base, "-")
+	if !found || len(hash) != hashLength {
+		return Backup{}, false
+	}
+
+	t, err := time.Parse(timeLayout, stamp)
+	if err != nil {
+		return Backup{}, false
+	}
+	return Backup{Path: path, Name: name, Time: t, Hash: hash}, true
+}
+
+// List all backups called `name`, as given by `Name`, most recent first.
+func (s *Store) List(name string) ([]Backup, error) {
+	entries, err := os.ReadDir(s.fileDir(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not list backups of '%s': %w", name, err)
+	}
+
+	var backups []Backup
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(s.fileDir(name), entry.Name())
+		if backup, ok := parseBackup(name, path); ok {
+			backups = append(backups, backup)
+		}
+	}
+	slices.SortFunc(backups, func(a, b Backup) int {
+		return b.Time.Compare(a.Time)
+	})
+	return backups, nil
+}
+
+// List the names of all files that have at least one backup.
+func (s *Store) Names() ([]string, error) {
+	var names []string
+	err := filepath.WalkDir(s.Dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || path == s.Dir {
+			return nil
+		}
+		dir, err := filepath.Rel(s.Dir, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		if name := filepath.ToSlash(dir); !slices.Contains(names, name) && dir != "." {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not read backup directory '%s': %w", s.Dir, err)
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// Select the backups to keep according to `policy`; `backups` must be ordered
+// most recent first, as returned by `List`.
+func (policy Policy) keep(backups []Backup) map[string]bool {
+	keep := make(map[string]bool)
+	for i, backup := range backups {
+		if i < policy.KeepLast {
+			keep[backup.Path] = true
+		}
+	}
+
+	days := 0
+	lastDay := ""
+	for _, backup := range backups {
+		day := backup.Time.Format(time.DateOnly)
+		if day == lastDay {
+			continue
+		}
+		lastDay = day
+		days++
+		if days > policy.KeepDaily {
+			break
+		}
+		keep[backup.Path] = true
+	}
+	return keep
+}
+
+// Delete all backups called `name` that are not retained by `policy`,
+// returning the removed ones.
+func (s *Store) Prune(name string, policy Policy) ([]Backup, error) {
+	backups, err := s.List(name)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := policy.keep(backups)
+	var removed []Backup
+	for _, backup := range backups {
+		if keep[backup.Path] {
+			continue
+		}
+		if err := os.Remove(backup.Path); err != nil {
+			return removed, fmt.Errorf("could not remove backup '%s': %w", backup.Path, err)
+		}
+		removed = append(removed, backup)
+	}
+	return removed, nil
+}
+
+// Find the backup called `name` whose hash starts with `hash`. An empty `hash`
+// selects the most recent backup.
+func (s *Store) Find(name string, hash string) (Backup, error) {
+	backups, err := s.List(name)
+	if err != nil {
+		return Backup{}, err
+	}
+	for _, backup := range backups {
+		if strings.HasPrefix(backup.Hash, hash) {
+			return backup, nil
+		}
+	}
+	return Backup{}, fmt.Errorf("%w for '%s' matching '%s'", ErrNoBackups, name, hash)
+}
+
+// Make sure a backed up file can still be read by the game. Only stash files
+// can be decoded right now, so character files are merely checked to be
+// non-empty.
+func validate(backup Backup) error {
+	switch filepath.Ext(backup.Name) {
+	case ".gst", ".gsh":
+		if _, err := stash.ReadStash(backup.Path); err != nil {
+			return fmt.Errorf("backup '%s' is not a valid stash: %w", backup.Path, err)
+		}
+	default:
+		info, err := os.Stat(backup.Path)
+		if err != nil {
+			return err
+		}
+		if info.Size() == 0 {
+			return fmt.Errorf("backup '%s' is empty", backup.Path)
+		}
+	}
+	return nil
+}
+
+// Put `backup` back in place of `target`, after validating that it decodes. The
+// current contents of `target` are backed up first, so restoring can be undone.
+func (s *Store) Restore(backup Backup, target string) error {
+	if err := validate(backup); err != nil {
+		return err
+	}
+	// Read before backing up `target`, which may prune `backup` itself.
+	data, err := os.ReadFile(backup.Path)
+	if err != nil {
+		return fmt.Errorf("could not read backup '%s': %w", backup.Path, err)
+	}
+
+	if _, err := os.Stat(target); err == nil {
+		if _, _, err := s.Backup(target); err != nil {
+			return fmt.Errorf("could not back up '%s' before restoring: %w", target, err)
+		}
+	}
+	if err := writeFileAtomic(target, data); err != nil {
+		return fmt.Errorf("could not restore '%s': %w", target, err)
+	}
+	return nil
+}
+
+// Back up `files` every `interval` until `ctx` is cancelled. Files that do
+// not exist (yet) are skipped, and errors backing up a file, e.g. while the
+// game is writing it, are passed to `report` without stopping.
+func (s *Store) Watch(ctx context.Context, interval time.Duration, report func(error), files ...string) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, file := range files {
+			if _, err := os.Stat(file); errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if _, _, err := s.Backup(file); err != nil {
+				report(err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Write `data` to a temporary file next to `file` first, then rename it, so
+// that readers never observe a partially written file.
+func writeFileAtomic(file string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
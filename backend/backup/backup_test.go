@@ -0,0 +1,265 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func newTestStore(t *testing.T) (*Store, *time.Time) {
+	store, err := NewStore(filepath.Join(t.TempDir(), "backups"), t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Pruning is tested on its own.
+	store.Policy = Policy{}
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	return store, &now
+}
+
+func copyStash(t *testing.T, name string) string {
+	data, err := os.ReadFile(filepath.Join("../test_data/stashes", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "transfer.gst")
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestBackupDeduplicatesIdenticalContent(t *testing.T) {
+	t.Parallel()
+
+	store, now := newTestStore(t)
+	file := copyStash(t, "transfer.gst")
+	if _, created, err := store.Backup(file); err != nil || !created {
+		t.Fatalf("expected first backup to be created: %v", err)
+	}
+
+	*now = now.Add(time.Hour)
+	if _, created, err := store.Backup(file); err != nil || created {
+		t.Fatalf("expected identical backup to be skipped: %v", err)
+	}
+
+	backups, err := store.List("transfer.gst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 {
+		t.Errorf("expected 1 backup, got %d", len(backups))
+	}
+}
+
+func TestPruneKeepsLastAndDaily(t *testing.T) {
+	t.Parallel()
+
+	store, now := newTestStore(t)
+	file := copyStash(t, "transfer.gst")
+	// Five days with three distinct backups each.
+	for day := range 5 {
+		for i := range 3 {
+			*now = time.Date(2025, 5, 1+day, 8+i, 0, 0, 0, time.UTC)
+			if err := os.WriteFile(file, []byte{byte(day), byte(i)}, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, _, err := store.Backup(file); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	removed, err := store.Prune("transfer.gst", Policy{KeepLast: 2, KeepDaily: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 15-4 {
+		t.Errorf("expected 11 removed backups, got %d", len(removed))
+	}
+
+	backups, err := store.List("transfer.gst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []time.Time{
+		time.Date(2025, 5, 5, 10, 0, 0, 0, time.UTC),
+		time.Date(2025, 5, 5, 9, 0, 0, 0, time.UTC),
+		time.Date(2025, 5, 4, 10, 0, 0, 0, time.UTC),
+		time.Date(2025, 5, 3, 10, 0, 0, 0, time.UTC),
+	}
+	if len(backups) != len(expected) {
+		t.Fatalf("expected %d backups, got %d", len(expected), len(backups))
+	}
+	for i, backup := range backups {
+		if !backup.Time.Equal(expected[i]) {
+			t.Errorf("backup %d: expected time %v, got %v", i, expected[i], backup.Time)
+		}
+	}
+}
+
+func TestRestoreValidatesStash(t *testing.T) {
+	t.Parallel()
+
+	store, now := newTestStore(t)
+	file := copyStash(t, "transfer.gst")
+	good, _, err := store.Backup(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	*now = now.Add(time.Minute)
+	if err := os.WriteFile(file, []byte("garbage that is no stash"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bad, _, err := store.Backup(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Restore(bad, file); err == nil {
+		t.Errorf("expected restoring an invalid stash to fail")
+	}
+
+	*now = now.Add(time.Minute)
+	data, err := os.ReadFile("../test_data/stashes/transfer.gst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, data[:len(data)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+	truncated, _, err := store.Backup(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Restore(truncated, file); err == nil {
+		t.Errorf("expected restoring a truncated stash to fail")
+	}
+
+	if err := store.Restore(good, file); err != nil {
+		t.Fatalf("could not restore valid backup: %v", err)
+	}
+	restored, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hashData(restored) != good.Hash {
+		t.Errorf("restored file does not match backup")
+	}
+}
+
+func TestFindWithoutBackups(t *testing.T) {
+	t.Parallel()
+
+	store, _ := newTestStore(t)
+	if _, err := store.Find("transfer.gst", ""); !errors.Is(err, ErrNoBackups) {
+		t.Errorf("expected ErrNoBackups, got %v", err)
+	}
+}
+
+func TestBackupNamesFilesByPathWithinRoot(t *testing.T) {
+	t.Parallel()
+
+	store, _ := newTestStore(t)
+	var files []string
+	for _, dir := range []string{"main/_Alice", "main/_Bob", "mod"} {
+		file := filepath.Join(store.Root, dir, "player.gdc")
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(dir), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, file)
+	}
+	files = append(files, copyStash(t, "transfer.gst"))
+	for _, file := range files {
+		if _, _, err := store.Backup(file); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names, err := store.Names()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"main/_Alice/player.gdc", "main/_Bob/player.gdc", "mod/player.gdc", "transfer.gst"}
+	if !slices.Equal(names, expected) {
+		t.Errorf("expected names %v, got %v", expected, names)
+	}
+	if target := store.Target(names[1]); target != files[1] {
+		t.Errorf("expected backups of %s to be restored to %s, got %s", names[1], files[1], target)
+	}
+}
+
+func TestBackupPrunesByPolicy(t *testing.T) {
+	t.Parallel()
+
+	store, now := newTestStore(t)
+	store.Policy = Policy{KeepLast: 2}
+	file := copyStash(t, "transfer.gst")
+	for i := range 4 {
+		*now = now.Add(time.Hour)
+		if err := os.WriteFile(file, []byte{byte(i)}, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := store.Backup(file); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if backups, err := store.List("transfer.gst"); err != nil || len(backups) != 2 {
+		t.Errorf("expected the last 2 backups to be kept, got %v (%v)", backups, err)
+	}
+}
+
+func TestRestorePrunedBackup(t *testing.T) {
+	t.Parallel()
+
+	store, now := newTestStore(t)
+	store.Policy = Policy{KeepLast: 1}
+	file := copyStash(t, "transfer.gst")
+	original, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old, _, err := store.Backup(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Backing up the current contents before restoring prunes the old backup.
+	*now = now.Add(time.Hour)
+	if err := os.WriteFile(file, []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Restore(old, file); err != nil {
+		t.Fatalf("could not restore a backup outside of the policy: %v", err)
+	}
+	if data, err := os.ReadFile(file); err != nil || !bytes.Equal(data, original) {
+		t.Errorf("expected the old contents to be restored (%v)", err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	t.Parallel()
+
+	store, _ := newTestStore(t)
+	file := copyStash(t, "transfer.gst")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var reported []error
+	err := store.Watch(ctx, time.Hour, func(err error) { reported = append(reported, err) }, file, filepath.Join(store.Root, "missing.gst"))
+	if !errors.Is(err, context.Canceled) || len(reported) != 0 {
+		t.Errorf("expected watching to stop without errors, got %v and %v", err, reported)
+	}
+	if backups, err := store.List("transfer.gst"); err != nil || len(backups) != 1 {
+		t.Errorf("expected the file to be backed up right away, got %v (%v)", backups, err)
+	}
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/kenranunderscore/grimvault/backend/affixes"
+)
+
+func init() {
+	register(
+		&command{
+			group: "affixes",
+			name:  "pool",
+			args:  "<base-record>",
+			help:  "List the prefixes and suffixes that can roll on a base item, most likely first.",
+			setup: func(fs *flag.FlagSet) runFunc {
+				level := fs.Uint("level", 0, "only consider drops of this item level (0 for any)")
+				return func(o *options, args []string) error {
+					return affixesPool(o, args, uint32(*level))
+				}
+			},
+		},
+		&command{
+			group: "affixes",
+			name:  "bases",
+			args:  "<affix-record>",
+			help:  "List the base items an affix can roll on, most likely first.",
+			setup: noFlags(affixesBases),
+		},
+		&command{
+			group: "affixes",
+			name:  "odds",
+			args:  "<id>",
+			help:  "Show how likely a vault item is to drop with exactly its affixes.",
+			setup: noFlags(affixesOdds),
+		},
+	)
+}
+
+func rollLine(roll *affixes.Roll) string {
+	levels := fmt.Sprintf("level %d+", roll.LevelMin)
+	if roll.LevelMax != 0 {
+		levels = fmt.Sprintf("level %d-%d", roll.LevelMin, roll.LevelMax)
+	}
+	rare := ""
+	if roll.Rare {
+		rare = " (rare)"
+	}
+	return fmt.Sprintf("%7.3f%%  %s%s, %s", roll.Chance*100, roll.Name, rare, levels)
+}
+
+func affixesPool(o *options, args []string, level uint32) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: expected exactly one base record", errUsage)
+	}
+	r, err := o.loadResolver()
+	if err != nil {
+		return err
+	}
+
+	pool := affixes.NewIndex(r).Pool(args[0], level)
+	return o.print(pool, func(w io.Writer) {
+		fmt.Fprintln(w, "prefixes:")
+		for _, roll := range pool.Prefixes {
+			fmt.Fprintf(w, "  %s\n", rollLine(&roll))
+		}
+		fmt.Fprintln(w, "suffixes:")
+		for _, roll := range pool.Suffixes {
+			fmt.Fprintf(w, "  %s\n", rollLine(&roll))
+		}
+	})
+}
+
+func affixesBases(o *options, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: expected exactly one affix record", errUsage)
+	}
+	r, err := o.loadResolver()
+	if err != nil {
+		return err
+	}
+
+	sources := affixes.NewIndex(r).Bases(args[0])
+	return o.print(sources, func(w io.Writer) {
+		for _, source := range sources {
+			name := source.Name
+			if name == "" {
+				name = source.Base
+			}
+			fmt.Fprintf(w, "%7.3f%%  %s\n", source.Roll.Chance*100, name)
+		}
+	})
+}
+
+func affixesOdds(o *options, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: expected exactly one item id", errUsage)
+	}
+	id, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid item id '%s'", errUsage, args[0])
+	}
+	v, err := o.openVault()
+	if err != nil {
+		return err
+	}
+	entry, ok := v.Get(id)
+	if !ok {
+		return fmt.Errorf("no item with id %d in the vault", id)
+	}
+	r, err := o.loadResolver()
+	if err != nil {
+		return err
+	}
+
+	odds := affixes.NewIndex(r).Odds(&entry.Item)
+	return o.print(odds, func(w io.Writer) {
+		if odds == 0 {
+			fmt.Fprintf(w, "%s cannot drop like this from any known loot table\n", r.Name(&entry.Item))
+			return
+		}
+		fmt.Fprintf(w, "%s: %.4f%% of drops, about 1 in %.0f\n", r.Name(&entry.Item), odds*100, 1/odds)
+	})
+}
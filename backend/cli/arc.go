@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/kenranunderscore/grimvault/backend/arc"
+)
+
+func init() {
+	register(
+		&command{
+			group: "arc",
+			name:  "list",
+			args:  "<archive>",
+			help:  "List the files in an .arc archive.",
+			setup: noFlags(arcList),
+		},
+		&command{
+			group: "arc",
+			name:  "extract",
+			args:  "<archive> [file...]",
+			help:  "Extract files (default: all) from an .arc archive.",
+			setup: func(fs *flag.FlagSet) runFunc {
+				out := fs.String("o", ".", "directory to extract into")
+				return func(o *options, args []string) error {
+					return arcExtract(o, args, *out)
+				}
+			},
+		},
+	)
+}
+
+func arcList(o *options, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: expected exactly one archive", errUsage)
+	}
+	archive, err := arc.Open(args[0])
+	if err != nil {
+		return err
+	}
+
+	files := archive.Files()
+	return o.print(files, func(w io.Writer) {
+		for _, file := range files {
+			fmt.Fprintln(w, file)
+		}
+	})
+}
+
+func arcExtract(o *options, args []string, out string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("%w: expected an archive", errUsage)
+	}
+	archive, err := arc.Open(args[0])
+	if err != nil {
+		return err
+	}
+
+	files := args[1:]
+	if len(files) == 0 {
+		files = archive.Files()
+	}
+	var extracted []string
+	for _, file := range files {
+		data, err := archive.Read(file)
+		if err != nil {
+			return err
+		}
+		if !filepath.IsLocal(filepath.FromSlash(file)) {
+			return fmt.Errorf("refusing to extract '%s' outside of '%s'", file, out)
+		}
+		target := filepath.Join(out, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, data, 0644); err != nil {
+			return err
+		}
+		extracted = append(extracted, target)
+	}
+
+	return o.print(extracted, func(w io.Writer) {
+		fmt.Fprintf(w, "extracted %d files to %s\n", len(extracted), out)
+	})
+}
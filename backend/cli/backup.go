@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/kenranunderscore/grimvault/backend/backup"
+)
+
+func init() {
+	register(
+		&command{
+			group: "backup",
+			name:  "list",
+			args:  "[name]",
+			help:  "List the backed up files, or the backups of one of them, most recent first.",
+			setup: noFlags(backupList),
+		},
+		&command{
+			group: "backup",
+			name:  "restore",
+			args:  "<name> [hash]",
+			help:  "Put a backup (default: the most recent one) back in place, after checking that it decodes. The current file is backed up first.",
+			setup: func(fs *flag.FlagSet) runFunc {
+				to := fs.String("to", "", "file to restore to (default: where the backup was made from)")
+				return func(o *options, args []string) error {
+					return backupRestore(o, args, *to)
+				}
+			},
+		},
+	)
+}
+
+func (o *options) openBackupStore() (*backup.Store, error) {
+	v, err := o.openVault()
+	if err != nil {
+		return nil, err
+	}
+	return o.openBackups(v)
+}
+
+func backupList(o *options, args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("%w: expected at most one name", errUsage)
+	}
+	store, err := o.openBackupStore()
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		names, err := store.Names()
+		if err != nil {
+			return err
+		}
+		return o.print(names, func(w io.Writer) {
+			for _, name := range names {
+				fmt.Fprintln(w, name)
+			}
+		})
+	}
+
+	backups, err := store.List(args[0])
+	if err != nil {
+		return err
+	}
+	if len(backups) == 0 {
+		return fmt.Errorf("%w for '%s'", backup.ErrNoBackups, args[0])
+	}
+	return o.print(backups, func(w io.Writer) {
+		for _, b := range backups {
+			fmt.Fprintf(w, "%s  %s\n", b.Hash, b.Time.Local().Format(time.DateTime))
+		}
+	})
+}
+
+func backupRestore(o *options, args []string, to string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("%w: expected a name and optionally a hash", errUsage)
+	}
+	store, err := o.openBackupStore()
+	if err != nil {
+		return err
+	}
+	hash := ""
+	if len(args) == 2 {
+		hash = args[1]
+	}
+	b, err := store.Find(args[0], hash)
+	if err != nil {
+		return err
+	}
+	if to == "" {
+		to = store.Target(b.Name)
+	}
+	if err := store.Restore(b, to); err != nil {
+		return err
+	}
+	return o.print(b, func(w io.Writer) {
+		fmt.Fprintf(w, "restored %s from %s\n", to, b.Time.Local().Format(time.DateTime))
+	})
+}
@@ -0,0 +1,318 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/kenranunderscore/grimvault/backend/arc"
+	"github.com/kenranunderscore/grimvault/backend/backup"
+	"github.com/kenranunderscore/grimvault/backend/database"
+	"github.com/kenranunderscore/grimvault/backend/locate"
+	"github.com/kenranunderscore/grimvault/backend/resolve"
+	"github.com/kenranunderscore/grimvault/backend/vault"
+)
+
+// Exit codes of `Run`.
+const (
+	ExitOk    = 0
+	ExitError = 1
+	ExitUsage = 2
+)
+
+// Returned by commands when they have been called with wrong arguments.
+var errUsage = errors.New("invalid usage")
+
+// The flags every command understands.
+type options struct {
+	gameDir  string
+	saveDir  string
+	language string
+	format   string
+	vault    string
+	config   string
+	stdout   io.Writer
+	stderr   io.Writer
+}
+
+type runFunc func(o *options, args []string) error
+
+type command struct {
+	group string
+	name  string
+	args  string
+	help  string
+	// Register the command's own flags and return the function running it.
+	// Called anew for every invocation, so flag values are never shared.
+	setup func(fs *flag.FlagSet) runFunc
+}
+
+// The `setup` of a command without flags of its own.
+func noFlags(run runFunc) func(fs *flag.FlagSet) runFunc {
+	return func(fs *flag.FlagSet) runFunc {
+		return run
+	}
+}
+
+func (c *command) title() string {
+	return c.group + " " + c.name
+}
+
+var commands []*command
+
+func register(cmds ...*command) {
+	commands = append(commands, cmds...)
+}
+
+func findCommand(group string, name string) *command {
+	for _, c := range commands {
+		if c.group == group && c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: grimvault <group> <command> [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	sorted := slices.Clone(commands)
+	slices.SortStableFunc(sorted, func(a, b *command) int {
+		return strings.Compare(a.group, b.group)
+	})
+	for _, c := range sorted {
+		fmt.Fprintf(w, "  %-24s %s\n", c.title()+" "+c.args, c.help)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'grimvault <group> <command> -h' to see the flags of a command.")
+}
+
+func (o *options) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.gameDir, "game-dir", "", "Grim Dawn installation directory (default: auto-detect)")
+	fs.StringVar(&o.saveDir, "save-dir", "", "Grim Dawn save directory (default: auto-detect)")
+	fs.StringVar(&o.language, "lang", "EN", "language code of the localization to use")
+	fs.StringVar(&o.format, "format", "text", "output format: text or json")
+	fs.StringVar(&o.vault, "vault", "", "vault file (default: in the user config directory)")
+	fs.StringVar(&o.config, "config", "", "config file with additional search paths")
+}
+
+// Run the command line interface with the given arguments (without the program
+// name), returning the process exit code.
+func Run(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) < 2 {
+		usage(stderr)
+		return ExitUsage
+	}
+
+	c := findCommand(args[0], args[1])
+	if c == nil {
+		fmt.Fprintf(stderr, "unknown command '%s'\n\n", strings.Join(args[:2], " "))
+		usage(stderr)
+		return ExitUsage
+	}
+
+	o := &options{stdout: stdout, stderr: stderr}
+	fs := flag.NewFlagSet("grimvault "+c.title(), flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: grimvault %s [flags] %s\n\n%s\n\nflags:\n", c.title(), c.args, c.help)
+		fs.PrintDefaults()
+	}
+	o.register(fs)
+	run := c.setup(fs)
+	if err := fs.Parse(args[2:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOk
+		}
+		return ExitUsage
+	}
+
+	if o.format != "text" && o.format != "json" {
+		fmt.Fprintf(stderr, "unknown output format '%s'\n", o.format)
+		return ExitUsage
+	}
+
+	if err := run(o, fs.Args()); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(stderr, "%v\n\n", err)
+			fs.Usage()
+			return ExitUsage
+		}
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return ExitError
+	}
+	return ExitOk
+}
+
+// Print `value` as JSON, or using `text` when the output format is text.
+func (o *options) print(value any, text func(w io.Writer)) error {
+	if o.format == "json" {
+		enc := json.NewEncoder(o.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(value)
+	}
+	text(o.stdout)
+	return nil
+}
+
+func (o *options) finder() (*locate.Finder, error) {
+	file := o.config
+	if file == "" {
+		var err error
+		if file, err = locate.DefaultConfigFile(); err != nil {
+			return nil, err
+		}
+	}
+	cfg, err := locate.LoadConfig(file)
+	if err != nil {
+		return nil, err
+	}
+	return locate.NewFinder(cfg)
+}
+
+// The save directory given by flag, or else the first one that can be found.
+func (o *options) findSaveDir() (string, error) {
+	if o.saveDir != "" {
+		return o.saveDir, nil
+	}
+	f, err := o.finder()
+	if err != nil {
+		return "", err
+	}
+	dirs := f.SaveDirs()
+	if len(dirs) == 0 {
+		return "", errors.New("could not find a save directory; use -save-dir")
+	}
+	return dirs[0], nil
+}
+
+// The transfer stash given as argument, or else the softcore stash of the
+// unmodded game.
+func (o *options) findStash(args []string) (string, error) {
+	file, err := o.findStashFile(args)
+	return file.Path, err
+}
+
+// Like `findStash`, but also telling the mode of the stash. Stashes given as
+// argument are hardcore ones if they have the ".gsh" extension of the game.
+func (o *options) findStashFile(args []string) (locate.StashFile, error) {
+	if len(args) > 1 {
+		return locate.StashFile{}, fmt.Errorf("%w: expected at most one stash file", errUsage)
+	}
+	if len(args) == 1 {
+		return locate.StashFile{Path: args[0], Hardcore: strings.EqualFold(filepath.Ext(args[0]), ".gsh")}, nil
+	}
+
+	dir, err := o.findSaveDir()
+	if err != nil {
+		return locate.StashFile{}, err
+	}
+	save, err := locate.ScanSaveDir(dir)
+	if err != nil {
+		return locate.StashFile{}, err
+	}
+	for _, st := range save.Stashes {
+		if st.Mod == "" && !st.Hardcore {
+			return st, nil
+		}
+	}
+	return locate.StashFile{}, fmt.Errorf("no transfer stash in '%s'", dir)
+}
+
+// The game installation given by flag, or else the first one that can be
+// found.
+func (o *options) findInstallation() (locate.Installation, error) {
+	dir := o.gameDir
+	if dir == "" {
+		f, err := o.finder()
+		if err != nil {
+			return locate.Installation{}, err
+		}
+		dirs := f.GameDirs()
+		if len(dirs) == 0 {
+			return locate.Installation{}, errors.New("could not find the game installation; use -game-dir")
+		}
+		dir = dirs[0]
+	}
+	return locate.ScanGameDir(dir)
+}
+
+// Load the given database files, or else those of the game installation.
+func (o *options) loadDatabase(files []string) (*database.Database, error) {
+	if len(files) == 0 {
+		install, err := o.findInstallation()
+		if err != nil {
+			return nil, err
+		}
+		files = install.Databases
+	}
+	return database.Load(files...)
+}
+
+// Load the given localization archives, or else those of the game installation
+// for the selected language.
+func (o *options) loadTags(files []string) (arc.Tags, error) {
+	if len(files) == 0 {
+		install, err := o.findInstallation()
+		if err != nil {
+			return nil, err
+		}
+		files = install.TextArchives(o.language)
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no localization for language '%s' in '%s'", o.language, install.Path)
+		}
+	}
+	return arc.LoadTags(files...)
+}
+
+// The resolver for the game's database and the selected localization.
+func (o *options) loadResolver() (*resolve.Resolver, error) {
+	db, err := o.loadDatabase(nil)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := o.loadTags(nil)
+	if err != nil {
+		return nil, err
+	}
+	return resolve.New(db, tags), nil
+}
+
+func (o *options) openVault() (*vault.Vault, error) {
+	file := o.vault
+	if file == "" {
+		var err error
+		if file, err = vault.DefaultFile(); err != nil {
+			return nil, err
+		}
+	}
+	return vault.Open(file)
+}
+
+// The backups of stash and character files, which are kept next to the vault
+// and named by their path within the save directory, if there is one.
+func (o *options) openBackups(v *vault.Vault) (*backup.Store, error) {
+	saveDir, err := o.findSaveDir()
+	if err != nil {
+		saveDir = ""
+	}
+	return backup.NewStore(filepath.Join(filepath.Dir(v.File), "backups"), saveDir)
+}
+
+// A flag that can be given multiple times.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
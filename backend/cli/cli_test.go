@@ -0,0 +1,344 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kenranunderscore/grimvault/backend/backup"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+	"github.com/kenranunderscore/grimvault/backend/vault"
+)
+
+func run(t *testing.T, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := Run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestUnknownCommand(t *testing.T) {
+	t.Parallel()
+
+	code, _, stderr := run(t, "stash", "burn")
+	if code != ExitUsage {
+		t.Errorf("expected exit code %d, got %d", ExitUsage, code)
+	}
+	if !strings.Contains(stderr, "unknown command") {
+		t.Errorf("expected error message, got %q", stderr)
+	}
+}
+
+func TestStashShowJson(t *testing.T) {
+	t.Parallel()
+
+	code, stdout, stderr := run(t, "stash", "show", "-format", "json", "-tab", "3", "../test_data/stashes/transfer.gst")
+	if code != ExitOk {
+		t.Fatalf("expected success, got %d: %s", code, stderr)
+	}
+
+	var tabs []struct{ Items []struct{ Base string } }
+	if err := json.Unmarshal([]byte(stdout), &tabs); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if len(tabs) != 1 || len(tabs[0].Items) != 99 {
+		t.Errorf("expected a single tab with 99 items, got %v", tabs)
+	}
+}
+
+func TestStashShowMissingFile(t *testing.T) {
+	t.Parallel()
+
+	code, _, stderr := run(t, "stash", "show", "does-not-exist.gst")
+	if code != ExitError {
+		t.Errorf("expected exit code %d, got %d", ExitError, code)
+	}
+	if !strings.HasPrefix(stderr, "error: ") {
+		t.Errorf("expected error message, got %q", stderr)
+	}
+}
+
+func TestVaultImportAndSearch(t *testing.T) {
+	t.Parallel()
+
+	vaultFile := filepath.Join(t.TempDir(), "vault.json")
+	code, _, stderr := run(t, "vault", "import", "-vault", vaultFile, "../test_data/stashes/transfer.gst")
+	if code != ExitOk {
+		t.Fatalf("import failed: %s", stderr)
+	}
+	if _, err := os.Stat(vaultFile); err != nil {
+		t.Fatalf("vault has not been written: %v", err)
+	}
+
+	code, stdout, stderr := run(t, "vault", "search", "-vault", vaultFile, "compa_bristlyfur")
+	if code != ExitOk {
+		t.Fatalf("search failed: %s", stderr)
+	}
+	if !strings.Contains(stdout, "records/items/materia/compa_bristlyfur.dbr") {
+		t.Errorf("expected search result, got %q", stdout)
+	}
+
+	code, stdout, stderr = run(t, "vault", "find", "-vault", vaultFile, "bristlyfr")
+	if code != ExitOk {
+		t.Fatalf("find failed: %s", stderr)
+	}
+	if !strings.Contains(stdout, "records/items/materia/compa_bristlyfur.dbr") {
+		t.Errorf("expected the misspelled search to find the item, got %q", stdout)
+	}
+}
+
+func TestDbGet(t *testing.T) {
+	t.Parallel()
+
+	code, stdout, stderr := run(t, "db", "get", "-file", "../test_data/arz/some.arz", "records/items/gearweapons/caster/b203_scepter.dbr")
+	if code != ExitOk {
+		t.Fatalf("expected success, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "itemNameTag = tagGDX2WeaponCaster1hB203") {
+		t.Errorf("expected record fields, got %q", stdout)
+	}
+}
+
+func TestDbExpand(t *testing.T) {
+	t.Parallel()
+
+	code, stdout, stderr := run(t, "db", "expand", "-file", "../test_data/arz/some.arz", "records/items/gearweapons/caster/b203_scepter.dbr")
+	if code != ExitOk {
+		t.Fatalf("expected success, got %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "  augmentSkillName1 -> records/skills/playerclass03/evileye1.dbr (missing)\n") {
+		t.Errorf("expected the references of the record, got %q", stdout)
+	}
+}
+
+func TestArcExtract(t *testing.T) {
+	t.Parallel()
+
+	out := t.TempDir()
+	code, _, stderr := run(t, "arc", "extract", "-o", out, "../test_data/arc/some.arc", "tags_items.txt")
+	if code != ExitOk {
+		t.Fatalf("expected success, got %d: %s", code, stderr)
+	}
+	data, err := os.ReadFile(filepath.Join(out, "tags_items.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "tagFeetD003=Venomspine Greaves") {
+		t.Errorf("extracted file lacks expected tag")
+	}
+}
+
+func TestTagsLookupUnknownTag(t *testing.T) {
+	t.Parallel()
+
+	code, stdout, _ := run(t, "tags", "lookup", "-file", "../test_data/arc/some.arc", "tagFeetD003", "tagNope")
+	if code != ExitError {
+		t.Errorf("expected exit code %d, got %d", ExitError, code)
+	}
+	if stdout != "tagFeetD003 = Venomspine Greaves\n" {
+		t.Errorf("unexpected output %q", stdout)
+	}
+}
+
+func TestStashExportAndImportJson(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	export := filepath.Join(dir, "transfer.json")
+	code, _, stderr := run(t, "stash", "export", "-o", export, "../test_data/stashes/transfer.gst")
+	if code != ExitOk {
+		t.Fatalf("export failed: %s", stderr)
+	}
+
+	vaultFile := filepath.Join(dir, "vault.json")
+	code, stdout, stderr := run(t, "vault", "import", "-vault", vaultFile, "-format", "json", export)
+	if code != ExitOk {
+		t.Fatalf("import failed: %s", stderr)
+	}
+	var result struct{ Imported int }
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported == 0 {
+		t.Errorf("expected items to be imported from the JSON export")
+	}
+}
+
+func TestVaultImportGds(t *testing.T) {
+	t.Parallel()
+
+	vaultFile := filepath.Join(t.TempDir(), "vault.json")
+	code, stdout, stderr := run(t, "vault", "import", "-vault", vaultFile, "../test_data/example.gds")
+	if code != ExitOk {
+		t.Fatalf("import failed: %s", stderr)
+	}
+	if stdout != "imported 7694 items, the vault now holds 7694 items\n" {
+		t.Errorf("unexpected output %q", stdout)
+	}
+}
+
+func TestVaultExportGdsRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	vaultFile := filepath.Join(dir, "vault.json")
+	if code, _, stderr := run(t, "vault", "import", "-vault", vaultFile, "../test_data/example.gds"); code != ExitOk {
+		t.Fatalf("import failed: %s", stderr)
+	}
+	export := filepath.Join(dir, "export.gds")
+	if code, _, stderr := run(t, "vault", "export", "-vault", vaultFile, export); code != ExitOk {
+		t.Fatalf("export failed: %s", stderr)
+	}
+
+	original, err := os.ReadFile("../test_data/example.gds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exported, err := os.ReadFile(export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(original, exported) {
+		t.Errorf("exported vault differs from the imported GDStash file")
+	}
+}
+
+func TestQuerySaveAndRun(t *testing.T) {
+	t.Parallel()
+
+	vaultFile := filepath.Join(t.TempDir(), "vault.json")
+	if code, _, stderr := run(t, "vault", "import", "-vault", vaultFile, "../test_data/stashes/transfer.gst"); code != ExitOk {
+		t.Fatalf("import failed: %s", stderr)
+	}
+	if code, _, stderr := run(t, "query", "save", "-vault", vaultFile, "fur", "record:bristlyfur"); code != ExitOk {
+		t.Fatalf("save failed: %s", stderr)
+	}
+
+	code, stdout, stderr := run(t, "query", "run", "-vault", vaultFile, "-vault-only", "-format", "json", "@fur")
+	if code != ExitOk {
+		t.Fatalf("query failed: %s", stderr)
+	}
+	var matches []struct{ Source string }
+	if err := json.Unmarshal([]byte(stdout), &matches); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if len(matches) == 0 || matches[0].Source != "vault" {
+		t.Errorf("expected vault items to match, got %v", matches)
+	}
+
+	code, stdout, stderr = run(t, "query", "run", "-vault", vaultFile, "-stash", "../test_data/stashes/transfer.gst", "bristlyfur tab>=1 source:softcore")
+	if code != ExitOk {
+		t.Fatalf("query failed: %s", stderr)
+	}
+	if strings.Contains(stdout, "vault #") || !strings.Contains(stdout, "softcore tab") {
+		t.Errorf("expected only stash items to match, got %q", stdout)
+	}
+}
+
+func TestQuerySyntaxError(t *testing.T) {
+	t.Parallel()
+
+	vaultFile := filepath.Join(t.TempDir(), "vault.json")
+	code, _, stderr := run(t, "query", "save", "-vault", vaultFile, "bad", "level>=high")
+	if code != ExitError {
+		t.Errorf("expected exit code %d, got %d", ExitError, code)
+	}
+	if !strings.Contains(stderr, "level>=high\n       ^^^^") {
+		t.Errorf("expected the error to be marked, got %q", stderr)
+	}
+}
+
+func TestDedupeDiscard(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	data, err := os.ReadFile("../test_data/stashes/transfer.gst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "transfer.gst")
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	vaultFile := filepath.Join(dir, "vault", "vault.json")
+	if code, _, stderr := run(t, "vault", "import", "-vault", vaultFile, file); code != ExitOk {
+		t.Fatalf("import failed: %s", stderr)
+	}
+
+	flags := []string{"-vault", vaultFile, "-save-dir", dir, "-game-dir", dir, "-format", "json"}
+	code, stdout, stderr := run(t, append([]string{"dedupe", "discard", "-dry-run"}, flags...)...)
+	if code != ExitOk {
+		t.Fatalf("dry run failed: %s", stderr)
+	}
+	var planned []struct{ At struct{ Kind string } }
+	if err := json.Unmarshal([]byte(stdout), &planned); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	if len(planned) != 100 || planned[0].At.Kind != "stash" {
+		t.Fatalf("expected the stash copies of all imported items to be discarded, got %d", len(planned))
+	}
+	if after, _ := os.ReadFile(file); !bytes.Equal(after, data) {
+		t.Fatal("expected a dry run not to change the stash")
+	}
+
+	if code, _, stderr := run(t, append([]string{"dedupe", "discard"}, flags...)...); code != ExitOk {
+		t.Fatalf("discard failed: %s", stderr)
+	}
+	st, err := stash.ReadStash(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tab := range st.Tabs {
+		if len(tab.Items) != 0 {
+			t.Errorf("expected tab %d to be empty, got %d items", i+1, len(tab.Items))
+		}
+	}
+	code, stdout, _ = run(t, "vault", "discarded", "-vault", vaultFile, "-format", "json")
+	var discarded []vault.Entry
+	if err := json.Unmarshal([]byte(stdout), &discarded); code != ExitOk || err != nil || len(discarded) != 100 {
+		t.Errorf("expected 100 discarded items, got %d (%v)", len(discarded), err)
+	}
+}
+
+func TestBackupRestore(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	save := filepath.Join(dir, "save")
+	if err := os.MkdirAll(save, 0755); err != nil {
+		t.Fatal(err)
+	}
+	original, err := os.ReadFile("../test_data/stashes/transfer.gst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(save, "transfer.gst")
+	if err := os.WriteFile(file, original, 0644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := backup.NewStore(filepath.Join(dir, "backups"), save)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := store.Backup(file); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte("overwritten"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	flags := []string{"-vault", filepath.Join(dir, "vault.json"), "-save-dir", save}
+	code, stdout, stderr := run(t, append([]string{"backup", "list"}, flags...)...)
+	if code != ExitOk || stdout != "transfer.gst\n" {
+		t.Fatalf("expected the stash to be listed, got %d: %q %s", code, stdout, stderr)
+	}
+	code, _, stderr = run(t, append(append([]string{"backup", "restore"}, flags...), "transfer.gst")...)
+	if code != ExitOk {
+		t.Fatalf("expected success, got %d: %s", code, stderr)
+	}
+	if restored, err := os.ReadFile(file); err != nil || !bytes.Equal(restored, original) {
+		t.Errorf("expected the stash to be restored (%v)", err)
+	}
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kenranunderscore/grimvault/backend/collection"
+	"github.com/kenranunderscore/grimvault/backend/locate"
+	"github.com/kenranunderscore/grimvault/backend/owned"
+)
+
+func init() {
+	register(&command{
+		group: "collection",
+		name:  "report",
+		help:  "List every obtainable item of some rarities by slot, marking the ones that are owned.",
+		setup: func(fs *flag.FlagSet) runFunc {
+			rarities := fs.String("rarity", strings.Join(collection.DefaultRarities, ","), "comma-separated rarities to track")
+			missing := fs.Bool("missing", false, "only list items that are not owned")
+			return func(o *options, args []string) error {
+				return collectionReport(o, args, strings.Split(strings.ToLower(*rarities), ","), *missing)
+			}
+		},
+	})
+}
+
+func collectionReport(o *options, args []string, rarities []string, missing bool) error {
+	if len(args) != 0 {
+		return fmt.Errorf("%w: unexpected arguments", errUsage)
+	}
+	r, err := o.loadResolver()
+	if err != nil {
+		return err
+	}
+	v, err := o.openVault()
+	if err != nil {
+		return err
+	}
+	saveDir, err := o.findSaveDir()
+	if err != nil {
+		return err
+	}
+	save, err := locate.ScanSaveDir(saveDir)
+	if err != nil {
+		return err
+	}
+	items, skipped, err := owned.Collect(v, save)
+	if err != nil {
+		return err
+	}
+	for _, err := range skipped {
+		fmt.Fprintf(o.stderr, "warning: skipping character: %v\n", err)
+	}
+
+	groups := collection.NewCatalogue(r, rarities...).Track(items)
+	if missing {
+		groups = collection.OnlyMissing(groups)
+	}
+	return o.print(groups, func(w io.Writer) {
+		for _, g := range groups {
+			if missing {
+				fmt.Fprintf(w, "%s, %s: %d missing\n", g.Slot, g.Rarity, len(g.Items))
+			} else {
+				fmt.Fprintf(w, "%s, %s: %d/%d\n", g.Slot, g.Rarity, g.Owned, len(g.Items))
+			}
+			for _, entry := range g.Items {
+				mark := " "
+				if entry.Owned {
+					mark = "x"
+				}
+				fmt.Fprintf(w, "  [%s] %s (level %d)\n", mark, entry.Name, entry.Level)
+			}
+		}
+	})
+}
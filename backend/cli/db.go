@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kenranunderscore/grimvault/backend/database"
+)
+
+func init() {
+	register(
+		&command{
+			group: "db",
+			name:  "get",
+			args:  "<record>",
+			help:  "Show all fields of a game database record.",
+			setup: func(fs *flag.FlagSet) runFunc {
+				var files listFlag
+				fs.Var(&files, "file", "database file to read instead of the game's (repeatable)")
+				return func(o *options, args []string) error {
+					return dbGet(o, args, files)
+				}
+			},
+		},
+		&command{
+			group: "db",
+			name:  "expand",
+			args:  "<record>",
+			help:  "Show the records a record refers to, e.g. granted skills, recursively.",
+			setup: func(fs *flag.FlagSet) runFunc {
+				var files listFlag
+				fs.Var(&files, "file", "database file to read instead of the game's (repeatable)")
+				depth := fs.Int("depth", 4, "how many references deep to follow")
+				all := fs.Bool("all", false, "also follow references to sounds and visual effects")
+				return func(o *options, args []string) error {
+					return dbExpand(o, args, files, *depth, *all)
+				}
+			},
+		},
+	)
+}
+
+func dbGet(o *options, args []string, files []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: expected exactly one record path", errUsage)
+	}
+	db, err := o.loadDatabase(files)
+	if err != nil {
+		return err
+	}
+
+	entry, ok := db.Get(args[0])
+	if !ok {
+		return fmt.Errorf("no record '%s' in the database", args[0])
+	}
+	return o.print(entry, func(w io.Writer) {
+		fmt.Fprintln(w, entry.Key)
+		for _, stat := range entry.Stats {
+			fmt.Fprintf(w, "  %s = %v\n", stat.Name, stat.Value)
+		}
+	})
+}
+
+func dbExpand(o *options, args []string, files []string, depth int, all bool) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: expected exactly one record path", errUsage)
+	}
+	db, err := o.loadDatabase(files)
+	if err != nil {
+		return err
+	}
+	if _, ok := db.Get(args[0]); !ok {
+		return fmt.Errorf("no record '%s' in the database", args[0])
+	}
+
+	follow := database.Gameplay
+	if all {
+		follow = nil
+	}
+	root := db.Expand(args[0], depth, follow)
+	return o.print(root, func(w io.Writer) {
+		root.Walk(func(n *database.Node, depth int) {
+			line := strings.Repeat("  ", depth)
+			if n.Field != "" {
+				line += n.Field + " -> "
+			}
+			line += n.Key
+			switch {
+			case n.Missing:
+				line += " (missing)"
+			case n.Cycle:
+				line += " (cycle)"
+			case n.Truncated:
+				line += " ..."
+			}
+			fmt.Fprintln(w, line)
+		})
+	})
+}
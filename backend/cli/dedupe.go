@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/kenranunderscore/grimvault/backend/dedupe"
+	"github.com/kenranunderscore/grimvault/backend/locate"
+	"github.com/kenranunderscore/grimvault/backend/owned"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+	"github.com/kenranunderscore/grimvault/backend/vault"
+)
+
+func init() {
+	register(
+		&command{
+			group: "dedupe",
+			name:  "report",
+			help:  "List exact duplicates (same seed) and near duplicates (other seed) across the vault, stashes and characters.",
+			setup: noFlags(dedupeReport),
+		},
+		&command{
+			group: "dedupe",
+			name:  "discard",
+			help:  "Keep the best copy of each duplicated item and move the rest to the vault's discarded items.",
+			setup: func(fs *flag.FlagSet) runFunc {
+				near := fs.Bool("near", false, "discard near duplicates too, which may have other stats")
+				dryRun := fs.Bool("dry-run", false, "only show what would be discarded")
+				return func(o *options, args []string) error {
+					return dedupeDiscard(o, args, *near, *dryRun)
+				}
+			},
+		},
+	)
+}
+
+func findDuplicates(o *options, v *vault.Vault) (locate.SaveDir, []dedupe.Group, error) {
+	saveDir, err := o.findSaveDir()
+	if err != nil {
+		return locate.SaveDir{}, nil, err
+	}
+	save, err := locate.ScanSaveDir(saveDir)
+	if err != nil {
+		return save, nil, err
+	}
+	items, skipped, err := owned.Collect(v, save)
+	if err != nil {
+		return save, nil, err
+	}
+	for _, err := range skipped {
+		fmt.Fprintf(o.stderr, "warning: skipping character: %v\n", err)
+	}
+	return save, dedupe.Find(items, o.tryResolver()), nil
+}
+
+func dedupeReport(o *options, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("%w: unexpected arguments", errUsage)
+	}
+	v, err := o.openVault()
+	if err != nil {
+		return err
+	}
+	_, groups, err := findDuplicates(o, v)
+	if err != nil {
+		return err
+	}
+	return o.print(groups, func(w io.Writer) {
+		for _, g := range groups {
+			fmt.Fprintf(w, "%s duplicates of %s:\n", g.Kind, itemLine(&g.Copies[0].Item))
+			for i, c := range g.Copies {
+				mark := " "
+				if i == g.Keep {
+					mark = "*"
+				}
+				fmt.Fprintf(w, "  %s %-32s seed %d\n", mark, c.At, c.Item.Seed)
+			}
+		}
+	})
+}
+
+func dedupeDiscard(o *options, args []string, near bool, dryRun bool) error {
+	if len(args) != 0 {
+		return fmt.Errorf("%w: unexpected arguments", errUsage)
+	}
+	v, err := o.openVault()
+	if err != nil {
+		return err
+	}
+	save, groups, err := findDuplicates(o, v)
+	if err != nil {
+		return err
+	}
+	discard := dedupe.Plan(groups, near)
+
+	if !dryRun {
+		backups, err := o.openBackups(v)
+		if err != nil {
+			return err
+		}
+		write := func(file locate.StashFile, st *stash.Stash) error {
+			if _, _, err := backups.Backup(file.Path); err != nil {
+				return err
+			}
+			return stash.WriteStash(file.Path, st)
+		}
+		if err := dedupe.Discard(v, save, discard, write); err != nil {
+			return err
+		}
+	}
+	return o.print(discard, func(w io.Writer) {
+		for _, item := range discard {
+			fmt.Fprintf(w, "%-32s %s\n", item.At, itemLine(&item.Item))
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/kenranunderscore/grimvault/backend/drops"
+)
+
+func init() {
+	register(&command{
+		group: "drops",
+		name:  "sources",
+		args:  "<item-record>",
+		help:  "List the monsters, chests and other sources that drop an item, most likely first.",
+		setup: noFlags(dropsSources),
+	})
+}
+
+func dropsSources(o *options, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: expected exactly one item record", errUsage)
+	}
+	r, err := o.loadResolver()
+	if err != nil {
+		return err
+	}
+
+	sources := drops.NewIndex(r).Sources(args[0])
+	return o.print(sources, func(w io.Writer) {
+		if len(sources) == 0 {
+			fmt.Fprintf(w, "nothing is known to drop %s\n", args[0])
+			return
+		}
+		for _, source := range sources {
+			name := source.Name
+			if name == "" {
+				name = source.Record
+			}
+			if source.Classification != "" {
+				name += " (" + source.Classification + ")"
+			}
+			fmt.Fprintf(w, "%8.4f%%  %s\n", source.Chance*100, name)
+			for _, table := range source.Path {
+				fmt.Fprintf(w, "           via %s\n", table)
+			}
+		}
+	})
+}
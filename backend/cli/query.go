@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/kenranunderscore/grimvault/backend/query"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+func init() {
+	register(
+		&command{
+			group: "query",
+			name:  "run",
+			args:  "<query|@saved>",
+			help:  "Find items in the vault and a transfer stash, e.g. 'rarity:epic slot:ring level>=50'.",
+			setup: func(fs *flag.FlagSet) runFunc {
+				stashFile := fs.String("stash", "", "transfer stash to search (default: the softcore stash)")
+				vaultOnly := fs.Bool("vault-only", false, "only search the vault")
+				return func(o *options, args []string) error {
+					return queryRun(o, args, *stashFile, *vaultOnly)
+				}
+			},
+		},
+		&command{
+			group: "query",
+			name:  "save",
+			args:  "<name> <query>",
+			help:  "Save a query to run it later as @name.",
+			setup: noFlags(querySave),
+		},
+		&command{
+			group: "query",
+			name:  "list",
+			help:  "List the saved queries.",
+			setup: noFlags(queryList),
+		},
+		&command{
+			group: "query",
+			name:  "delete",
+			args:  "<name>",
+			help:  "Delete a saved query.",
+			setup: noFlags(queryDelete),
+		},
+	)
+}
+
+// Parse `text`, showing where syntax errors are.
+func (o *options) parseQuery(text string) (*query.Query, error) {
+	q, err := query.Parse(text)
+	var syntaxErr *query.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		fmt.Fprintln(o.stderr, syntaxErr.Marker())
+	}
+	return q, err
+}
+
+// A query result, with where the item is. `Source` is what `source:`
+// matches: "vault", or the id of the stash, e.g. "softcore".
+type queryMatch struct {
+	Source string
+	Tab    int    `json:",omitempty"`
+	Id     uint64 `json:",omitempty"`
+	Name   string
+	Item   stash.Item
+}
+
+func queryRun(o *options, args []string, stashFile string, vaultOnly bool) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: expected exactly one query", errUsage)
+	}
+	v, err := o.openVault()
+	if err != nil {
+		return err
+	}
+	text := args[0]
+	if name, ok := strings.CutPrefix(text, "@"); ok {
+		if text, ok = v.Searches[name]; !ok {
+			return fmt.Errorf("no saved query '%s'", name)
+		}
+	}
+	q, err := o.parseQuery(text)
+	if err != nil {
+		return err
+	}
+
+	resolver := o.tryResolver()
+	var matches []queryMatch
+	for _, entry := range v.Entries {
+		item := query.Resolve(resolver, &entry.Item)
+		item.Source, item.Owner = "vault", entry.Owner
+		if q.Match(&item) {
+			matches = append(matches, queryMatch{Source: item.Source, Id: entry.Id, Name: item.Name, Item: entry.Item})
+		}
+	}
+
+	if !vaultOnly {
+		var files []string
+		if stashFile != "" {
+			files = append(files, stashFile)
+		}
+		file, err := o.findStashFile(files)
+		if err != nil {
+			return err
+		}
+		st, err := readStash(o, []string{file.Path})
+		if err != nil {
+			return err
+		}
+		// Mod stashes given as argument are only known as such by their
+		// contents.
+		if file.Mod == "" {
+			file.Mod = st.Mod
+		}
+		for i, tab := range st.Tabs {
+			for _, stashItem := range tab.Items {
+				item := query.Resolve(resolver, &stashItem)
+				item.Source, item.Tab = file.Id(), i+1
+				if q.Match(&item) {
+					matches = append(matches, queryMatch{Source: item.Source, Tab: item.Tab, Name: item.Name, Item: stashItem})
+				}
+			}
+		}
+	}
+
+	return o.print(matches, func(w io.Writer) {
+		for _, m := range matches {
+			where := fmt.Sprintf("vault #%d", m.Id)
+			if m.Source != "vault" {
+				where = fmt.Sprintf("%s tab %d", m.Source, m.Tab)
+			}
+			fmt.Fprintf(w, "%-14s %s\n", where, m.Name)
+		}
+	})
+}
+
+func querySave(o *options, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("%w: expected a name and a query", errUsage)
+	}
+	if _, err := o.parseQuery(args[1]); err != nil {
+		return err
+	}
+	v, err := o.openVault()
+	if err != nil {
+		return err
+	}
+	v.Searches[args[0]] = args[1]
+	return v.Save()
+}
+
+func queryList(o *options, args []string) error {
+	v, err := o.openVault()
+	if err != nil {
+		return err
+	}
+	return o.print(v.Searches, func(w io.Writer) {
+		for _, name := range slices.Sorted(maps.Keys(v.Searches)) {
+			fmt.Fprintf(w, "@%s  %s\n", name, v.Searches[name])
+		}
+	})
+}
+
+func queryDelete(o *options, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: expected exactly one name", errUsage)
+	}
+	v, err := o.openVault()
+	if err != nil {
+		return err
+	}
+	if _, ok := v.Searches[args[0]]; !ok {
+		return fmt.Errorf("no saved query '%s'", args[0])
+	}
+	delete(v.Searches, args[0])
+	return v.Save()
+}
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kenranunderscore/grimvault/backend/arc"
+	"github.com/kenranunderscore/grimvault/backend/backup"
+	"github.com/kenranunderscore/grimvault/backend/locate"
+	"github.com/kenranunderscore/grimvault/backend/resolve"
+	"github.com/kenranunderscore/grimvault/backend/server"
+)
+
+func init() {
+	register(&command{
+		group: "server",
+		name:  "run",
+		help:  "Serve the JSON API for the frontend.",
+		setup: func(fs *flag.FlagSet) runFunc {
+			addr := fs.String("addr", "localhost:8080", "address to listen on")
+			languages := fs.String("search-langs", "all", "comma-separated language codes to find vault items by, or 'all'")
+			every := fs.Duration("backup-every", 10*time.Minute, "how often to back up the stash and character files, or 0 for only before writing them")
+			return func(o *options, args []string) error {
+				return serverRun(o, args, *addr, *languages, *every)
+			}
+		},
+	})
+}
+
+// The resolver for item names and details, or nil if the game data cannot be
+// found. The server is still useful without it.
+func (o *options) tryResolver() *resolve.Resolver {
+	r, err := o.loadResolver()
+	if err != nil {
+		fmt.Fprintf(o.stderr, "warning: item names are not available: %v\n", err)
+		return nil
+	}
+	return r
+}
+
+// Resolvers for `base` and the localizations of the given comma-separated
+// languages, or of all of them if it is "all". Languages that cannot be loaded
+// are skipped with a warning. Returns nil if `base` is.
+func (o *options) searchResolvers(base *resolve.Resolver, languages string) []*resolve.Resolver {
+	if base == nil {
+		return nil
+	}
+	resolvers := []*resolve.Resolver{base}
+	if languages == "" {
+		return resolvers
+	}
+	install, err := o.findInstallation()
+	if err != nil {
+		fmt.Fprintf(o.stderr, "warning: only searching in '%s': %v\n", o.language, err)
+		return resolvers
+	}
+	codes := install.Languages()
+	if languages != "all" {
+		codes = strings.Split(languages, ",")
+	}
+	for _, code := range codes {
+		if strings.EqualFold(code, o.language) {
+			continue
+		}
+		files := install.TextArchives(code)
+		if len(files) == 0 {
+			fmt.Fprintf(o.stderr, "warning: no localization for language '%s'\n", code)
+			continue
+		}
+		tags, err := arc.LoadTags(files...)
+		if err != nil {
+			fmt.Fprintf(o.stderr, "warning: could not load language '%s': %v\n", code, err)
+			continue
+		}
+		resolvers = append(resolvers, resolve.New(base.DB, tags))
+	}
+	return resolvers
+}
+
+func serverRun(o *options, args []string, addr string, languages string, every time.Duration) error {
+	if len(args) != 0 {
+		return fmt.Errorf("%w: unexpected arguments", errUsage)
+	}
+	saveDir, err := o.findSaveDir()
+	if err != nil {
+		return err
+	}
+	v, err := o.openVault()
+	if err != nil {
+		return err
+	}
+	backups, err := o.openBackups(v)
+	if err != nil {
+		return err
+	}
+
+	resolver := o.tryResolver()
+	s := server.New(saveDir, v, resolver, backups)
+	if resolvers := o.searchResolvers(resolver, languages); len(resolvers) > 1 {
+		s.IndexLanguages(resolvers...)
+	}
+	go s.Watch(context.Background(), time.Second)
+	if every > 0 {
+		if err := o.watchBackups(backups, saveDir, every); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(o.stderr, "serving %s on http://%s\n", saveDir, addr)
+	return http.ListenAndServe(addr, s)
+}
+
+// Back up the stash and character files of `saveDir` every `interval` in the
+// background, as the game writes them without grimvault noticing.
+func (o *options) watchBackups(backups *backup.Store, saveDir string, interval time.Duration) error {
+	save, err := locate.ScanSaveDir(saveDir)
+	if err != nil {
+		return err
+	}
+	var files []string
+	for _, file := range save.Stashes {
+		files = append(files, file.Path)
+	}
+	for _, character := range save.Characters {
+		files = append(files, character.Path)
+	}
+	report := func(err error) {
+		fmt.Fprintf(o.stderr, "warning: %v\n", err)
+	}
+	go backups.Watch(context.Background(), interval, report, files...)
+	return nil
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/kenranunderscore/grimvault/backend/locate"
+	"github.com/kenranunderscore/grimvault/backend/owned"
+	"github.com/kenranunderscore/grimvault/backend/sets"
+)
+
+func init() {
+	register(&command{
+		group: "sets",
+		name:  "report",
+		help:  "Show which pieces of item sets are owned, where they are, and which are missing.",
+		setup: func(fs *flag.FlagSet) runFunc {
+			incomplete := fs.Bool("incomplete", false, "only show sets that are not complete")
+			return func(o *options, args []string) error {
+				return setsReport(o, args, *incomplete)
+			}
+		},
+	})
+}
+
+func setsReport(o *options, args []string, incomplete bool) error {
+	if len(args) != 0 {
+		return fmt.Errorf("%w: unexpected arguments", errUsage)
+	}
+	r, err := o.loadResolver()
+	if err != nil {
+		return err
+	}
+	v, err := o.openVault()
+	if err != nil {
+		return err
+	}
+	saveDir, err := o.findSaveDir()
+	if err != nil {
+		return err
+	}
+	save, err := locate.ScanSaveDir(saveDir)
+	if err != nil {
+		return err
+	}
+	owned, skipped, err := owned.Collect(v, save)
+	if err != nil {
+		return err
+	}
+	for _, err := range skipped {
+		fmt.Fprintf(o.stderr, "warning: skipping character: %v\n", err)
+	}
+
+	var report []sets.Completion
+	for _, c := range sets.NewIndex(r).Report(owned, r.BaseName) {
+		if !incomplete || !c.Complete() {
+			report = append(report, c)
+		}
+	}
+	return o.print(report, func(w io.Writer) {
+		for _, c := range report {
+			fmt.Fprintf(w, "%s (%d/%d)\n", c.Set.Name, len(c.Owned), len(c.Set.Members))
+			for _, piece := range c.Owned {
+				fmt.Fprintf(w, "  + %s\n", piece.Name)
+				for _, at := range piece.Locations {
+					fmt.Fprintf(w, "      %s\n", at)
+				}
+			}
+			for _, piece := range c.Missing {
+				fmt.Fprintf(w, "  - %s\n", piece.Name)
+			}
+		}
+	})
+}
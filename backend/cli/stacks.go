@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/kenranunderscore/grimvault/backend/stacks"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+func init() {
+	register(&command{
+		group: "stacks",
+		name:  "consolidate",
+		args:  "[stash-file]",
+		help:  "Merge the stacks of the same materials and components in a transfer stash and the vault, up to the game's stack sizes.",
+		setup: func(fs *flag.FlagSet) runFunc {
+			base := fs.String("base", "", "only merge stacks of this record")
+			return func(o *options, args []string) error {
+				return stacksConsolidate(o, args, *base)
+			}
+		},
+	})
+}
+
+func stacksConsolidate(o *options, args []string, base string) error {
+	file, err := o.findStash(args)
+	if err != nil {
+		return err
+	}
+	st, err := stash.ReadStash(file)
+	if err != nil {
+		return err
+	}
+	r, err := o.loadResolver()
+	if err != nil {
+		return err
+	}
+	v, err := o.openVault()
+	if err != nil {
+		return err
+	}
+	backups, err := o.openBackups(v)
+	if err != nil {
+		return err
+	}
+
+	hardcore := strings.ToLower(filepath.Ext(file)) == ".gsh"
+	res := stacks.Consolidate(st, v, hardcore, r.MaxStack, base)
+	// The stash is written first, so that items are at worst duplicated if
+	// the vault cannot be saved, but never lost.
+	if _, _, err := backups.Backup(file); err != nil {
+		return err
+	}
+	if err := stash.WriteStash(file, st); err != nil {
+		return err
+	}
+	if err := v.Save(); err != nil {
+		return err
+	}
+	return o.print(res, func(w io.Writer) {
+		fmt.Fprintf(w, "freed %d stacks\n", res.Freed)
+		for _, entry := range res.Removed {
+			fmt.Fprintf(w, "  emptied vault item %d: %s\n", entry.Id, itemLine(&entry.Item))
+		}
+	})
+}
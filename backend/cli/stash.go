@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kenranunderscore/grimvault/backend/resolve"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+	"github.com/kenranunderscore/grimvault/backend/stashjson"
+)
+
+func init() {
+	register(
+		&command{
+			group: "stash",
+			name:  "show",
+			args:  "[stash-file]",
+			help:  "List the items in a transfer stash.",
+			setup: func(fs *flag.FlagSet) runFunc {
+				tab := fs.Int("tab", 0, "only show this tab (1-based); 0 shows all tabs")
+				return func(o *options, args []string) error {
+					return stashShow(o, args, *tab)
+				}
+			},
+		},
+		&command{
+			group: "stash",
+			name:  "export",
+			args:  "[stash-file]",
+			help:  "Export a transfer stash as JSON.",
+			setup: func(fs *flag.FlagSet) runFunc {
+				out := fs.String("o", "", "output file (default: standard output)")
+				names := fs.Bool("names", false, "include localized item names (needs the game data)")
+				return func(o *options, args []string) error {
+					return stashExport(o, args, *out, *names)
+				}
+			},
+		},
+	)
+}
+
+// Read the stash given as argument, either a stash file or a JSON export.
+func readStash(o *options, args []string) (*stash.Stash, error) {
+	file, err := o.findStash(args)
+	if err != nil {
+		return nil, err
+	}
+	if strings.ToLower(filepath.Ext(file)) == ".json" {
+		return stashjson.ReadFile(file)
+	}
+	return stash.ReadStash(file)
+}
+
+// A single line describing `item`.
+func itemLine(item *stash.Item) string {
+	line := item.Base
+	if item.Prefix != "" {
+		line += " prefix=" + item.Prefix
+	}
+	if item.Suffix != "" {
+		line += " suffix=" + item.Suffix
+	}
+	if item.StackSize > 1 {
+		line += fmt.Sprintf(" x%d", item.StackSize)
+	}
+	return line
+}
+
+func stashShow(o *options, args []string, tab int) error {
+	st, err := readStash(o, args)
+	if err != nil {
+		return err
+	}
+
+	first := 0
+	tabs := st.Tabs
+	if tab != 0 {
+		if tab < 0 || tab > len(st.Tabs) {
+			return fmt.Errorf("%w: tab %d does not exist, the stash has %d tabs", errUsage, tab, len(st.Tabs))
+		}
+		first = tab - 1
+		tabs = st.Tabs[first:tab]
+	}
+
+	return o.print(tabs, func(w io.Writer) {
+		for i, t := range tabs {
+			fmt.Fprintf(w, "Tab %d (%dx%d, %d items)\n", first+i+1, t.Width, t.Height, len(t.Items))
+			for _, item := range t.Items {
+				fmt.Fprintf(w, "  %s\n", itemLine(&item))
+			}
+		}
+	})
+}
+
+func stashExport(o *options, args []string, out string, names bool) error {
+	st, err := readStash(o, args)
+	if err != nil {
+		return err
+	}
+
+	var namer stashjson.Namer
+	if names {
+		db, err := o.loadDatabase(nil)
+		if err != nil {
+			return err
+		}
+		tags, err := o.loadTags(nil)
+		if err != nil {
+			return err
+		}
+		namer = resolve.New(db, tags).Name
+	}
+
+	if out == "" {
+		return stashjson.Write(o.stdout, st, namer)
+	}
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	if err := stashjson.Write(f, st, namer); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
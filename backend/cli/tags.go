@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+)
+
+func init() {
+	register(&command{
+		group: "tags",
+		name:  "lookup",
+		args:  "<tag...>",
+		help:  "Show the localized texts of tags.",
+		setup: func(fs *flag.FlagSet) runFunc {
+			var files listFlag
+			fs.Var(&files, "file", "localization archive to read instead of the game's (repeatable)")
+			return func(o *options, args []string) error {
+				return tagsLookup(o, args, files)
+			}
+		},
+	})
+}
+
+func tagsLookup(o *options, args []string, files []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: expected at least one tag", errUsage)
+	}
+	tags, err := o.loadTags(files)
+	if err != nil {
+		return err
+	}
+
+	found := make(map[string]string, len(args))
+	var missing []string
+	for _, tag := range args {
+		if name, ok := tags.Lookup(tag); ok {
+			found[tag] = name
+		} else {
+			missing = append(missing, tag)
+		}
+	}
+
+	err = o.print(found, func(w io.Writer) {
+		for _, tag := range args {
+			if name, ok := found[tag]; ok {
+				fmt.Fprintf(w, "%s = %s\n", tag, name)
+			}
+		}
+	})
+	if err == nil && len(missing) > 0 {
+		err = fmt.Errorf("unknown tags: %v", missing)
+	}
+	return err
+}
@@ -0,0 +1,424 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kenranunderscore/grimvault/backend/affixes"
+	"github.com/kenranunderscore/grimvault/backend/gds"
+	"github.com/kenranunderscore/grimvault/backend/search"
+	"github.com/kenranunderscore/grimvault/backend/sets"
+	"github.com/kenranunderscore/grimvault/backend/sharecode"
+	"github.com/kenranunderscore/grimvault/backend/tooltip"
+	"github.com/kenranunderscore/grimvault/backend/validate"
+	"github.com/kenranunderscore/grimvault/backend/vault"
+)
+
+func init() {
+	register(
+		&command{
+			group: "vault",
+			name:  "import",
+			args:  "[stash-file]",
+			help:  "Copy all items of a stash, JSON export or GDStash export into the vault.",
+			setup: func(fs *flag.FlagSet) runFunc {
+				strict := fs.Bool("strict", false, "leave out items the game would not produce, which needs the game data")
+				return func(o *options, args []string) error {
+					return vaultImport(o, args, *strict)
+				}
+			},
+		},
+		&command{
+			group: "vault",
+			name:  "export",
+			args:  "<file.gds>",
+			help:  "Write all vault items to a GDStash export.",
+			setup: noFlags(vaultExport),
+		},
+		&command{
+			group: "vault",
+			name:  "share",
+			args:  "<id>",
+			help:  "Print a shareable code for a vault item.",
+			setup: noFlags(vaultShare),
+		},
+		&command{
+			group: "vault",
+			name:  "redeem",
+			args:  "<code>",
+			help:  "Add the item of a shared code to the vault.",
+			setup: noFlags(vaultRedeem),
+		},
+		&command{
+			group: "vault",
+			name:  "search",
+			args:  "<text>",
+			help:  "Find vault items whose records contain the given text.",
+			setup: noFlags(vaultSearch),
+		},
+		&command{
+			group: "vault",
+			name:  "discarded",
+			help:  "List the discarded items, e.g. duplicates.",
+			setup: noFlags(vaultDiscarded),
+		},
+		&command{
+			group: "vault",
+			name:  "restore",
+			args:  "<id>",
+			help:  "Move a discarded item back into the vault.",
+			setup: noFlags(vaultRestore),
+		},
+		&command{
+			group: "vault",
+			name:  "find",
+			args:  "<text>",
+			help:  "Find vault items by name, allowing for typos, e.g. 'stonebrker'.",
+			setup: func(fs *flag.FlagSet) runFunc {
+				languages := fs.String("search-langs", "", "comma-separated additional language codes to search in, or 'all'")
+				return func(o *options, args []string) error {
+					return vaultFind(o, args, *languages)
+				}
+			},
+		},
+		&command{
+			group: "vault",
+			name:  "tooltip",
+			args:  "<id>",
+			help:  "Show the tooltip of a vault item the way the game does.",
+			setup: func(fs *flag.FlagSet) runFunc {
+				color := fs.Bool("color", false, "colour the tooltip with ANSI escape sequences")
+				return func(o *options, args []string) error {
+					return vaultTooltip(o, args, *color)
+				}
+			},
+		},
+		&command{
+			group: "vault",
+			name:  "check",
+			args:  "[id]",
+			help:  "List what is wrong with a vault item (default: all items), e.g. affixes that do not roll on its base.",
+			setup: noFlags(vaultCheck),
+		},
+	)
+}
+
+func vaultImport(o *options, args []string, strict bool) error {
+	file, err := o.findStash(args)
+	if err != nil {
+		return err
+	}
+	entries, err := readImport(o, file)
+	if err != nil {
+		return err
+	}
+	var check *validate.Validator
+	if strict {
+		r, err := o.loadResolver()
+		if err != nil {
+			return err
+		}
+		check = validate.New(r, affixes.NewIndex(r))
+	}
+	v, err := o.openVault()
+	if err != nil {
+		return err
+	}
+
+	result := struct {
+		Imported int
+		Rejected int
+		Total    int
+	}{}
+	for _, entry := range entries {
+		if check != nil && validate.HasErrors(check.Check(&entry.Item)) {
+			result.Rejected++
+			continue
+		}
+		v.Insert(entry)
+		result.Imported++
+	}
+	if err := v.Save(); err != nil {
+		return err
+	}
+
+	result.Total = len(v.Entries)
+	return o.print(result, func(w io.Writer) {
+		fmt.Fprintf(w, "imported %d items, the vault now holds %d items\n", result.Imported, result.Total)
+		if result.Rejected > 0 {
+			fmt.Fprintf(w, "left out %d items the game would not produce\n", result.Rejected)
+		}
+	})
+}
+
+// Read the items to import from `file`, which is either a stash, a JSON export
+// or a GDStash export.
+func readImport(o *options, file string) ([]vault.Entry, error) {
+	source := filepath.Base(file)
+	var entries []vault.Entry
+	if strings.ToLower(filepath.Ext(file)) == ".gds" {
+		items, err := gds.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			entries = append(entries, vault.Entry{Item: item.Item, Source: source, Owner: item.Owner, Hardcore: item.Hardcore})
+		}
+		return entries, nil
+	}
+
+	st, err := readStash(o, []string{file})
+	if err != nil {
+		return nil, err
+	}
+	for _, tab := range st.Tabs {
+		for _, item := range tab.Items {
+			entries = append(entries, vault.Entry{Item: item, Source: source})
+		}
+	}
+	return entries, nil
+}
+
+func vaultExport(o *options, args []string) error {
+	if len(args) != 1 || strings.ToLower(filepath.Ext(args[0])) != ".gds" {
+		return fmt.Errorf("%w: expected a single .gds file", errUsage)
+	}
+	v, err := o.openVault()
+	if err != nil {
+		return err
+	}
+
+	entries := make([]gds.Entry, 0, len(v.Entries))
+	for _, entry := range v.Entries {
+		entries = append(entries, gds.Entry{Item: entry.Item, Owner: entry.Owner, Hardcore: entry.Hardcore})
+	}
+	if err := gds.WriteFile(args[0], entries); err != nil {
+		return err
+	}
+	return o.print(len(entries), func(w io.Writer) {
+		fmt.Fprintf(w, "exported %d items to %s\n", len(entries), args[0])
+	})
+}
+
+func vaultShare(o *options, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: expected exactly one item id", errUsage)
+	}
+	id, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid item id '%s'", errUsage, args[0])
+	}
+	v, err := o.openVault()
+	if err != nil {
+		return err
+	}
+	entry, ok := v.Get(id)
+	if !ok {
+		return fmt.Errorf("no item with id %d in the vault", id)
+	}
+	db, err := o.loadDatabase(nil)
+	if err != nil {
+		return err
+	}
+
+	code, err := sharecode.NewTable(db).Encode(&entry.Item)
+	if err != nil {
+		return err
+	}
+	return o.print(code, func(w io.Writer) {
+		fmt.Fprintln(w, code)
+	})
+}
+
+func vaultRedeem(o *options, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: expected exactly one code", errUsage)
+	}
+	db, err := o.loadDatabase(nil)
+	if err != nil {
+		return err
+	}
+	item, err := sharecode.NewTable(db).Decode(args[0])
+	if err != nil {
+		return err
+	}
+	v, err := o.openVault()
+	if err != nil {
+		return err
+	}
+
+	entry := v.Add(item, "share code")
+	if err := v.Save(); err != nil {
+		return err
+	}
+	return o.print(entry, func(w io.Writer) {
+		fmt.Fprintf(w, "%6d  %s\n", entry.Id, itemLine(&entry.Item))
+	})
+}
+
+func vaultSearch(o *options, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: expected exactly one search text", errUsage)
+	}
+	v, err := o.openVault()
+	if err != nil {
+		return err
+	}
+
+	found := v.Search(args[0])
+	return o.print(found, func(w io.Writer) {
+		for _, entry := range found {
+			fmt.Fprintf(w, "%6d  %s\n", entry.Id, itemLine(&entry.Item))
+		}
+	})
+}
+
+func vaultFind(o *options, args []string, languages string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: expected exactly one search text", errUsage)
+	}
+	v, err := o.openVault()
+	if err != nil {
+		return err
+	}
+
+	resolver := o.tryResolver()
+	ix := search.NewItemIndex(o.searchResolvers(resolver, languages)...)
+	for i := range v.Entries {
+		ix.AddItem(v.Entries[i].Id, &v.Entries[i].Item)
+	}
+	var found []vault.Entry
+	for _, result := range ix.Search(args[0]) {
+		if entry, ok := v.Get(result.Id); ok {
+			found = append(found, entry)
+		}
+	}
+	return o.print(found, func(w io.Writer) {
+		for _, entry := range found {
+			name := itemLine(&entry.Item)
+			if resolver != nil {
+				name = resolver.Name(&entry.Item)
+			}
+			fmt.Fprintf(w, "%6d  %s\n", entry.Id, name)
+		}
+	})
+}
+
+func vaultTooltip(o *options, args []string, color bool) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: expected exactly one item id", errUsage)
+	}
+	id, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid item id '%s'", errUsage, args[0])
+	}
+	v, err := o.openVault()
+	if err != nil {
+		return err
+	}
+	entry, ok := v.Get(id)
+	if !ok {
+		return fmt.Errorf("no item with id %d in the vault", id)
+	}
+	r, err := o.loadResolver()
+	if err != nil {
+		return err
+	}
+
+	t := tooltip.New(r, sets.NewIndex(r)).Build(&entry.Item)
+	return o.print(t, func(w io.Writer) {
+		if color {
+			fmt.Fprint(w, t.ANSI())
+		} else {
+			fmt.Fprint(w, t.Text())
+		}
+	})
+}
+
+func vaultDiscarded(o *options, args []string) error {
+	if len(args) != 0 {
+		return fmt.Errorf("%w: unexpected arguments", errUsage)
+	}
+	v, err := o.openVault()
+	if err != nil {
+		return err
+	}
+	return o.print(v.Discarded, func(w io.Writer) {
+		for _, entry := range v.Discarded {
+			fmt.Fprintf(w, "%6d  %s\n", entry.Id, itemLine(&entry.Item))
+		}
+	})
+}
+
+func vaultRestore(o *options, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: expected exactly one item id", errUsage)
+	}
+	id, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid item id '%s'", errUsage, args[0])
+	}
+	v, err := o.openVault()
+	if err != nil {
+		return err
+	}
+	if _, ok := v.Restore(id); !ok {
+		return fmt.Errorf("no discarded item with id %d", id)
+	}
+	return v.Save()
+}
+
+func vaultCheck(o *options, args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("%w: expected at most one item id", errUsage)
+	}
+	v, err := o.openVault()
+	if err != nil {
+		return err
+	}
+	entries := v.Entries
+	if len(args) == 1 {
+		id, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: invalid item id '%s'", errUsage, args[0])
+		}
+		entry, ok := v.Get(id)
+		if !ok {
+			return fmt.Errorf("no item with id %d in the vault", id)
+		}
+		entries = []vault.Entry{entry}
+	}
+	r, err := o.loadResolver()
+	if err != nil {
+		return err
+	}
+
+	check := validate.New(r, affixes.NewIndex(r))
+	type itemCheck struct {
+		Id       uint64
+		Findings []validate.Finding
+	}
+	var checks []itemCheck
+	for _, entry := range entries {
+		if findings := check.Check(&entry.Item); len(findings) > 0 {
+			checks = append(checks, itemCheck{entry.Id, findings})
+		}
+	}
+	return o.print(checks, func(w io.Writer) {
+		if len(checks) == 0 {
+			fmt.Fprintln(w, "found nothing wrong")
+			return
+		}
+		for _, c := range checks {
+			entry, _ := v.Get(c.Id)
+			fmt.Fprintf(w, "%6d  %s\n", c.Id, r.Name(&entry.Item))
+			for _, f := range c.Findings {
+				fmt.Fprintf(w, "        %s: %s\n", f.Severity, f.Message)
+			}
+		}
+	})
+}
@@ -0,0 +1,128 @@
+// Generates the TypeScript declarations of the types the frontend receives
+// from the API.
+package main
+
+//go:generate go run . -o ../../../frontend/src/api/types.ts
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/kenranunderscore/grimvault/backend/affixes"
+	"github.com/kenranunderscore/grimvault/backend/collection"
+	"github.com/kenranunderscore/grimvault/backend/database"
+	"github.com/kenranunderscore/grimvault/backend/dedupe"
+	"github.com/kenranunderscore/grimvault/backend/drops"
+	"github.com/kenranunderscore/grimvault/backend/editor"
+	"github.com/kenranunderscore/grimvault/backend/owned"
+	"github.com/kenranunderscore/grimvault/backend/resolve"
+	"github.com/kenranunderscore/grimvault/backend/server"
+	"github.com/kenranunderscore/grimvault/backend/sets"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+	"github.com/kenranunderscore/grimvault/backend/stashjson"
+	"github.com/kenranunderscore/grimvault/backend/tooltip"
+	"github.com/kenranunderscore/grimvault/backend/tsgen"
+	"github.com/kenranunderscore/grimvault/backend/validate"
+	"github.com/kenranunderscore/grimvault/backend/vault"
+)
+
+// Types are added before the types using them, and named after their Go
+// names unless those clash.
+func generate() (string, error) {
+	g := tsgen.New()
+
+	g.Add("Item", stash.Item{})
+	g.Add("StashTab", stash.StashTab{})
+	g.Add("Stash", stash.Stash{})
+
+	g.Add("ExportItem", stashjson.Item{})
+	g.Add("ExportTab", stashjson.Tab{})
+	g.Add("ExportDocument", stashjson.Document{})
+
+	g.Add("DatabaseStat", database.Stat{})
+	g.Add("DatabaseEntry", database.Entry{})
+
+	g.Add("VaultEntry", vault.Entry{})
+
+	g.Add("ItemStat", resolve.Stat{})
+	g.Add("Details", resolve.Details{})
+	g.Add("Requirements", resolve.Requirements{})
+
+	g.Add("ItemSet", sets.Set{})
+	g.Add("ItemLocation", owned.Location{})
+	g.Add("SetPiece", sets.Piece{})
+	g.Add("SetCompletion", sets.Completion{})
+
+	g.Add("CollectionItem", collection.Item{})
+	g.Add("CollectionEntry", collection.Entry{})
+	g.Add("CollectionGroup", collection.Group{})
+
+	g.Add("OwnedItem", owned.Item{})
+	g.Add("DuplicateGroup", dedupe.Group{})
+
+	g.Add("AffixRoll", affixes.Roll{})
+	g.Add("AffixPool", affixes.Pool{})
+	g.Add("AffixSource", affixes.Source{})
+	g.Add("DropSource", drops.Source{})
+	g.Add("Finding", validate.Finding{})
+	g.Add("EditorChoice", editor.Choice{})
+	g.Add("EditorOptions", editor.Options{})
+	g.Add("ItemSpec", editor.Spec{})
+
+	g.Add("TooltipSection", tooltip.Section{})
+	g.Add("SetBonus", tooltip.SetBonus{})
+	g.Add("SetInfo", tooltip.SetInfo{})
+	g.Add("Tooltip", tooltip.Tooltip{})
+
+	g.Add("StashInfo", server.StashInfo{})
+	g.Add("ItemDetails", server.ItemDetails{})
+	g.Add("VaultItem", server.VaultItem{})
+	g.Add("ToVaultRequest", server.ToVaultRequest{})
+	g.Add("ToStashRequest", server.ToStashRequest{})
+	g.Add("StashLocation", server.Location{})
+	g.Add("ItemEvent", server.ItemEvent{})
+	g.Add("ItemPosition", server.ItemPosition{})
+	g.Add("SearchResult", server.SearchResult{})
+	g.Add("SavedSearch", server.SavedSearch{})
+	g.Add("SyntaxErrorPosition", server.SyntaxErrorPosition{})
+	g.Add("DiscardRequest", server.DiscardRequest{})
+	g.Add("DiscardResult", server.DiscardResult{})
+	g.Add("TooltipResponse", server.TooltipResponse{})
+	g.Add("ItemOdds", server.ItemOdds{})
+	g.Add("ItemCheck", server.ItemCheck{})
+	g.Add("CreateItemRequest", server.CreateItemRequest{})
+	g.Add("ConsolidateRequest", server.ConsolidateRequest{})
+	g.Add("ConsolidateResult", server.ConsolidateResult{})
+	g.Add("SplitRequest", server.SplitRequest{})
+	g.Add("ErrorBody", server.ErrorBody{})
+	g.AddUnion("EventType",
+		server.EventItemAdded,
+		server.EventItemRemoved,
+		server.EventItemMoved,
+		server.EventVaultAdded,
+		server.EventVaultRemoved,
+		server.EventReset,
+	)
+
+	return g.Generate()
+}
+
+func main() {
+	out := flag.String("o", "", "output file (default: standard output)")
+	flag.Parse()
+
+	ts, err := generate()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+	if *out == "" {
+		fmt.Print(ts)
+		return
+	}
+	if err := os.WriteFile(*out, []byte(ts), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
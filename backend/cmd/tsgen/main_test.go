@@ -0,0 +1,24 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+// The checked in declarations have to be regenerated whenever the API types
+// change.
+func TestGeneratedTypesAreUpToDate(t *testing.T) {
+	t.Parallel()
+
+	ts, err := generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := os.ReadFile("../../../frontend/src/api/types.ts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(current) != ts {
+		t.Errorf("frontend/src/api/types.ts is outdated, run 'go generate ./...' in backend")
+	}
+}
@@ -0,0 +1,164 @@
+package collection
+
+import (
+	"cmp"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/kenranunderscore/grimvault/backend/database"
+	"github.com/kenranunderscore/grimvault/backend/owned"
+	"github.com/kenranunderscore/grimvault/backend/resolve"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+// The rarities tracked unless others are asked for. Epic items are what
+// players call uniques.
+var DefaultRarities = []string{"epic", "legendary"}
+
+// A named item that can be obtained in game. Items the game has several
+// records of, e.g. one per difficulty, are listed once with all of them.
+type Item struct {
+	Name    string   `json:"name"`
+	Slot    string   `json:"slot"`
+	Rarity  string   `json:"rarity"`
+	Level   uint32   `json:"level"`
+	Records []string `json:"records"`
+}
+
+// Every obtainable item of some rarities.
+type Catalogue struct {
+	Items    []Item
+	byRecord map[string]int
+}
+
+// Directories of records that never drop: test items, and those left over
+// from earlier versions of the game.
+var unused = []string{"test", "testing", "old", "unused", "deprecated", "obsolete", "dev"}
+
+// Whether `record` looks like a test item or one the game does not use.
+func isUnused(record string) bool {
+	dir, file := path.Split(record)
+	for _, part := range strings.Split(dir, "/") {
+		if slices.Contains(unused, part) {
+			return true
+		}
+	}
+	return strings.HasPrefix(file, "test")
+}
+
+// List the items of the given rarities in the database of `r`, e.g. "epic"
+// and "legendary". Items need a slot and a localized name, which filters out
+// most records the game does not use; the rest are recognized by their path.
+func NewCatalogue(r *resolve.Resolver, rarities ...string) *Catalogue {
+	c := &Catalogue{byRecord: make(map[string]int)}
+	type identity struct{ name, slot, rarity string }
+	byName := make(map[identity]int)
+	for _, key := range r.DB.Keys() {
+		if !strings.HasPrefix(key, "records/items/") || isUnused(key) {
+			continue
+		}
+		entry := r.DB.Entries[key]
+		slot := resolve.Slot(entry.String("Class"))
+		rarity := strings.ToLower(entry.String("itemClassification"))
+		// Blueprints share the rarity of what they craft.
+		if slot == "" || slot == "blueprint" || !slices.Contains(rarities, rarity) {
+			continue
+		}
+		if r.BaseName(key) == "" {
+			continue
+		}
+
+		name := r.Name(&stash.Item{Base: key})
+		level := r.Requirements(&stash.Item{Base: key}).Level
+		id := identity{name, slot, rarity}
+		i, ok := byName[id]
+		if !ok {
+			i = len(c.Items)
+			byName[id] = i
+			c.Items = append(c.Items, Item{Name: name, Slot: slot, Rarity: rarity, Level: level})
+		}
+		item := &c.Items[i]
+		item.Level = min(item.Level, level)
+		item.Records = append(item.Records, key)
+		c.byRecord[key] = i
+	}
+	return c
+}
+
+// A catalogue item, and where the user has it.
+type Entry struct {
+	Item
+	Owned     bool             `json:"owned"`
+	Locations []owned.Location `json:"locations"`
+}
+
+// The items of one slot and rarity.
+type Group struct {
+	Slot   string  `json:"slot"`
+	Rarity string  `json:"rarity"`
+	Owned  int     `json:"owned"`
+	Items  []Entry `json:"items"`
+}
+
+// Mark the catalogue items the user owns, grouped by slot and by rarity from
+// highest to lowest. Items within a group are sorted by name.
+func (c *Catalogue) Track(items []owned.Item) []Group {
+	entries := make([]Entry, len(c.Items))
+	for i, item := range c.Items {
+		entries[i] = Entry{Item: item, Locations: []owned.Location{}}
+	}
+	for _, o := range items {
+		if i, ok := c.byRecord[database.NormalizeKey(o.Item.Base)]; ok {
+			entries[i].Owned = true
+			entries[i].Locations = append(entries[i].Locations, o.At)
+		}
+	}
+
+	var groups []Group
+	index := make(map[[2]string]int)
+	for _, entry := range entries {
+		key := [2]string{entry.Slot, entry.Rarity}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, Group{Slot: entry.Slot, Rarity: entry.Rarity})
+		}
+		g := &groups[i]
+		g.Items = append(g.Items, entry)
+		if entry.Owned {
+			g.Owned++
+		}
+	}
+
+	for _, g := range groups {
+		slices.SortFunc(g.Items, func(a, b Entry) int {
+			return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.Records[0], b.Records[0]))
+		})
+	}
+	slices.SortFunc(groups, func(a, b Group) int {
+		if c := cmp.Compare(a.Slot, b.Slot); c != 0 {
+			return c
+		}
+		rankA, _ := resolve.RarityRank(a.Rarity)
+		rankB, _ := resolve.RarityRank(b.Rarity)
+		return cmp.Compare(rankB, rankA)
+	})
+	return groups
+}
+
+// The groups with only the items that are not owned, leaving out complete
+// groups.
+func OnlyMissing(groups []Group) []Group {
+	res := make([]Group, 0, len(groups))
+	for _, g := range groups {
+		missing := g
+		missing.Items = slices.DeleteFunc(slices.Clone(g.Items), func(e Entry) bool { return e.Owned })
+		missing.Owned = 0
+		if len(missing.Items) > 0 {
+			res = append(res, missing)
+		}
+	}
+	return res
+}
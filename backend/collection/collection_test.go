@@ -0,0 +1,90 @@
+package collection
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/kenranunderscore/grimvault/backend/arc"
+	"github.com/kenranunderscore/grimvault/backend/database"
+	"github.com/kenranunderscore/grimvault/backend/owned"
+	"github.com/kenranunderscore/grimvault/backend/resolve"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+func item(key string, class string, rarity string, tag string, level float32) database.Entry {
+	return database.Entry{Key: key, Stats: []database.Stat{
+		{Name: "Class", Value: class},
+		{Name: "itemClassification", Value: rarity},
+		{Name: "itemNameTag", Value: tag},
+		{Name: "levelRequirement", Value: level},
+	}}
+}
+
+func testResolver() *resolve.Resolver {
+	db := database.New([]database.Entry{
+		item("records/items/gearhead/d001_head.dbr", "ArmorProtective_Head", "Legendary", "tagCrown", 94),
+		item("records/items/gearhead/d001_head_b.dbr", "ArmorProtective_Head", "Legendary", "tagCrown", 70),
+		item("records/items/gearaccessories/rings/c001_ring.dbr", "ArmorJewelry_Ring", "Epic", "tagRing", 50),
+		item("records/items/gearaccessories/rings/a001_ring.dbr", "ArmorJewelry_Ring", "Legendary", "tagBand", 90),
+		item("records/items/gearaccessories/rings/b001_ring.dbr", "ArmorJewelry_Ring", "Rare", "tagLoop", 30),
+		item("records/items/test/d002_head.dbr", "ArmorProtective_Head", "Legendary", "tagCrown", 1),
+		item("records/items/gearhead/test_head.dbr", "ArmorProtective_Head", "Legendary", "tagCrown", 1),
+		item("records/items/gearhead/d003_head.dbr", "ArmorProtective_Head", "Legendary", "tagUntranslated", 1),
+		item("records/items/crafting/blueprints/d001_head.dbr", "ItemArtifactFormula", "Legendary", "tagCrown", 1),
+	})
+	tags := arc.NewTags([]arc.Tag{
+		{Tag: "tagCrown", Name: "Crown of the Winter King"},
+		{Tag: "tagRing", Name: "Ring of Frost"},
+		{Tag: "tagBand", Name: "Band of Eternity"},
+		{Tag: "tagLoop", Name: "Loop"},
+	})
+	return resolve.New(db, tags)
+}
+
+func TestCatalogue(t *testing.T) {
+	t.Parallel()
+
+	c := NewCatalogue(testResolver(), DefaultRarities...)
+	if len(c.Items) != 3 {
+		t.Fatalf("expected 3 items, got %+v", c.Items)
+	}
+	crown := c.Items[slices.IndexFunc(c.Items, func(item Item) bool { return item.Slot == "head" })]
+	if crown.Name != "Crown of the Winter King" || crown.Level != 70 || len(crown.Records) != 2 {
+		t.Errorf("expected both variants of the crown in one item, got %+v", crown)
+	}
+}
+
+func TestTrack(t *testing.T) {
+	t.Parallel()
+
+	c := NewCatalogue(testResolver(), DefaultRarities...)
+	at := owned.Location{Kind: "vault", VaultId: 4}
+	groups := c.Track([]owned.Item{
+		{Item: stash.Item{Base: "Records\\Items\\GearHead\\d001_head_b.dbr"}, At: at},
+		{Item: stash.Item{Base: "records/items/gearaccessories/rings/b001_ring.dbr"}, At: at},
+	})
+
+	var summary []string
+	for _, g := range groups {
+		summary = append(summary, g.Slot+"/"+g.Rarity)
+	}
+	if expected := []string{"head/legendary", "ring/legendary", "ring/epic"}; !slices.Equal(summary, expected) {
+		t.Fatalf("expected groups %v, got %v", expected, summary)
+	}
+	if g := groups[0]; g.Owned != 1 || !g.Items[0].Owned || g.Items[0].Locations[0] != at {
+		t.Errorf("expected the crown to be owned, got %+v", g)
+	}
+	if g := groups[1]; g.Owned != 0 || g.Items[0].Owned || len(g.Items[0].Locations) != 0 {
+		t.Errorf("expected the band not to be owned, got %+v", g)
+	}
+}
+
+func TestOnlyMissing(t *testing.T) {
+	t.Parallel()
+
+	c := NewCatalogue(testResolver(), DefaultRarities...)
+	groups := OnlyMissing(c.Track([]owned.Item{{Item: stash.Item{Base: "records/items/gearhead/d001_head.dbr"}}}))
+	if len(groups) != 2 || groups[0].Slot != "ring" || groups[1].Slot != "ring" {
+		t.Errorf("expected only the ring groups to be left, got %+v", groups)
+	}
+}
@@ -2,7 +2,11 @@ package database
 
 import (
 	"fmt"
+	"maps"
 	"math"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/kenranunderscore/grimvault/backend/rawreader"
 	"github.com/pierrec/lz4"
@@ -14,7 +18,6 @@ func getStringTable(r *rawreader.T, start uint32) stringTable {
 	var strings []string
 	r.Seek(start)
 	count := r.Uint32()
-	fmt.Printf("    reading %d strings\n", count)
 	for range count {
 		strings = append(strings, r.String())
 	}
@@ -45,7 +48,6 @@ func readRecord(r *rawreader.T) record {
 
 func readRecords(r *rawreader.T, start uint32, count uint32) []record {
 	r.Seek(start)
-	fmt.Printf("reading %d records\n", count)
 	records := make([]record, 0, count)
 	for range count {
 		records = append(records, readRecord(r))
@@ -67,7 +69,7 @@ func uncompress(r *rawreader.T, rec *record) error {
 type Stat struct {
 	Name string
 	// FIXME: go has no sum types, so what's the idiom here?
-	Value any
+	Value any `ts:"string | number"`
 }
 
 type Entry struct {
@@ -140,7 +142,6 @@ func GetEntries(file string) ([]Entry, error) {
 	_ = r.Uint32()
 
 	strings := getStringTable(r, stringStart)
-	fmt.Printf("  found %d strings in %s\n", len(strings), file)
 
 	records := readRecords(r, recordStart, recordCount)
 	for i := range records {
@@ -161,3 +162,118 @@ func GetEntries(file string) ([]Entry, error) {
 
 	return items, nil
 }
+
+// Normalize a record path the way the game does: lowercase with forward
+// slashes.
+func NormalizeKey(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "\\", "/"))
+}
+
+// Get the value of the first stat called `name`.
+func (e *Entry) Get(name string) (any, bool) {
+	for _, stat := range e.Stats {
+		if stat.Name == name {
+			return stat.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Get all values of the stats called `name`; array-valued fields are stored as
+// several stats with the same name.
+func (e *Entry) All(name string) []any {
+	var values []any
+	for _, stat := range e.Stats {
+		if stat.Name == name {
+			values = append(values, stat.Value)
+		}
+	}
+	return values
+}
+
+// Get the string value of the stat `name`, or "" if it does not exist or is not
+// a string.
+func (e *Entry) String(name string) string {
+	value, _ := e.Get(name)
+	s, _ := value.(string)
+	return s
+}
+
+// Get the numeric value of the stat `name`, or 0 if it does not exist or is not
+// a number.
+func (e *Entry) Float(name string) float64 {
+	value, _ := e.Get(name)
+	return ToFloat(value)
+}
+
+// The numbers `n` for which the entry has a field `name` followed by `n`,
+// sorted, e.g. 1 and 3 for `lootName1` and `lootName3`.
+func (e *Entry) Numbered(name string) []int {
+	var res []int
+	for _, stat := range e.Stats {
+		rest, ok := strings.CutPrefix(stat.Name, name)
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(rest); err == nil && !slices.Contains(res, n) {
+			res = append(res, n)
+		}
+	}
+	slices.Sort(res)
+	return res
+}
+
+// Convert the value of a stat to a float, or 0 if it is not a number.
+func ToFloat(value any) float64 {
+	switch v := value.(type) {
+	case float32:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	}
+	return 0
+}
+
+// A set of database entries, indexed by their normalized record path.
+type Database struct {
+	Entries map[string]*Entry
+}
+
+// Create a database containing `entries`. Later entries replace earlier ones
+// with the same key.
+func New(entries []Entry) *Database {
+	db := &Database{Entries: make(map[string]*Entry, len(entries))}
+	db.add(entries)
+	return db
+}
+
+func (db *Database) add(entries []Entry) {
+	for i := range entries {
+		db.Entries[NormalizeKey(entries[i].Key)] = &entries[i]
+	}
+}
+
+// Read all `files` into a single database. Expansion databases override the
+// records of the ones before them, so they have to be passed last.
+func Load(files ...string) (*Database, error) {
+	db := New(nil)
+	for _, file := range files {
+		entries, err := GetEntries(file)
+		if err != nil {
+			return nil, fmt.Errorf("could not read database '%s': %w", file, err)
+		}
+		db.add(entries)
+	}
+	return db, nil
+}
+
+// Look up the entry for the record `key`.
+func (db *Database) Get(key string) (*Entry, bool) {
+	entry, ok := db.Entries[NormalizeKey(key)]
+	return entry, ok
+}
+
+// All record keys, sorted.
+func (db *Database) Keys() []string {
+	return slices.Sorted(maps.Keys(db.Entries))
+}
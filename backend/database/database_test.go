@@ -1,6 +1,7 @@
 package database
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/kenranunderscore/grimvault/backend/golden"
@@ -18,3 +19,41 @@ func TestAllDatabasesGolden(t *testing.T) {
 		return entries
 	})
 }
+
+func TestNumbered(t *testing.T) {
+	t.Parallel()
+
+	entry := Entry{Stats: []Stat{
+		{Name: "lootName3", Value: "b"},
+		{Name: "lootName1", Value: "a"},
+		{Name: "lootName1", Value: "c"},
+		{Name: "lootNameTag", Value: "d"},
+		{Name: "lootWeight2", Value: float32(1)},
+	}}
+	if numbers := entry.Numbered("lootName"); !slices.Equal(numbers, []int{1, 3}) {
+		t.Errorf("expected [1 3], got %v", numbers)
+	}
+}
+
+func TestLoadAndLookUpRecord(t *testing.T) {
+	t.Parallel()
+
+	db, err := Load("../test_data/arz/some.arz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry, ok := db.Get(`records\items\gearweapons\caster\B203_Scepter.dbr`)
+	if !ok {
+		t.Fatal("expected to find scepter record")
+	}
+	if class := entry.String("Class"); class != "WeaponMelee_Scepter" {
+		t.Errorf("expected class WeaponMelee_Scepter, got %q", class)
+	}
+	if level := entry.Float("itemLevel"); level != 1 {
+		t.Errorf("expected item level 1, got %v", level)
+	}
+	if life := entry.Float("offensiveBaseLifeMax"); life != 19 {
+		t.Errorf("expected max life damage 19, got %v", life)
+	}
+}
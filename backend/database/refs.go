@@ -0,0 +1,116 @@
+package database
+
+import (
+	"slices"
+	"strings"
+)
+
+// A field of a record whose value is the path of another record, e.g. a
+// granted skill in `itemSkillName` or the pet of a summoning skill.
+type Reference struct {
+	Field string `json:"field"`
+	Key   string `json:"key"`
+}
+
+// All references of the entry to other records, normalized, in field order.
+// The records need not exist. Records were flattened when the database was
+// built, so templates and inherited values need not be followed.
+func (e *Entry) References() []Reference {
+	var refs []Reference
+	for _, stat := range e.Stats {
+		s, ok := stat.Value.(string)
+		if ok && strings.HasSuffix(strings.ToLower(s), ".dbr") {
+			refs = append(refs, Reference{Field: stat.Name, Key: NormalizeKey(s)})
+		}
+	}
+	return refs
+}
+
+// Paths of records that only describe visuals and sounds.
+var presentationPrefixes = []string{"records/sounds/", "records/fx/", "records/effects/", "records/ui/"}
+
+// Whether `ref` is about what an item does rather than how it looks or
+// sounds. Can be passed to `Expand`.
+func Gameplay(ref Reference) bool {
+	return !slices.ContainsFunc(presentationPrefixes, func(prefix string) bool {
+		return strings.HasPrefix(ref.Key, prefix)
+	})
+}
+
+// A record together with the records it refers to, as built by `Expand`.
+type Node struct {
+	Key string `json:"key"`
+	// The field of the referring record, or "" for the record expanded.
+	Field string `json:"field,omitempty"`
+	// Nil if the record is missing, or was not expanded for one of the
+	// reasons below.
+	Entry *Entry  `json:"entry,omitempty"`
+	Refs  []*Node `json:"refs,omitempty"`
+	// The record does not exist.
+	Missing bool `json:"missing,omitempty"`
+	// The record refers back to itself through this reference, so it is not
+	// expanded again.
+	Cycle bool `json:"cycle,omitempty"`
+	// The record is too deep to be expanded.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Expand the record `key` by following its references, up to `maxDepth`
+// levels deep. Only references for which `follow` returns true are expanded;
+// all are if it is nil. A record referring back to one of the records that
+// lead to it is marked as a cycle instead of being expanded again.
+func (db *Database) Expand(key string, maxDepth int, follow func(ref Reference) bool) *Node {
+	var path []string
+	var expand func(key string, field string, depth int) *Node
+	expand = func(key string, field string, depth int) *Node {
+		key = NormalizeKey(key)
+		n := &Node{Key: key, Field: field}
+		if slices.Contains(path, key) {
+			n.Cycle = true
+			return n
+		}
+		entry, ok := db.Get(key)
+		if !ok {
+			n.Missing = true
+			return n
+		}
+		if depth > maxDepth {
+			n.Truncated = true
+			return n
+		}
+		n.Entry = entry
+
+		path = append(path, key)
+		for _, ref := range entry.References() {
+			if follow == nil || follow(ref) {
+				n.Refs = append(n.Refs, expand(ref.Key, ref.Field, depth+1))
+			}
+		}
+		path = path[:len(path)-1]
+		return n
+	}
+	return expand(key, "", 0)
+}
+
+// Call `fn` for `n` and all nodes below it, depth-first, with their depth
+// below `n`.
+func (n *Node) Walk(fn func(n *Node, depth int)) {
+	var walk func(n *Node, depth int)
+	walk = func(n *Node, depth int) {
+		fn(n, depth)
+		for _, ref := range n.Refs {
+			walk(ref, depth+1)
+		}
+	}
+	walk(n, 0)
+}
+
+// Whether any record below `n` refers back to one of the records leading to
+// it.
+func (n *Node) HasCycle() bool {
+	found := false
+	n.Walk(func(n *Node, _ int) {
+		found = found || n.Cycle
+	})
+	return found
+}
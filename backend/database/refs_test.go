@@ -0,0 +1,74 @@
+package database
+
+import (
+	"slices"
+	"testing"
+)
+
+func refsDatabase() *Database {
+	return New([]Entry{
+		{Key: "records/items/a001_ring.dbr", Stats: []Stat{
+			{Name: "itemSkillName", Value: "Records\\Skills\\Items\\Summon.dbr"},
+			{Name: "dropSound", Value: "records/sounds/ring.dbr"},
+			{Name: "bitmap", Value: "items/ring.tex"},
+			{Name: "itemSetName", Value: "records/items/missing_set.dbr"},
+		}},
+		{Key: "records/skills/items/summon.dbr", Stats: []Stat{
+			{Name: "spawnObjects", Value: "records/creatures/pets/wolf.dbr"},
+		}},
+		{Key: "records/creatures/pets/wolf.dbr", Stats: []Stat{
+			{Name: "skillName1", Value: "records/skills/items/summon.dbr"},
+		}},
+		{Key: "records/sounds/ring.dbr"},
+	})
+}
+
+func TestReferences(t *testing.T) {
+	t.Parallel()
+
+	entry, _ := refsDatabase().Get("records/items/a001_ring.dbr")
+	expected := []Reference{
+		{"itemSkillName", "records/skills/items/summon.dbr"},
+		{"dropSound", "records/sounds/ring.dbr"},
+		{"itemSetName", "records/items/missing_set.dbr"},
+	}
+	if refs := entry.References(); !slices.Equal(refs, expected) {
+		t.Errorf("expected %v, got %v", expected, refs)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	t.Parallel()
+
+	db := refsDatabase()
+	root := db.Expand("records/items/a001_ring.dbr", 10, Gameplay)
+	var summary []string
+	root.Walk(func(n *Node, depth int) {
+		s := n.Key
+		switch {
+		case n.Cycle:
+			s += " (cycle)"
+		case n.Missing:
+			s += " (missing)"
+		}
+		summary = append(summary, s)
+	})
+	expected := []string{
+		"records/items/a001_ring.dbr",
+		"records/skills/items/summon.dbr",
+		"records/creatures/pets/wolf.dbr",
+		"records/skills/items/summon.dbr (cycle)",
+		"records/items/missing_set.dbr (missing)",
+	}
+	if !slices.Equal(summary, expected) {
+		t.Errorf("expected %v, got %v", expected, summary)
+	}
+	if !root.HasCycle() {
+		t.Errorf("expected the cycle to be found")
+	}
+
+	shallow := db.Expand("records/items/a001_ring.dbr", 1, nil)
+	if len(shallow.Refs) != 3 || !shallow.Refs[0].Refs[0].Truncated || shallow.HasCycle() {
+		t.Errorf("expected the expansion to stop at depth 1, got %+v", shallow.Refs[0])
+	}
+}
@@ -0,0 +1,255 @@
+package dedupe
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/kenranunderscore/grimvault/backend/database"
+	"github.com/kenranunderscore/grimvault/backend/locate"
+	"github.com/kenranunderscore/grimvault/backend/owned"
+	"github.com/kenranunderscore/grimvault/backend/resolve"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+	"github.com/kenranunderscore/grimvault/backend/vault"
+)
+
+// Kinds of duplicates.
+const (
+	// Same base, affixes, stack size and seed: the same item twice, e.g. from
+	// a bug or from restoring a backup.
+	Exact = "exact"
+	// Same base and affixes, but a different seed, so the stats may differ.
+	Near = "near"
+)
+
+// Copies of the same item. `Keep` is the index of the copy worth keeping.
+type Group struct {
+	Kind   string       `json:"kind"`
+	Copies []owned.Item `json:"copies"`
+	Keep   int          `json:"keep"`
+}
+
+// What makes copies the same item. Hardcore and softcore copies are never
+// duplicates of each other, as neither can stand in for the other.
+type identity struct {
+	base, prefix, suffix string
+	hardcore             bool
+	// Stacks of different sizes are no copies of each other, and discarding
+	// one would lose the whole stack.
+	stackSize uint32
+}
+
+func identityOf(item *owned.Item) identity {
+	return identity{
+		database.NormalizeKey(item.Item.Base),
+		database.NormalizeKey(item.Item.Prefix),
+		database.NormalizeKey(item.Item.Suffix),
+		item.Hardcore,
+		item.Item.StackSize,
+	}
+}
+
+// Whether near duplicates of `item` are worth reporting. Stackable things like
+// components only differ by their seed, so all of them would be. Without the
+// game database, only items with affixes are considered.
+func unique(r *resolve.Resolver, item *stash.Item) bool {
+	if r == nil {
+		return item.Prefix != "" || item.Suffix != ""
+	}
+	entry, ok := r.DB.Get(item.Base)
+	if !ok {
+		return false
+	}
+	switch resolve.Slot(entry.String("Class")) {
+	case "", "component", "augment", "blueprint":
+		return false
+	}
+	return true
+}
+
+// How much has been done to an item: attached components, augments and
+// completed relics make a copy worth more than a bare one.
+func rank(item *stash.Item) int {
+	n := int(item.MaterialCombines)
+	for _, record := range []string{item.Material, item.Enchantment, item.RelicCompletionBonus, item.Modifier, item.Transmute} {
+		if record != "" {
+			n += 10
+		}
+	}
+	return n
+}
+
+// Where copies are best kept: the vault is safer than a transfer stash.
+var kindOrder = []string{"character", "vault", "stash"}
+
+// Order copies from the one most worth keeping. Items of characters cannot be
+// moved, so they are always kept; otherwise the copy that has been worked on
+// the most wins.
+func compareCopies(a *owned.Item, b *owned.Item) int {
+	pinned := func(item *owned.Item) int {
+		if item.At.Kind == "character" {
+			return 0
+		}
+		return 1
+	}
+	return cmp.Or(
+		cmp.Compare(pinned(a), pinned(b)),
+		cmp.Compare(rank(&b.Item), rank(&a.Item)),
+		cmp.Compare(slices.Index(kindOrder, a.At.Kind), slices.Index(kindOrder, b.At.Kind)),
+	)
+}
+
+func newGroup(kind string, copies []owned.Item) Group {
+	keep := 0
+	for i := range copies {
+		if compareCopies(&copies[i], &copies[keep]) < 0 {
+			keep = i
+		}
+	}
+	return Group{Kind: kind, Copies: copies, Keep: keep}
+}
+
+// Group the exact and near duplicates among `items`. Each exact duplicate only
+// shows up in its exact group; a near group lists one copy per seed, namely
+// the one that is kept. `r` may be nil if the game data is not available.
+func Find(items []owned.Item, r *resolve.Resolver) []Group {
+	byIdentity := make(map[identity][]owned.Item)
+	var order []identity
+	for _, item := range items {
+		id := identityOf(&item)
+		if _, ok := byIdentity[id]; !ok {
+			order = append(order, id)
+		}
+		byIdentity[id] = append(byIdentity[id], item)
+	}
+
+	var groups []Group
+	for _, id := range order {
+		copies := byIdentity[id]
+		if len(copies) < 2 {
+			continue
+		}
+		bySeed := make(map[uint32][]owned.Item)
+		var seeds []uint32
+		for _, item := range copies {
+			if _, ok := bySeed[item.Item.Seed]; !ok {
+				seeds = append(seeds, item.Item.Seed)
+			}
+			bySeed[item.Item.Seed] = append(bySeed[item.Item.Seed], item)
+		}
+
+		var distinct []owned.Item
+		for _, seed := range seeds {
+			same := bySeed[seed]
+			if len(same) > 1 {
+				g := newGroup(Exact, same)
+				groups = append(groups, g)
+				distinct = append(distinct, same[g.Keep])
+			} else {
+				distinct = append(distinct, same[0])
+			}
+		}
+		if len(distinct) > 1 && unique(r, &distinct[0].Item) {
+			groups = append(groups, newGroup(Near, distinct))
+		}
+	}
+	return groups
+}
+
+// The copies of `g` that can go: all but the kept one, except those of
+// characters, which cannot be changed while the game might be running.
+func (g *Group) Discardable() []owned.Item {
+	var res []owned.Item
+	for i, item := range g.Copies {
+		if i != g.Keep && item.At.Kind != "character" {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// The copies to discard to keep only the best one of each group: those of
+// exact duplicates, and of near duplicates too if `near` is set.
+func Plan(groups []Group, near bool) []owned.Item {
+	var res []owned.Item
+	for _, g := range groups {
+		if g.Kind == Exact || near {
+			res = append(res, g.Discardable()...)
+		}
+	}
+	return res
+}
+
+// Move `items` to the discarded items of the vault, taking those in transfer
+// stashes out of them. Changed stashes are written by `write`. Nothing is
+// changed if any item is not where it is expected anymore. The vault is saved
+// first, so that an item is at worst duplicated, but never lost.
+func Discard(v *vault.Vault, save locate.SaveDir, items []owned.Item, write func(file locate.StashFile, st *stash.Stash) error) error {
+	var vaultIds []uint64
+	byStash := make(map[string][]owned.Item)
+	for _, item := range items {
+		switch item.At.Kind {
+		case "vault":
+			if _, ok := v.Get(item.At.VaultId); !ok {
+				return fmt.Errorf("no item with id %d in the vault", item.At.VaultId)
+			}
+			vaultIds = append(vaultIds, item.At.VaultId)
+		case "stash":
+			byStash[item.At.Name] = append(byStash[item.At.Name], item)
+		default:
+			return fmt.Errorf("cannot discard items of %s", item.At)
+		}
+	}
+
+	files := make(map[string]locate.StashFile)
+	stashes := make(map[string]*stash.Stash)
+	for _, file := range save.Stashes {
+		stashItems, ok := byStash[file.Id()]
+		if !ok {
+			continue
+		}
+		st, err := stash.ReadStash(file.Path)
+		if err != nil {
+			return err
+		}
+		for _, item := range stashItems {
+			at := item.At
+			if at.Tab >= len(st.Tabs) || at.Index >= len(st.Tabs[at.Tab].Items) || st.Tabs[at.Tab].Items[at.Index] != item.Item {
+				return fmt.Errorf("the %s stash has changed, item %d of tab %d is not there anymore", file.Id(), at.Index, at.Tab+1)
+			}
+		}
+		files[file.Id()] = file
+		stashes[file.Id()] = st
+	}
+	for id := range byStash {
+		if _, ok := stashes[id]; !ok {
+			return fmt.Errorf("no stash '%s' in the save directory", id)
+		}
+	}
+
+	for _, id := range vaultIds {
+		v.Discard(id)
+	}
+	for id, stashItems := range byStash {
+		// Later items first, so that the indices of the others stay valid.
+		slices.SortFunc(stashItems, func(a, b owned.Item) int {
+			return cmp.Or(cmp.Compare(b.At.Tab, a.At.Tab), cmp.Compare(b.At.Index, a.At.Index))
+		})
+		file, st := files[id], stashes[id]
+		for _, item := range stashItems {
+			tab := &st.Tabs[item.At.Tab]
+			v.InsertDiscarded(vault.Entry{Item: item.Item, Source: file.Path, Hardcore: file.Hardcore})
+			tab.Items = slices.Delete(tab.Items, item.At.Index, item.At.Index+1)
+		}
+	}
+
+	if err := v.Save(); err != nil {
+		return err
+	}
+	for id, st := range stashes {
+		if err := write(files[id], st); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package dedupe
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/kenranunderscore/grimvault/backend/locate"
+	"github.com/kenranunderscore/grimvault/backend/owned"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+	"github.com/kenranunderscore/grimvault/backend/vault"
+)
+
+const sword = "records/items/gearweapons/swords1h/d011_sword.dbr"
+
+func copyOf(seed uint32, at owned.Location) owned.Item {
+	return owned.Item{Item: stash.Item{Base: sword, Prefix: "records/items/lootaffixes/prefix/a.dbr", Seed: seed}, At: at}
+}
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+
+	augmented := copyOf(1, owned.Location{Kind: "stash", Name: "softcore", Index: 2})
+	augmented.Item.Enchantment = "records/items/enchants/e.dbr"
+	items := []owned.Item{
+		copyOf(1, owned.Location{Kind: "stash", Name: "softcore", Index: 1}),
+		augmented,
+		copyOf(1, owned.Location{Kind: "vault", VaultId: 1}),
+		copyOf(2, owned.Location{Kind: "vault", VaultId: 2}),
+		copyOf(3, owned.Location{Kind: "character", Name: "Alice", Place: "equipped"}),
+		{Item: stash.Item{Base: "records/items/materia/compa_bristlyfur.dbr", Seed: 4}},
+		{Item: stash.Item{Base: "records/items/materia/compa_bristlyfur.dbr", Seed: 5}},
+	}
+
+	groups := Find(items, nil)
+	if len(groups) != 2 {
+		t.Fatalf("expected an exact and a near group, got %+v", groups)
+	}
+	exact, near := groups[0], groups[1]
+	if exact.Kind != Exact || len(exact.Copies) != 3 || exact.Keep != 1 {
+		t.Errorf("expected the augmented copy to be kept, got %+v", exact)
+	}
+	if near.Kind != Near || len(near.Copies) != 3 || near.Copies[near.Keep].At.Kind != "character" {
+		t.Errorf("expected one near copy per seed, keeping the equipped one, got %+v", near)
+	}
+	if discard := Plan(groups, false); len(discard) != 2 {
+		t.Errorf("expected only the exact duplicates to go, got %+v", discard)
+	}
+	if discard := Plan(groups, true); len(discard) != 4 {
+		t.Errorf("expected the near duplicates to go too, got %+v", discard)
+	}
+}
+
+func TestFindKeepsModesApart(t *testing.T) {
+	t.Parallel()
+
+	hardcore := copyOf(1, owned.Location{Kind: "stash", Name: "hardcore"})
+	hardcore.Hardcore = true
+	items := []owned.Item{
+		copyOf(1, owned.Location{Kind: "stash", Name: "softcore"}),
+		hardcore,
+		copyOf(2, owned.Location{Kind: "vault", VaultId: 1}),
+	}
+	groups := Find(items, nil)
+	if len(groups) != 1 || groups[0].Kind != Near || len(groups[0].Copies) != 2 {
+		t.Fatalf("expected only the softcore copies to be grouped, got %+v", groups)
+	}
+	for _, item := range groups[0].Copies {
+		if item.Hardcore {
+			t.Errorf("expected no hardcore copy in the group, got %+v", item)
+		}
+	}
+}
+
+func TestFindKeepsStackSizesApart(t *testing.T) {
+	t.Parallel()
+
+	stack := func(size uint32) owned.Item {
+		return owned.Item{Item: stash.Item{Base: "records/items/materia/compa_bristlyfur.dbr", Seed: 4, StackSize: size}}
+	}
+	if groups := Find([]owned.Item{stack(3), stack(5)}, nil); len(groups) != 0 {
+		t.Errorf("expected stacks of different sizes not to be duplicates, got %+v", groups)
+	}
+	if groups := Find([]owned.Item{stack(5), stack(5)}, nil); len(groups) != 1 || groups[0].Kind != Exact {
+		t.Errorf("expected stacks of the same size to be exact duplicates, got %+v", groups)
+	}
+}
+
+func TestDiscard(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	st, err := stash.ReadStash("../test_data/stashes/transfer.gst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fur := st.Tabs[2].Items[0]
+	st.Tabs[3].Items = append(st.Tabs[3].Items, fur)
+	file := filepath.Join(dir, "transfer.gst")
+	if err := stash.WriteStash(file, st); err != nil {
+		t.Fatal(err)
+	}
+	v, err := vault.Open(filepath.Join(dir, "vault.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept := v.Add(fur, "transfer.gst")
+
+	save := locate.SaveDir{Path: dir, Stashes: []locate.StashFile{{Path: file}}}
+	items, _, err := owned.Collect(v, save)
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups := Find(items, nil)
+	if len(groups) != 1 || len(groups[0].Copies) != 3 {
+		t.Fatalf("expected three copies of the fur, got %+v", groups)
+	}
+
+	written := 0
+	write := func(file locate.StashFile, st *stash.Stash) error {
+		written++
+		return stash.WriteStash(file.Path, st)
+	}
+	if err := Discard(v, save, Plan(groups, false), write); err != nil {
+		t.Fatal(err)
+	}
+	if written != 1 || len(v.Entries) != 1 || v.Entries[0].Id != kept.Id || len(v.Discarded) != 2 {
+		t.Errorf("expected the stash copies to be discarded, got %+v and %+v", v.Entries, v.Discarded)
+	}
+	after, err := stash.ReadStash(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after.Tabs[2].Items) != 98 || len(after.Tabs[3].Items) != 0 {
+		t.Errorf("expected the copies to be taken out of the stash")
+	}
+
+	// The plan is stale now, so nothing must happen.
+	if err := Discard(v, save, Plan(groups, false), write); err == nil || written != 1 {
+		t.Errorf("expected a changed stash to be detected, got %v", err)
+	}
+}
@@ -0,0 +1,287 @@
+// Finds out who drops an item: monsters, chests and other records referring
+// to loot tables, through any number of nested tables.
+//
+// Loot tables pick one of their entries by weight (`lootName1` with
+// `lootWeight1`), except level tables, which pick one by the level of the
+// source (`levels` and `records`). Monsters roll each equipment slot with
+// `chanceToEquip<Slot>` percent and then pick one of the slot's tables, e.g.
+// `lootFinger1Item1`, by the weights `chanceToEquip<Slot>Item1`.
+package drops
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kenranunderscore/grimvault/backend/database"
+	"github.com/kenranunderscore/grimvault/backend/resolve"
+)
+
+// Something a table or source can pick, and the chance it does.
+type edge struct {
+	record string
+	chance float64
+}
+
+type table struct {
+	entries []edge
+	// Whether the entries are picked by level rather than by weight, so only
+	// one of them applies at a time.
+	byLevel bool
+}
+
+// The loot tables and drop sources of a database.
+type Index struct {
+	r      *resolve.Resolver
+	tables map[string]*table
+	// What each source drops, with the chance per kill or opening.
+	sources map[string][]edge
+	// The tables and sources referring to each record.
+	parents map[string][]string
+}
+
+// Pick each of `edges` by its share of their total weight.
+func normalize(edges []edge) []edge {
+	var total float64
+	for _, e := range edges {
+		total += e.chance
+	}
+	res := make([]edge, 0, len(edges))
+	for _, e := range edges {
+		if total > 0 {
+			res = append(res, edge{e.record, e.chance / total})
+		}
+	}
+	return res
+}
+
+// Read `entry` as a loot table, or return nil if it is none.
+func readTable(entry *database.Entry) *table {
+	if entry.String("Class") == "LevelTable" {
+		t := &table{byLevel: true}
+		for _, value := range entry.All("records") {
+			if record, ok := value.(string); ok {
+				t.entries = append(t.entries, edge{database.NormalizeKey(record), 1})
+			}
+		}
+		return t
+	}
+
+	var entries []edge
+	for _, n := range entry.Numbered("lootName") {
+		i := strconv.Itoa(n)
+		record := entry.String("lootName" + i)
+		if weight := entry.Float("lootWeight" + i); record != "" && weight > 0 {
+			entries = append(entries, edge{database.NormalizeKey(record), weight})
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	return &table{entries: normalize(entries)}
+}
+
+// The loot of `entry` if it is a source: the tables of its equipment slots,
+// or any other tables it refers to, which are taken to always drop.
+func (ix *Index) readSource(entry *database.Entry) []edge {
+	bySlot := make(map[string][]edge)
+	var slots []string
+	for _, stat := range entry.Stats {
+		rest, ok := strings.CutPrefix(stat.Name, "loot")
+		if !ok {
+			continue
+		}
+		slot, n, ok := strings.Cut(rest, "Item")
+		record, isString := stat.Value.(string)
+		if !ok || !isString || record == "" {
+			continue
+		}
+		if _, err := strconv.Atoi(n); err != nil {
+			continue
+		}
+		if _, ok := bySlot[slot]; !ok {
+			slots = append(slots, slot)
+		}
+		weight := entry.Float("chanceToEquip" + slot + "Item" + n)
+		if weight > 0 {
+			bySlot[slot] = append(bySlot[slot], edge{database.NormalizeKey(record), weight})
+		}
+	}
+
+	var loot []edge
+	for _, slot := range slots {
+		chance := entry.Float("chanceToEquip"+slot) / 100
+		for _, e := range normalize(bySlot[slot]) {
+			loot = append(loot, edge{e.record, chance * e.chance})
+		}
+	}
+	if len(slots) > 0 {
+		return loot
+	}
+	for _, ref := range entry.References() {
+		if _, ok := ix.tables[ref.Key]; ok {
+			loot = append(loot, edge{ref.Key, 1})
+		}
+	}
+	return loot
+}
+
+// Find the loot tables and drop sources in the database of `r`.
+func NewIndex(r *resolve.Resolver) *Index {
+	ix := &Index{
+		r:       r,
+		tables:  make(map[string]*table),
+		sources: make(map[string][]edge),
+		parents: make(map[string][]string),
+	}
+	keys := r.DB.Keys()
+	for _, key := range keys {
+		if t := readTable(r.DB.Entries[key]); t != nil {
+			ix.tables[key] = t
+			for _, e := range t.entries {
+				ix.parents[e.record] = append(ix.parents[e.record], key)
+			}
+		}
+	}
+	for _, key := range keys {
+		if _, ok := ix.tables[key]; ok {
+			continue
+		}
+		if loot := ix.readSource(r.DB.Entries[key]); len(loot) > 0 {
+			ix.sources[key] = loot
+			for _, e := range loot {
+				ix.parents[e.record] = append(ix.parents[e.record], key)
+			}
+		}
+	}
+	return ix
+}
+
+// Something that drops an item.
+type Source struct {
+	Record string `json:"record"`
+	Name   string `json:"name"`
+	Class  string `json:"class"`
+	// For monsters, e.g. "Boss" or "Hero".
+	Classification string `json:"classification,omitempty"`
+	// The chance to drop the item per kill or opening, at the level where it
+	// is most likely if the loot depends on the level. Drops of several items
+	// at once are not accounted for, nor are tables leading back into
+	// themselves.
+	Chance float64 `json:"chance"`
+	// The tables leading from the source to the item, the most likely way
+	// first.
+	Path []string `json:"path"`
+}
+
+// Finds the chance of tables to yield one item, remembering the tables
+// already visited.
+type search struct {
+	ix     *Index
+	target string
+	memo   map[string]result
+	// The tables being visited, to cut cycles.
+	visiting map[string]bool
+}
+
+type result struct {
+	chance float64
+	// The most likely way to the target, starting with the table itself.
+	path []string
+}
+
+func (s *search) reach(record string) result {
+	if record == s.target {
+		return result{1, nil}
+	}
+	if r, ok := s.memo[record]; ok {
+		return r
+	}
+	t, ok := s.ix.tables[record]
+	if !ok || s.visiting[record] {
+		return result{}
+	}
+	s.visiting[record] = true
+	defer delete(s.visiting, record)
+
+	var res result
+	var best float64
+	for _, e := range t.entries {
+		r := s.reach(e.record)
+		if r.chance == 0 {
+			continue
+		}
+		chance := r.chance
+		if !t.byLevel {
+			chance *= e.chance
+			res.chance += chance
+		} else {
+			res.chance = max(res.chance, chance)
+		}
+		if chance > best {
+			best = chance
+			res.path = append([]string{record}, r.path...)
+		}
+	}
+	if res.path == nil && res.chance > 0 {
+		res.path = []string{record}
+	}
+	s.memo[record] = res
+	return res
+}
+
+// Find everything that drops `record`, the most likely source first.
+func (ix *Index) Sources(record string) []Source {
+	record = database.NormalizeKey(record)
+	s := &search{ix: ix, target: record, memo: make(map[string]result), visiting: make(map[string]bool)}
+
+	// Only the ancestors of the item can drop it.
+	seen := map[string]bool{record: true}
+	queue := []string{record}
+	var candidates []string
+	for len(queue) > 0 {
+		key := queue[0]
+		queue = queue[1:]
+		if _, ok := ix.sources[key]; ok {
+			candidates = append(candidates, key)
+		}
+		for _, parent := range ix.parents[key] {
+			if !seen[parent] {
+				seen[parent] = true
+				queue = append(queue, parent)
+			}
+		}
+	}
+
+	var sources []Source
+	for _, key := range candidates {
+		source := Source{Record: key, Path: []string{}}
+		var best float64
+		for _, e := range ix.sources[key] {
+			r := s.reach(e.record)
+			chance := e.chance * r.chance
+			if chance == 0 {
+				continue
+			}
+			source.Chance += chance
+			if chance > best {
+				best = chance
+				source.Path = append([]string{}, r.path...)
+			}
+		}
+		if source.Chance == 0 {
+			continue
+		}
+		if entry, ok := ix.r.DB.Get(key); ok {
+			source.Class = entry.String("Class")
+			source.Classification = entry.String("monsterClassification")
+		}
+		source.Name = ix.r.BaseName(key)
+		sources = append(sources, source)
+	}
+	slices.SortFunc(sources, func(a, b Source) int {
+		return cmp.Or(cmp.Compare(b.Chance, a.Chance), cmp.Compare(a.Record, b.Record))
+	})
+	return sources
+}
@@ -0,0 +1,110 @@
+package drops
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/kenranunderscore/grimvault/backend/arc"
+	"github.com/kenranunderscore/grimvault/backend/database"
+	"github.com/kenranunderscore/grimvault/backend/internal/testdb"
+	"github.com/kenranunderscore/grimvault/backend/resolve"
+)
+
+const (
+	ring    = "records/items/gearaccessories/rings/a001_ring.dbr"
+	amulet  = "records/items/gearaccessories/necklaces/a001_necklace.dbr"
+	jewelry = "records/items/loottables/tdyn_jewelry.dbr"
+	levels  = "records/items/loottables/tdyn_jewelry_level.dbr"
+	master  = "records/items/loottables/mastertables/mt_boss.dbr"
+	loop    = "records/items/loottables/mastertables/mt_loop.dbr"
+	boss    = "records/creatures/enemies/boss/boss01.dbr"
+	chest   = "records/items/lootchests/chest01.dbr"
+)
+
+func testIndex() *Index {
+	db := database.New([]database.Entry{
+		{Key: jewelry, Stats: testdb.Stats(
+			"lootName1", ring, "lootWeight1", float32(3),
+			"lootName2", amulet, "lootWeight2", float32(1),
+		)},
+		{Key: levels, Stats: testdb.Stats(
+			"Class", "LevelTable",
+			"levels", float32(1), "levels", float32(50),
+			"records", jewelry, "records", amulet,
+		)},
+		{Key: master, Stats: testdb.Stats(
+			"lootName1", levels, "lootWeight1", float32(1),
+			"lootName2", loop, "lootWeight2", float32(1),
+		)},
+		{Key: loop, Stats: testdb.Stats(
+			"lootName1", master, "lootWeight1", float32(1),
+			"lootName2", ring, "lootWeight2", float32(1),
+		)},
+		{Key: boss, Stats: testdb.Stats(
+			"Class", "Monster",
+			"description", "tagBoss",
+			"monsterClassification", "Boss",
+			"chanceToEquipFinger1", float32(50),
+			"lootFinger1Item1", jewelry, "chanceToEquipFinger1Item1", float32(1),
+			"lootFinger1Item2", amulet, "chanceToEquipFinger1Item2", float32(1),
+		)},
+		{Key: chest, Stats: testdb.Stats(
+			"Class", "FixedItemChest",
+			"tableName", master,
+		)},
+		{Key: ring, Stats: testdb.Stats("itemNameTag", "tagRing")},
+	})
+	tags := arc.NewTags([]arc.Tag{
+		{Tag: "tagRing", Name: "Ring"},
+		{Tag: "tagBoss", Name: "Boss"},
+	})
+	return NewIndex(resolve.New(db, tags))
+}
+
+func near(a float64, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestSources(t *testing.T) {
+	t.Parallel()
+
+	sources := testIndex().Sources("Records\\Items\\GearAccessories\\Rings\\a001_ring.dbr")
+	if len(sources) != 2 {
+		t.Fatalf("expected the chest and the boss, got %+v", sources)
+	}
+
+	// The master table yields the ring through the level table (3/4 at low
+	// levels) or the loop (1/2, ignoring the way back into the master table).
+	c := sources[0]
+	if c.Record != chest || c.Class != "FixedItemChest" || !near(c.Chance, 0.5*0.75+0.5*0.5) {
+		t.Errorf("expected the chest first, got %+v", c)
+	}
+	if expected := []string{master, levels, jewelry}; !slices.Equal(c.Path, expected) {
+		t.Errorf("expected path %v, got %v", expected, c.Path)
+	}
+
+	b := sources[1]
+	if b.Record != boss || b.Name != "Boss" || b.Classification != "Boss" || !near(b.Chance, 0.5*0.5*0.75) {
+		t.Errorf("expected the boss second, got %+v", b)
+	}
+	if expected := []string{jewelry}; !slices.Equal(b.Path, expected) {
+		t.Errorf("expected path %v, got %v", expected, b.Path)
+	}
+}
+
+func TestSourcesDirect(t *testing.T) {
+	t.Parallel()
+
+	sources := testIndex().Sources(amulet)
+	if len(sources) != 2 || sources[0].Record != chest {
+		t.Fatalf("expected the chest and the boss, got %+v", sources)
+	}
+	// Directly by the second table of the slot, or by the first.
+	if b := sources[1]; !near(b.Chance, 0.5*0.5+0.5*0.5*0.25) || !slices.Equal(b.Path, []string{}) {
+		t.Errorf("expected the boss to drop the amulet directly, got %+v", b)
+	}
+	if len(testIndex().Sources("records/items/unknown.dbr")) != 0 {
+		t.Error("expected no sources for an unknown record")
+	}
+}
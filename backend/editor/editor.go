@@ -0,0 +1,211 @@
+// Creates items for single-player testing: a base with a choice of the
+// affixes, component and augment it can have, checked by the validator so the
+// game loads the stash it is written into.
+package editor
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kenranunderscore/grimvault/backend/affixes"
+	"github.com/kenranunderscore/grimvault/backend/database"
+	"github.com/kenranunderscore/grimvault/backend/resolve"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+	"github.com/kenranunderscore/grimvault/backend/validate"
+)
+
+// A record to choose for an item field.
+type Choice struct {
+	Record string `json:"record"`
+	Name   string `json:"name"`
+}
+
+// Everything that can be chosen for an item of a base.
+type Options struct {
+	Base     string         `json:"base"`
+	Name     string         `json:"name"`
+	Prefixes []affixes.Roll `json:"prefixes"`
+	Suffixes []affixes.Roll `json:"suffixes"`
+	// The components that can be socketed into the item, with the
+	// completion bonuses of each by record.
+	Components  []Choice            `json:"components"`
+	Completions map[string][]Choice `json:"completions"`
+	Augments    []Choice            `json:"augments"`
+	MaxStack    uint32              `json:"maxStack"`
+}
+
+// The item to create. Seeds of 0 are rolled at random, and a stack size of 0
+// means a single item.
+type Spec struct {
+	Base                 string `json:"base"`
+	Prefix               string `json:"prefix,omitempty"`
+	Suffix               string `json:"suffix,omitempty"`
+	Material             string `json:"material,omitempty"`
+	RelicCompletionBonus string `json:"relicCompletionBonus,omitempty"`
+	Enchantment          string `json:"enchantment,omitempty"`
+	Seed                 uint32 `json:"seed,omitempty"`
+	RelicSeed            uint32 `json:"relicSeed,omitempty"`
+	EnchantmentSeed      uint32 `json:"enchantmentSeed,omitempty"`
+	StackSize            uint32 `json:"stackSize,omitempty"`
+	// Roll new seeds even where they are given, e.g. to try other stat
+	// values for an item created before.
+	Reroll bool `json:"reroll,omitempty"`
+}
+
+// An item that the game would not produce.
+type InvalidError struct {
+	Findings []validate.Finding
+}
+
+func (e *InvalidError) Error() string {
+	var problems []string
+	for _, f := range e.Findings {
+		if f.Severity == validate.Error {
+			problems = append(problems, f.Message)
+		}
+	}
+	return fmt.Sprintf("invalid item: %s", strings.Join(problems, "; "))
+}
+
+type Editor struct {
+	r        *resolve.Resolver
+	affixes  *affixes.Index
+	validate *validate.Validator
+	// All components and augments in the database, found on first use.
+	components []string
+	augments   []string
+}
+
+func New(r *resolve.Resolver, ix *affixes.Index, v *validate.Validator) *Editor {
+	return &Editor{r: r, affixes: ix, validate: v}
+}
+
+func (e *Editor) scan() {
+	if e.components != nil {
+		return
+	}
+	e.components, e.augments = []string{}, []string{}
+	for _, key := range e.r.DB.Keys() {
+		switch e.r.DB.Entries[key].String("Class") {
+		case "ItemRelic":
+			e.components = append(e.components, key)
+		case "ItemEnchantment":
+			e.augments = append(e.augments, key)
+		}
+	}
+}
+
+func (e *Editor) choice(record string) Choice {
+	name := e.r.BaseName(record)
+	if name == "" {
+		name = record
+	}
+	return Choice{Record: record, Name: name}
+}
+
+func byName(a Choice, b Choice) int {
+	return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.Record, b.Record))
+}
+
+// The records of `candidates` that the validator finds no fault with when put
+// into an item of `base` by `set`.
+func (e *Editor) fitting(base string, candidates []string, set func(*stash.Item, string)) []Choice {
+	choices := []Choice{}
+	for _, record := range candidates {
+		item := stash.Item{Base: base}
+		set(&item, record)
+		if len(e.validate.Compatibility(&item)) == 0 {
+			choices = append(choices, e.choice(record))
+		}
+	}
+	slices.SortFunc(choices, byName)
+	return choices
+}
+
+// List what can be chosen for an item of `base`. Affixes are those rolled by
+// the loot tables, at any level.
+func (e *Editor) Options(base string) Options {
+	e.scan()
+	base = database.NormalizeKey(base)
+	pool := e.affixes.Pool(base, 0)
+	opts := Options{
+		Base:        base,
+		Name:        pool.Name,
+		Prefixes:    pool.Prefixes,
+		Suffixes:    pool.Suffixes,
+		Completions: make(map[string][]Choice),
+		MaxStack:    e.r.MaxStack(base),
+	}
+	opts.Components = e.fitting(base, e.components, func(item *stash.Item, record string) { item.Material = record })
+	opts.Augments = e.fitting(base, e.augments, func(item *stash.Item, record string) { item.Enchantment = record })
+
+	owners := []string{base}
+	for _, c := range opts.Components {
+		owners = append(owners, c.Record)
+	}
+	for _, owner := range owners {
+		bonuses, _ := e.validate.CompletionBonuses(owner)
+		for _, bonus := range bonuses {
+			opts.Completions[owner] = append(opts.Completions[owner], e.choice(bonus))
+		}
+		slices.SortFunc(opts.Completions[owner], byName)
+	}
+	return opts
+}
+
+// Create the item described by `spec`, rolling the seeds that are not given.
+// Returns an `InvalidError` if the validator finds errors, so only items the
+// game would produce end up in a stash.
+func (e *Editor) Create(spec Spec) (stash.Item, error) {
+	item := stash.Item{
+		Base:                 database.NormalizeKey(spec.Base),
+		Prefix:               database.NormalizeKey(spec.Prefix),
+		Suffix:               database.NormalizeKey(spec.Suffix),
+		Material:             database.NormalizeKey(spec.Material),
+		RelicCompletionBonus: database.NormalizeKey(spec.RelicCompletionBonus),
+		Enchantment:          database.NormalizeKey(spec.Enchantment),
+		Seed:                 cmp.Or(spec.Seed, stash.NewSeed()),
+		StackSize:            max(spec.StackSize, 1),
+	}
+	if item.Material != "" || item.RelicCompletionBonus != "" {
+		item.RelicSeed = cmp.Or(spec.RelicSeed, stash.NewSeed())
+	}
+	if item.Enchantment != "" {
+		item.EnchantmentSeed = cmp.Or(spec.EnchantmentSeed, stash.NewSeed())
+	}
+	if spec.Reroll {
+		Reroll(&item)
+	}
+
+	if findings := e.validate.Check(&item); validate.HasErrors(findings) {
+		return stash.Item{}, &InvalidError{Findings: findings}
+	}
+	return item, nil
+}
+
+// Give `item` new seeds, which changes the values of its stats.
+func Reroll(item *stash.Item) {
+	item.Seed = stash.NewSeed()
+	if item.RelicSeed != 0 {
+		item.RelicSeed = stash.NewSeed()
+	}
+	if item.EnchantmentSeed != 0 {
+		item.EnchantmentSeed = stash.NewSeed()
+	}
+}
+
+// Put a copy of `item` into `tab` at grid position (`x`, `y`), or into the
+// first free cell of the tab if neither is given. Fails with
+// `stash.ErrNoRoom` if the item does not fit.
+func Place(tab *stash.StashTab, item *stash.Item, x *float32, y *float32, size stash.Size) error {
+	switch {
+	case x == nil && y == nil:
+		return tab.PutFirstFree(item, size)
+	case x == nil || y == nil:
+		return fmt.Errorf("a position needs both x and y")
+	}
+	item.SetPosition(*x, *y)
+	return tab.Put(item, size)
+}
@@ -0,0 +1,146 @@
+package editor
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/kenranunderscore/grimvault/backend/affixes"
+	"github.com/kenranunderscore/grimvault/backend/internal/testdb"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+	"github.com/kenranunderscore/grimvault/backend/validate"
+)
+
+const (
+	ring      = testdb.Ring
+	sword     = testdb.Sword
+	component = testdb.Component
+	augment   = testdb.Augment
+	bonus     = testdb.Bonus
+	fiery     = testdb.Fiery
+	valor     = testdb.Valor
+)
+
+func testEditor() *Editor {
+	r := testdb.Resolver()
+	ix := affixes.NewIndex(r)
+	return New(r, ix, validate.New(r, ix))
+}
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	e := testEditor()
+	opts := e.Options(ring)
+	if opts.Name != "Ring" || len(opts.Prefixes) != 3 || len(opts.Suffixes) != 1 || opts.MaxStack != 1 {
+		t.Errorf("expected the affixes of the ring, got %+v", opts)
+	}
+	if expected := []Choice{{component, "Component"}}; !slices.Equal(opts.Components, expected) {
+		t.Errorf("expected components %v, got %v", expected, opts.Components)
+	}
+	if expected := []Choice{{bonus, bonus}}; !slices.Equal(opts.Completions[component], expected) {
+		t.Errorf("expected completion bonuses %v, got %v", expected, opts.Completions)
+	}
+
+	if len(opts.Augments) != 0 {
+		t.Errorf("expected no augment to fit the ring, got %v", opts.Augments)
+	}
+
+	swordOpts := e.Options(sword)
+	if len(swordOpts.Components) != 0 {
+		t.Errorf("expected no component to fit the sword, got %+v", swordOpts)
+	}
+	if expected := []Choice{{augment, augment}}; !slices.Equal(swordOpts.Augments, expected) {
+		t.Errorf("expected augments %v, got %v", expected, swordOpts.Augments)
+	}
+	if e.Options(component).MaxStack != 100 {
+		t.Error("expected components to stack")
+	}
+}
+
+func TestCreate(t *testing.T) {
+	t.Parallel()
+
+	e := testEditor()
+	item, err := e.Create(Spec{Base: ring, Prefix: fiery, Suffix: valor, Material: component, RelicCompletionBonus: bonus, Seed: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.Seed != 42 || item.RelicSeed == 0 || item.EnchantmentSeed != 0 || item.StackSize != 1 {
+		t.Errorf("expected the given seed and a rolled relic seed, got %+v", item)
+	}
+
+	rerolled, err := e.Create(Spec{Base: ring, Seed: 42, Reroll: true})
+	if err != nil || rerolled.Seed == 42 {
+		t.Errorf("expected a new seed, got %+v (%v)", rerolled, err)
+	}
+
+	_, err = e.Create(Spec{Base: sword, Material: component})
+	var invalid *InvalidError
+	if !errors.As(err, &invalid) || len(invalid.Findings) != 1 || invalid.Findings[0].Kind != validate.ComponentSlot {
+		t.Errorf("expected the component not to fit, got %v", err)
+	}
+	if _, err := e.Create(Spec{Base: component, StackSize: 101}); !errors.As(err, &invalid) {
+		t.Errorf("expected the stack to be too large, got %v", err)
+	}
+}
+
+func TestReroll(t *testing.T) {
+	t.Parallel()
+
+	item := stash.Item{Base: ring, Seed: 1, EnchantmentSeed: 1}
+	Reroll(&item)
+	if item.Seed == 1 || item.EnchantmentSeed == 1 || item.RelicSeed != 0 {
+		t.Errorf("expected new seeds where there were any, got %+v", item)
+	}
+}
+
+func TestPlaceAndWrite(t *testing.T) {
+	t.Parallel()
+
+	st, err := stash.ReadStash("../test_data/stashes/transfer_empty.gst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	item, err := testEditor().Create(Spec{Base: component, StackSize: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tab := &st.Tabs[0]
+	at := func(x float32, y float32) (*float32, *float32) { return &x, &y }
+	outside, zero := at(float32(tab.Width), 0)
+	if err := Place(tab, &item, outside, zero, stash.SingleCell); !errors.Is(err, stash.ErrNoRoom) {
+		t.Errorf("expected a position outside of the tab to fail, got %v", err)
+	}
+	x, y := at(2, 3)
+	if err := Place(tab, &item, x, y, stash.SingleCell); err != nil {
+		t.Fatal(err)
+	}
+	if err := Place(tab, &item, x, y, stash.SingleCell); !errors.Is(err, stash.ErrNoRoom) {
+		t.Errorf("expected a taken cell to fail, got %v", err)
+	}
+	other := item
+	if err := Place(tab, &other, nil, nil, stash.SingleCell); err != nil {
+		t.Fatal(err)
+	}
+	if x, y := other.Position(); x != 0 || y != 0 {
+		t.Errorf("expected the first free cell (0, 0), got (%v, %v)", x, y)
+	}
+
+	file := filepath.Join(t.TempDir(), "transfer.gst")
+	if err := stash.WriteStash(file, st); err != nil {
+		t.Fatal(err)
+	}
+	read, err := stash.ReadStash(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := read.Tabs[0].Items
+	if len(items) != 2 || items[0].Base != component || items[0].StackSize != 5 || items[0].Seed != item.Seed {
+		t.Fatalf("expected the created item in the stash, got %+v", items)
+	}
+	if x, y := items[0].Position(); x != 2 || y != 3 {
+		t.Errorf("expected the item at (2, 3), got (%v, %v)", x, y)
+	}
+}
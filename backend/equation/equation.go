@@ -0,0 +1,425 @@
+// Parses and evaluates the equations the game database stores as strings,
+// e.g. the formulas for the attribute requirements of items.
+//
+// Equations are arithmetic expressions over numbers and variables with the
+// operators `+`, `-`, `*`, `/` and `^`, parentheses and the functions `min`,
+// `max`, `floor`, `ceil`, `round` and `abs`. Nothing else can be expressed, so
+// evaluating an equation taken from a database is safe.
+package equation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kenranunderscore/grimvault/backend/database"
+)
+
+// Errors of evaluation, e.g. because a variable is not defined, wrap this.
+var ErrEval = errors.New("cannot evaluate equation")
+
+// An equation that is not well-formed.
+type SyntaxError struct {
+	Equation string
+	// The byte offset of the error in the equation.
+	Pos     int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid equation '%s': %s at position %d", e.Equation, e.Message, e.Pos+1)
+}
+
+// The values of the variables of an equation, or false if there is none by
+// that name.
+type Vars func(name string) (float64, bool)
+
+// Variables taken from the numeric fields of `entry`.
+func EntryVars(entry *database.Entry) Vars {
+	return func(name string) (float64, bool) {
+		value, ok := entry.Get(name)
+		if !ok {
+			return 0, false
+		}
+		switch value.(type) {
+		case float32, uint32:
+			return database.ToFloat(value), true
+		}
+		return 0, false
+	}
+}
+
+// Variables taken from a map.
+func MapVars(m map[string]float64) Vars {
+	return func(name string) (float64, bool) {
+		v, ok := m[name]
+		return v, ok
+	}
+}
+
+// A parsed equation.
+type Equation struct {
+	text string
+	root node
+}
+
+// Parse `text`, returning a `*SyntaxError` if it is not a valid equation.
+func Parse(text string) (*Equation, error) {
+	p := &parser{text: text}
+	p.skipSpace()
+	root, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.text) {
+		return nil, p.errorf("unexpected '%c'", p.text[p.pos])
+	}
+	return &Equation{text: text, root: root}, nil
+}
+
+func (e *Equation) String() string {
+	return e.text
+}
+
+// The names of the variables the equation uses, sorted and without
+// duplicates.
+func (e *Equation) Variables() []string {
+	var names []string
+	e.root.variables(&names)
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// Evaluate the equation with the variables `vars`. Variables that are not
+// defined and results that are not finite, e.g. from dividing by zero, are
+// errors.
+func (e *Equation) Eval(vars Vars) (float64, error) {
+	v, err := e.root.eval(vars)
+	if err != nil {
+		return 0, fmt.Errorf("%w '%s': %w", ErrEval, e.text, err)
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("%w '%s': the result is not a number", ErrEval, e.text)
+	}
+	return v, nil
+}
+
+// Parse and evaluate `text` in one go.
+func Eval(text string, vars Vars) (float64, error) {
+	e, err := Parse(text)
+	if err != nil {
+		return 0, err
+	}
+	return e.Eval(vars)
+}
+
+type node interface {
+	eval(vars Vars) (float64, error)
+	variables(names *[]string)
+}
+
+type number float64
+
+func (n number) eval(Vars) (float64, error) {
+	return float64(n), nil
+}
+
+func (n number) variables(*[]string) {}
+
+type variable string
+
+func (v variable) eval(vars Vars) (float64, error) {
+	value, ok := vars(string(v))
+	if !ok {
+		return 0, fmt.Errorf("variable '%s' is not defined", v)
+	}
+	return value, nil
+}
+
+func (v variable) variables(names *[]string) {
+	*names = append(*names, string(v))
+}
+
+type negate struct {
+	operand node
+}
+
+func (n *negate) eval(vars Vars) (float64, error) {
+	v, err := n.operand.eval(vars)
+	return -v, err
+}
+
+func (n *negate) variables(names *[]string) {
+	n.operand.variables(names)
+}
+
+type binary struct {
+	op          byte
+	left, right node
+}
+
+func (b *binary) eval(vars Vars) (float64, error) {
+	l, err := b.left.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	r, err := b.right.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	switch b.op {
+	case '+':
+		return l + r, nil
+	case '-':
+		return l - r, nil
+	case '*':
+		return l * r, nil
+	case '/':
+		if r == 0 {
+			return 0, errors.New("division by zero")
+		}
+		return l / r, nil
+	}
+	return math.Pow(l, r), nil
+}
+
+func (b *binary) variables(names *[]string) {
+	b.left.variables(names)
+	b.right.variables(names)
+}
+
+// The functions equations may call, by name and number of arguments.
+var functions = map[string]struct {
+	arity int
+	fn    func(args []float64) float64
+}{
+	"min":   {2, func(args []float64) float64 { return math.Min(args[0], args[1]) }},
+	"max":   {2, func(args []float64) float64 { return math.Max(args[0], args[1]) }},
+	"floor": {1, func(args []float64) float64 { return math.Floor(args[0]) }},
+	"ceil":  {1, func(args []float64) float64 { return math.Ceil(args[0]) }},
+	"round": {1, func(args []float64) float64 { return math.Round(args[0]) }},
+	"abs":   {1, func(args []float64) float64 { return math.Abs(args[0]) }},
+}
+
+type call struct {
+	name string
+	args []node
+}
+
+func (c *call) eval(vars Vars) (float64, error) {
+	args := make([]float64, len(c.args))
+	for i, arg := range c.args {
+		v, err := arg.eval(vars)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = v
+	}
+	return functions[c.name].fn(args), nil
+}
+
+func (c *call) variables(names *[]string) {
+	for _, arg := range c.args {
+		arg.variables(names)
+	}
+}
+
+// A recursive descent parser working on the text directly, as the tokens are
+// simple enough not to need a lexer.
+type parser struct {
+	text  string
+	pos   int
+	depth int
+}
+
+// How deeply terms may be nested, so that malformed equations cannot exhaust
+// the stack.
+const maxDepth = 100
+
+func (p *parser) errorf(format string, args ...any) *SyntaxError {
+	return &SyntaxError{Equation: p.text, Pos: p.pos, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.text) && strings.IndexByte(" \t\r\n", p.text[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// Consume `c` and the space after it if it comes next.
+func (p *parser) accept(c byte) bool {
+	if p.pos < len(p.text) && p.text[p.pos] == c {
+		p.pos++
+		p.skipSpace()
+		return true
+	}
+	return false
+}
+
+// sum = product (("+" | "-") product)*
+func (p *parser) sum() (node, error) {
+	left, err := p.product()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var op byte
+		switch {
+		case p.accept('+'):
+			op = '+'
+		case p.accept('-'):
+			op = '-'
+		default:
+			return left, nil
+		}
+		right, err := p.product()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op, left, right}
+	}
+}
+
+// product = unary (("*" | "/") unary)*
+func (p *parser) product() (node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		var op byte
+		switch {
+		case p.accept('*'):
+			op = '*'
+		case p.accept('/'):
+			op = '/'
+		default:
+			return left, nil
+		}
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op, left, right}
+	}
+}
+
+// unary = ("-" | "+") unary | power
+func (p *parser) unary() (node, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return nil, p.errorf("nested too deeply")
+	}
+	if p.accept('-') {
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &negate{operand}, nil
+	}
+	if p.accept('+') {
+		return p.unary()
+	}
+	return p.power()
+}
+
+// power = atom ("^" unary)?, which makes `^` right-associative and bind
+// tighter than a leading minus, as usual: -2^2 is -4.
+func (p *parser) power() (node, error) {
+	base, err := p.atom()
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept('^') {
+		return base, nil
+	}
+	exponent, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return &binary{'^', base, exponent}, nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c|0x20 >= 'a' && c|0x20 <= 'z')
+}
+
+// atom = number | variable | function "(" sum ("," sum)* ")" | "(" sum ")"
+func (p *parser) atom() (node, error) {
+	if p.pos >= len(p.text) {
+		return nil, p.errorf("unexpected end of equation")
+	}
+	start := p.pos
+	c := p.text[p.pos]
+	switch {
+	case c == '(':
+		p.accept('(')
+		inner, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(')') {
+			return nil, p.errorf("expected ')'")
+		}
+		return inner, nil
+	case isDigit(c) || c == '.':
+		for p.pos < len(p.text) && (isDigit(p.text[p.pos]) || p.text[p.pos] == '.') {
+			p.pos++
+		}
+		text := p.text[start:p.pos]
+		v, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			p.pos = start
+			return nil, p.errorf("invalid number '%s'", text)
+		}
+		p.skipSpace()
+		return number(v), nil
+	case isIdentStart(c):
+		for p.pos < len(p.text) && (isIdentStart(p.text[p.pos]) || isDigit(p.text[p.pos])) {
+			p.pos++
+		}
+		name := p.text[start:p.pos]
+		p.skipSpace()
+		if !p.accept('(') {
+			return variable(name), nil
+		}
+		return p.call(name, start)
+	}
+	return nil, p.errorf("unexpected '%c'", c)
+}
+
+// Parse the arguments of a call of `name`, which starts at `start`.
+func (p *parser) call(name string, start int) (node, error) {
+	fn, ok := functions[strings.ToLower(name)]
+	if !ok {
+		p.pos = start
+		return nil, p.errorf("unknown function '%s'", name)
+	}
+	c := &call{name: strings.ToLower(name)}
+	for {
+		arg, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		c.args = append(c.args, arg)
+		if p.accept(')') {
+			break
+		}
+		if !p.accept(',') {
+			return nil, p.errorf("expected ',' or ')'")
+		}
+	}
+	if len(c.args) != fn.arity {
+		p.pos = start
+		return nil, p.errorf("%s expects %d arguments, got %d", name, fn.arity, len(c.args))
+	}
+	return c, nil
+}
@@ -0,0 +1,102 @@
+package equation
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kenranunderscore/grimvault/backend/database"
+)
+
+func TestEval(t *testing.T) {
+	t.Parallel()
+
+	vars := MapVars(map[string]float64{"itemLevel": 50, "totalAttCount": 4})
+	cases := []struct {
+		equation string
+		expected float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 4 - 3", 3},
+		{"2 ^ 3 ^ 2", 512},
+		{"-2^2", -4},
+		{"2^-1", 0.5},
+		{"--3", 3},
+		{".5 * 4", 2},
+		{"((itemLevel * 1.8) + (totalAttCount * 3.5)) * 0.9", 93.6},
+		{"max(itemLevel - 10, 1) + floor(2.7) + ceil(0.2) + round(1.5) + abs(-1)", 46},
+		{"MIN(itemLevel, 10)", 10},
+	}
+	for _, c := range cases {
+		v, err := Eval(c.equation, vars)
+		if err != nil {
+			t.Errorf("%s: %v", c.equation, err)
+		} else if math.Abs(v-c.expected) > 1e-9 {
+			t.Errorf("%s: expected %v, got %v", c.equation, c.expected, v)
+		}
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		equation string
+		pos      int
+	}{
+		{"", 0},
+		{"1 +", 3},
+		{"(1 + 2", 6},
+		{"1 2", 2},
+		{"1.2.3", 0},
+		{"exp(1)", 0},
+		{"max(1)", 0},
+		{"1 $ 2", 2},
+		{strings.Repeat("(", 1000) + "1" + strings.Repeat(")", 1000), 100},
+	}
+	for _, c := range cases {
+		_, err := Parse(c.equation)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("%q: expected a syntax error, got %v", c.equation, err)
+		} else if syntaxErr.Pos != c.pos {
+			t.Errorf("%q: expected the error at %d, got %v", c.equation, c.pos, err)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, equation := range []string{"itemLevel * 2", "1 / (2 - 2)", "10 ^ 1000"} {
+		if _, err := Eval(equation, MapVars(nil)); !errors.Is(err, ErrEval) {
+			t.Errorf("%s: expected an evaluation error, got %v", equation, err)
+		}
+	}
+}
+
+func TestEntryVars(t *testing.T) {
+	t.Parallel()
+
+	entry := database.Entry{Stats: []database.Stat{
+		{Name: "itemLevel", Value: float32(20)},
+		{Name: "count", Value: uint32(3)},
+		{Name: "name", Value: "tagName"},
+	}}
+	e, err := Parse("itemLevel * count")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := e.Variables(); !slices.Equal(names, []string{"count", "itemLevel"}) {
+		t.Errorf("unexpected variables %v", names)
+	}
+	if v, err := e.Eval(EntryVars(&entry)); err != nil || v != 60 {
+		t.Errorf("expected 60, got %v, %v", v, err)
+	}
+	if _, err := Eval("name + 1", EntryVars(&entry)); err == nil {
+		t.Errorf("expected text fields not to be variables")
+	}
+}
@@ -0,0 +1,206 @@
+package gds
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/kenranunderscore/grimvault/backend/rawreader"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+// The only version of the format that is known so far.
+const Version = 1
+
+// An item of a GDStash database export, together with the character it is
+// assigned to in GDStash. `Owner` is empty for unassigned items.
+type Entry struct {
+	Item     stash.Item
+	Owner    string
+	Hardcore bool
+}
+
+var errTooLittleData = errors.New("too little data")
+
+// Wraps a reader with bounds checks, as exports may well be truncated.
+type decoder struct {
+	r   *rawreader.T
+	err error
+}
+
+func (d *decoder) need(count uint32) bool {
+	if d.err != nil {
+		return false
+	}
+	if uint64(d.r.Cursor)+uint64(count) > uint64(len(d.r.Data)) {
+		d.err = fmt.Errorf("%w at offset %d", errTooLittleData, d.r.Cursor)
+		return false
+	}
+	return true
+}
+
+func (d *decoder) uint32() uint32 {
+	if !d.need(4) {
+		return 0
+	}
+	return d.r.Uint32()
+}
+
+func (d *decoder) bool() bool {
+	if !d.need(1) {
+		return false
+	}
+	return d.r.Byte() != 0
+}
+
+// Strings are prefixed with their length as a single byte.
+func (d *decoder) string() string {
+	if !d.need(1) {
+		return ""
+	}
+	length := uint32(d.r.Byte())
+	if !d.need(length) {
+		return ""
+	}
+	return string(d.r.Bytes(length))
+}
+
+func (d *decoder) readEntry() Entry {
+	var item stash.Item
+	item.Base = d.string()
+	item.Prefix = d.string()
+	item.Suffix = d.string()
+	item.Modifier = d.string()
+	item.Transmute = d.string()
+	item.Seed = d.uint32()
+	item.Material = d.string()
+	item.RelicCompletionBonus = d.string()
+	item.RelicSeed = d.uint32()
+	item.Enchantment = d.string()
+	_ = d.uint32()
+	item.EnchantmentSeed = d.uint32()
+	item.MaterialCombines = d.uint32()
+	item.StackSize = d.uint32()
+	hardcore := d.bool()
+	owner := d.string()
+	return Entry{Item: item, Owner: owner, Hardcore: hardcore}
+}
+
+// Decode a GDStash export from `data`.
+func Decode(data []byte) ([]Entry, error) {
+	d := &decoder{r: rawreader.New(data)}
+	version := d.uint32()
+	count := d.uint32()
+	if d.err != nil {
+		return nil, fmt.Errorf("could not read header: %w", d.err)
+	}
+	if version != Version {
+		return nil, fmt.Errorf("unsupported version: %d", version)
+	}
+
+	// Every entry takes at least 35 bytes, which bounds the allocation for
+	// corrupt counts.
+	entries := make([]Entry, 0, min(count, uint32(len(data))/35))
+	for i := range count {
+		entry := d.readEntry()
+		if d.err != nil {
+			return entries, fmt.Errorf("could not read item %d: %w", i, d.err)
+		}
+		if entry.Item.Base == "" {
+			return entries, fmt.Errorf("item %d has no base record", i)
+		}
+		entries = append(entries, entry)
+	}
+
+	if d.r.Cursor != uint32(len(data)) {
+		return entries, fmt.Errorf("unexpected trailing data at offset %d", d.r.Cursor)
+	}
+	return entries, nil
+}
+
+// Read the GDStash export `file`.
+func ReadFile(file string) ([]Entry, error) {
+	r, err := rawreader.FromFile(file)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := Decode(r.Data)
+	if err != nil {
+		return entries, fmt.Errorf("could not decode '%s': %w", file, err)
+	}
+	return entries, nil
+}
+
+type encoder struct {
+	buf bytes.Buffer
+	err error
+}
+
+func (e *encoder) uint32(n uint32) {
+	e.buf.Write(binary.LittleEndian.AppendUint32(nil, n))
+}
+
+func (e *encoder) bool(b bool) {
+	if b {
+		e.buf.WriteByte(1)
+	} else {
+		e.buf.WriteByte(0)
+	}
+}
+
+func (e *encoder) string(s string) {
+	if len(s) > math.MaxUint8 {
+		if e.err == nil {
+			e.err = fmt.Errorf("string too long for GDStash: '%s'", s)
+		}
+		return
+	}
+	e.buf.WriteByte(byte(len(s)))
+	e.buf.WriteString(s)
+}
+
+func (e *encoder) writeEntry(entry *Entry) {
+	item := &entry.Item
+	e.string(item.Base)
+	e.string(item.Prefix)
+	e.string(item.Suffix)
+	e.string(item.Modifier)
+	e.string(item.Transmute)
+	e.uint32(item.Seed)
+	e.string(item.Material)
+	e.string(item.RelicCompletionBonus)
+	e.uint32(item.RelicSeed)
+	e.string(item.Enchantment)
+	e.uint32(0)
+	e.uint32(item.EnchantmentSeed)
+	e.uint32(item.MaterialCombines)
+	e.uint32(item.StackSize)
+	e.bool(entry.Hardcore)
+	e.string(entry.Owner)
+}
+
+// Encode `entries` in the format of a GDStash export.
+func Encode(entries []Entry) ([]byte, error) {
+	e := &encoder{}
+	e.uint32(Version)
+	e.uint32(uint32(len(entries)))
+	for i := range entries {
+		e.writeEntry(&entries[i])
+	}
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.buf.Bytes(), nil
+}
+
+// Write `entries` as GDStash export to `file`.
+func WriteFile(file string, entries []Entry) error {
+	data, err := Encode(entries)
+	if err != nil {
+		return fmt.Errorf("could not encode '%s': %w", file, err)
+	}
+	return os.WriteFile(file, data, 0644)
+}
@@ -0,0 +1,102 @@
+package gds
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+func TestReadExample(t *testing.T) {
+	t.Parallel()
+
+	entries, err := ReadFile("../test_data/example.gds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(entries); n != 7694 {
+		t.Fatalf("expected 7694 items, got %d", n)
+	}
+
+	first := Entry{
+		Item: stash.Item{
+			Base:      "records/items/enchants/a01a_enchant.dbr",
+			StackSize: 5,
+		},
+		Owner: "NightTank",
+	}
+	if entries[0] != first {
+		t.Errorf("unexpected first entry %+v", entries[0])
+	}
+
+	helm := entries[4].Item
+	if helm.Base != "records/items/gearhead/a06_head005.dbr" ||
+		helm.Suffix != "records/items/lootaffixes/suffix/b_ar041_ar_b.dbr" ||
+		helm.Material != "records/items/materia/compa_polishedemerald.dbr" ||
+		helm.MaterialCombines != 3 {
+		t.Errorf("unexpected fifth item %+v", helm)
+	}
+
+	hardcore := 0
+	owners := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.Hardcore {
+			hardcore++
+		}
+		owners[entry.Owner] = true
+	}
+	if hardcore != 755 {
+		t.Errorf("expected 755 hardcore items, got %d", hardcore)
+	}
+	if len(owners) != 28 {
+		t.Errorf("expected 27 characters and unassigned items, got %d owners", len(owners))
+	}
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile("../test_data/example.gds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := Decode(data[:1000])
+	if err == nil {
+		t.Fatal("expected an error for truncated data")
+	}
+	if len(entries) == 0 {
+		t.Errorf("expected the items before the truncation to be returned")
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile("../test_data/example.gds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, err := Encode(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encoded, data) {
+		t.Errorf("encoded export differs from the original")
+	}
+}
+
+func TestEncodeRejectsLongStrings(t *testing.T) {
+	t.Parallel()
+
+	long := Entry{Item: stash.Item{Base: "records/" + strings.Repeat("x", 300) + ".dbr"}}
+	if _, err := Encode([]Entry{long}); err == nil {
+		t.Errorf("expected an error for a record path longer than 255 bytes")
+	}
+}
@@ -0,0 +1,81 @@
+package testdb
+
+import (
+	"github.com/kenranunderscore/grimvault/backend/arc"
+	"github.com/kenranunderscore/grimvault/backend/database"
+	"github.com/kenranunderscore/grimvault/backend/resolve"
+)
+
+// The records of the database of `Resolver`.
+const (
+	Ring      = "records/items/gearaccessories/rings/a001_ring.dbr"
+	Sword     = "records/items/gearweapons/swords1h/a001_sword.dbr"
+	Relic     = "records/items/crafting/blueprints/relic/a001_relic.dbr"
+	Component = "records/items/materia/compa_ring.dbr"
+	Augment   = "records/items/enchants/a001_sword.dbr"
+	Bonuses   = "records/items/materia/bonuses/compa_ring_bonus.dbr"
+	Bonus     = "records/items/lootaffixes/completion/ring_bonus01.dbr"
+	// A completion bonus of another relic.
+	OtherBonus = "records/items/lootaffixes/completion/relic_bonus01.dbr"
+	Fiery      = "records/items/lootaffixes/prefix/a001_fire.dbr"
+	// A prefix that only rolls up to level 30.
+	Ancient = "records/items/lootaffixes/prefix/a002_ancient.dbr"
+	// A prefix that only rolls from level 70 on.
+	Mighty = "records/items/lootaffixes/prefix/a003_mighty.dbr"
+	Valor  = "records/items/lootaffixes/suffix/a001_valor.dbr"
+)
+
+// The stats given by `pairs` of names and values.
+func Stats(pairs ...any) []database.Stat {
+	var res []database.Stat
+	for i := 0; i < len(pairs); i += 2 {
+		res = append(res, database.Stat{Name: pairs[i].(string), Value: pairs[i+1]})
+	}
+	return res
+}
+
+// A resolver for a small database: a level 60 ring that rolls the `Fiery`,
+// `Ancient` and `Mighty` prefixes and the `Valor` suffix, a sword only the `Augment` fits,
+// and a ring `Component` with its completion `Bonus`.
+func Resolver() *resolve.Resolver {
+	db := database.New([]database.Entry{
+		{Key: Ring, Stats: Stats("Class", "ArmorJewelry_Ring", "itemNameTag", "tagRing", "itemLevel", float32(60))},
+		{Key: Sword, Stats: Stats("Class", "WeaponMelee_Sword")},
+		{Key: Relic, Stats: Stats("Class", "ItemArtifact", "bonusTableName", "records/items/relic/missing_bonuses.dbr")},
+		{Key: Component, Stats: Stats(
+			"Class", "ItemRelic",
+			"description", "tagComponent",
+			"ring", uint32(1),
+			"completedRelicLevel", uint32(3),
+			"maxStackSize", uint32(100),
+			"bonusTableName", Bonuses,
+		)},
+		{Key: Bonuses, Stats: Stats("randomizerName1", Bonus, "randomizerWeight1", uint32(1))},
+		{Key: Augment, Stats: Stats("Class", "ItemEnchantment", "sword", uint32(1))},
+		{Key: Bonus, Stats: Stats("Class", "LootRandomizer")},
+		{Key: OtherBonus, Stats: Stats("Class", "LootRandomizer")},
+		{Key: "records/items/loottables/tdyn_ring.dbr", Stats: Stats(
+			"lootName1", Ring, "lootWeight1", float32(1),
+			"prefixTableName1", "records/items/lootaffixes/prefix/tables/ring.dbr", "prefixTableWeight1", float32(1),
+			"suffixTableName1", "records/items/lootaffixes/suffix/tables/ring.dbr", "suffixTableWeight1", float32(1),
+			"bothPrefixSuffix", float32(1),
+		)},
+		{Key: "records/items/lootaffixes/prefix/tables/ring.dbr", Stats: Stats(
+			"randomizerName1", Fiery, "randomizerWeight1", float32(1),
+			"randomizerName2", Ancient, "randomizerWeight2", float32(1), "randomizerLevelMax2", float32(30),
+			"randomizerName3", Mighty, "randomizerWeight3", float32(1), "randomizerLevelMin3", float32(70),
+		)},
+		{Key: "records/items/lootaffixes/suffix/tables/ring.dbr", Stats: Stats(
+			"randomizerName1", Valor, "randomizerWeight1", float32(1),
+		)},
+		{Key: Fiery, Stats: Stats("Class", "LootRandomizer")},
+		{Key: Ancient, Stats: Stats("Class", "LootRandomizer")},
+		{Key: Mighty, Stats: Stats("Class", "LootRandomizer")},
+		{Key: Valor, Stats: Stats("Class", "LootRandomizer")},
+	})
+	tags := arc.NewTags([]arc.Tag{
+		{Tag: "tagRing", Name: "Ring"},
+		{Tag: "tagComponent", Name: "Component"},
+	})
+	return resolve.New(db, tags)
+}
@@ -0,0 +1,398 @@
+package locate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// Grim Dawn's Steam app id, which names the Proton prefix.
+const SteamAppId = "219990"
+
+const (
+	softcoreStash = "transfer.gst"
+	hardcoreStash = "transfer.gsh"
+	characterFile = "player.gdc"
+)
+
+// User supplied paths that are searched in addition to (and before) the
+// well-known locations.
+type Config struct {
+	SaveDirs []string `json:"saveDirs"`
+	GameDirs []string `json:"gameDirs"`
+}
+
+// The default location of the config file, in the user's config directory.
+func DefaultConfigFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "grimvault", "config.json"), nil
+}
+
+// Read a `Config` from a JSON file. A missing file yields an empty config.
+func LoadConfig(file string) (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("could not read config '%s': %w", file, err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("could not parse config '%s': %w", file, err)
+	}
+	return cfg, nil
+}
+
+// Knows where to look for Grim Dawn files on this machine.
+type Finder struct {
+	Home   string
+	GOOS   string
+	Getenv func(string) string
+	Config Config
+}
+
+// Create a `Finder` for the current user and operating system.
+func NewFinder(cfg Config) (*Finder, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("could not determine home directory: %w", err)
+	}
+	return &Finder{Home: home, GOOS: runtime.GOOS, Getenv: os.Getenv, Config: cfg}, nil
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+func appendUnique(paths []string, path string) []string {
+	path = filepath.Clean(path)
+	if slices.Contains(paths, path) {
+		return paths
+	}
+	return append(paths, path)
+}
+
+// The Steam installations that might exist for the user.
+func (f *Finder) steamRoots() []string {
+	if f.GOOS == "windows" {
+		var roots []string
+		for _, env := range []string{"ProgramFiles(x86)", "ProgramFiles"} {
+			if dir := f.Getenv(env); dir != "" {
+				roots = append(roots, filepath.Join(dir, "Steam"))
+			}
+		}
+		return roots
+	}
+	return []string{
+		filepath.Join(f.Home, ".steam", "steam"),
+		filepath.Join(f.Home, ".local", "share", "Steam"),
+		filepath.Join(f.Home, ".var", "app", "com.valvesoftware.Steam", ".local", "share", "Steam"),
+	}
+}
+
+var libraryPathRegexp = regexp.MustCompile(`"path"\s+"([^"]+)"`)
+
+// All Steam library folders, including those configured in
+// `steamapps/libraryfolders.vdf`.
+func (f *Finder) steamLibraries() []string {
+	var libraries []string
+	for _, root := range f.steamRoots() {
+		libraries = appendUnique(libraries, root)
+		data, err := os.ReadFile(filepath.Join(root, "steamapps", "libraryfolders.vdf"))
+		if err != nil {
+			continue
+		}
+		for _, match := range libraryPathRegexp.FindAllStringSubmatch(string(data), -1) {
+			path := strings.ReplaceAll(match[1], `\\`, `\`)
+			libraries = appendUnique(libraries, path)
+		}
+	}
+	return libraries
+}
+
+// The "Documents" directories of all users in a Wine prefix.
+func wineDocuments(prefix string) []string {
+	users, err := os.ReadDir(filepath.Join(prefix, "drive_c", "users"))
+	if err != nil {
+		return nil
+	}
+	var docs []string
+	for _, user := range users {
+		if user.IsDir() {
+			docs = append(docs, filepath.Join(prefix, "drive_c", "users", user.Name(), "Documents"))
+		}
+	}
+	return docs
+}
+
+func saveDirIn(documents string) string {
+	return filepath.Join(documents, "My Games", "Grim Dawn", "save")
+}
+
+// All locations where a save directory might be, in order of preference. The
+// directories need not exist.
+func (f *Finder) SaveDirCandidates() []string {
+	var dirs []string
+	for _, dir := range f.Config.SaveDirs {
+		dirs = appendUnique(dirs, dir)
+	}
+
+	if f.GOOS == "windows" {
+		profile := f.Getenv("USERPROFILE")
+		if profile == "" {
+			profile = f.Home
+		}
+		dirs = appendUnique(dirs, saveDirIn(filepath.Join(profile, "Documents")))
+		if oneDrive := f.Getenv("OneDrive"); oneDrive != "" {
+			dirs = appendUnique(dirs, saveDirIn(filepath.Join(oneDrive, "Documents")))
+		}
+	} else {
+		for _, library := range f.steamLibraries() {
+			prefix := filepath.Join(library, "steamapps", "compatdata", SteamAppId, "pfx")
+			for _, docs := range wineDocuments(prefix) {
+				dirs = appendUnique(dirs, saveDirIn(docs))
+			}
+		}
+
+		prefixes := []string{filepath.Join(f.Home, ".wine")}
+		if prefix := f.Getenv("WINEPREFIX"); prefix != "" {
+			prefixes = append([]string{prefix}, prefixes...)
+		}
+		for _, prefix := range prefixes {
+			for _, docs := range wineDocuments(prefix) {
+				dirs = appendUnique(dirs, saveDirIn(docs))
+			}
+		}
+	}
+
+	// Steam cloud saves live in the user data of each Steam account.
+	for _, root := range f.steamRoots() {
+		accounts, err := os.ReadDir(filepath.Join(root, "userdata"))
+		if err != nil {
+			continue
+		}
+		for _, account := range accounts {
+			dirs = appendUnique(dirs, filepath.Join(root, "userdata", account.Name(), SteamAppId, "remote", "save"))
+		}
+	}
+
+	return dirs
+}
+
+// All existing save directories.
+func (f *Finder) SaveDirs() []string {
+	var dirs []string
+	for _, dir := range f.SaveDirCandidates() {
+		if isDir(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// All locations where the game might be installed, in order of preference.
+// The directories need not exist.
+func (f *Finder) GameDirCandidates() []string {
+	var dirs []string
+	for _, dir := range f.Config.GameDirs {
+		dirs = appendUnique(dirs, dir)
+	}
+	for _, library := range f.steamLibraries() {
+		dirs = appendUnique(dirs, filepath.Join(library, "steamapps", "common", "Grim Dawn"))
+	}
+	if f.GOOS == "windows" {
+		for _, env := range []string{"ProgramFiles(x86)", "ProgramFiles"} {
+			if dir := f.Getenv(env); dir != "" {
+				dirs = appendUnique(dirs, filepath.Join(dir, "GOG Galaxy", "Games", "Grim Dawn"))
+			}
+		}
+	} else {
+		dirs = appendUnique(dirs, filepath.Join(f.Home, "GOG Games", "Grim Dawn"))
+	}
+	return dirs
+}
+
+// All existing game installations, i.e. directories containing the main
+// database.
+func (f *Finder) GameDirs() []string {
+	var dirs []string
+	for _, dir := range f.GameDirCandidates() {
+		if isFile(filepath.Join(dir, "database", "database.arz")) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// A transfer stash file. `Mod` is empty for the unmodded game.
+type StashFile struct {
+	Path     string
+	Mod      string
+	Hardcore bool
+}
+
+// The id of the stash file within the save directory: "softcore" or
+// "hardcore", prefixed by the mod name and a colon for mod stashes.
+func (file StashFile) Id() string {
+	id := "softcore"
+	if file.Hardcore {
+		id = "hardcore"
+	}
+	if file.Mod != "" {
+		id = file.Mod + ":" + id
+	}
+	return id
+}
+
+// A character's save file. Characters of custom games live in the "user"
+// directory instead of "main".
+type Character struct {
+	Name   string
+	Path   string
+	Custom bool
+}
+
+// The contents of a save directory.
+type SaveDir struct {
+	Path       string
+	Stashes    []StashFile
+	Characters []Character
+}
+
+func stashesIn(dir string, mod string) []StashFile {
+	var stashes []StashFile
+	for _, name := range []string{softcoreStash, hardcoreStash} {
+		path := filepath.Join(dir, name)
+		if isFile(path) {
+			stashes = append(stashes, StashFile{Path: path, Mod: mod, Hardcore: name == hardcoreStash})
+		}
+	}
+	return stashes
+}
+
+func charactersIn(dir string, custom bool) []Character {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var characters []Character
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name(), characterFile)
+		if entry.IsDir() && isFile(path) {
+			name := strings.TrimPrefix(entry.Name(), "_")
+			characters = append(characters, Character{Name: name, Path: path, Custom: custom})
+		}
+	}
+	return characters
+}
+
+// Enumerate the transfer stashes (including those of mods, which live in
+// subdirectories named after the mod) and characters in the save directory
+// `dir`.
+func ScanSaveDir(dir string) (SaveDir, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return SaveDir{}, fmt.Errorf("could not read save directory '%s': %w", dir, err)
+	}
+
+	save := SaveDir{Path: dir, Stashes: stashesIn(dir, "")}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		switch name := entry.Name(); name {
+		case "main":
+			save.Characters = append(save.Characters, charactersIn(filepath.Join(dir, name), false)...)
+		case "user":
+			save.Characters = append(save.Characters, charactersIn(filepath.Join(dir, name), true)...)
+		default:
+			save.Stashes = append(save.Stashes, stashesIn(filepath.Join(dir, name), name)...)
+		}
+	}
+	return save, nil
+}
+
+// The game data files of an installation, ordered such that files of later
+// expansions come after the ones they override.
+type Installation struct {
+	Path      string
+	Databases []string
+	Resources []string
+}
+
+// The directories containing game data, the base game first.
+func contentDirs(dir string) []string {
+	dirs := []string{dir}
+	expansions, _ := filepath.Glob(filepath.Join(dir, "gdx*"))
+	slices.Sort(expansions)
+	for _, expansion := range expansions {
+		if isDir(expansion) {
+			dirs = append(dirs, expansion)
+		}
+	}
+	return dirs
+}
+
+func globSorted(pattern string) []string {
+	files, _ := filepath.Glob(pattern)
+	slices.Sort(files)
+	return files
+}
+
+// Find the `.arz` databases and `.arc` resource archives of the game installed
+// in `dir`, including those of the expansions.
+func ScanGameDir(dir string) (Installation, error) {
+	install := Installation{Path: dir}
+	for _, content := range contentDirs(dir) {
+		install.Databases = append(install.Databases, globSorted(filepath.Join(content, "database", "*.arz"))...)
+		install.Resources = append(install.Resources, globSorted(filepath.Join(content, "resources", "*.arc"))...)
+	}
+	if len(install.Databases) == 0 {
+		return install, fmt.Errorf("no game database found in '%s'", dir)
+	}
+	return install, nil
+}
+
+// The localization archives (`Text_<LANG>.arc`) of an installation for the
+// given language code, e.g. "EN" or "DE".
+func (install *Installation) TextArchives(language string) []string {
+	want := "text_" + strings.ToLower(language) + ".arc"
+	var files []string
+	for _, file := range install.Resources {
+		if strings.ToLower(filepath.Base(file)) == want {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// The codes of all languages the installation has localization archives for,
+// sorted and upper-cased, e.g. ["DE", "EN"].
+func (install *Installation) Languages() []string {
+	var languages []string
+	for _, file := range install.Resources {
+		name := strings.ToLower(filepath.Base(file))
+		if lang, ok := strings.CutPrefix(name, "text_"); ok && strings.HasSuffix(lang, ".arc") {
+			languages = append(languages, strings.ToUpper(strings.TrimSuffix(lang, ".arc")))
+		}
+	}
+	slices.Sort(languages)
+	return slices.Compact(languages)
+}
@@ -0,0 +1,157 @@
+package locate
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func touch(t *testing.T, parts ...string) string {
+	path := filepath.Join(parts...)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte{0}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newLinuxFinder(home string, env map[string]string) *Finder {
+	return &Finder{
+		Home:   home,
+		GOOS:   "linux",
+		Getenv: func(key string) string { return env[key] },
+	}
+}
+
+func TestSaveDirsOnProtonAndWine(t *testing.T) {
+	t.Parallel()
+
+	home := t.TempDir()
+	steam := filepath.Join(home, ".local", "share", "Steam")
+	proton := saveDirIn(filepath.Join(steam, "steamapps", "compatdata", SteamAppId, "pfx", "drive_c", "users", "steamuser", "Documents"))
+	touch(t, proton, softcoreStash)
+
+	wine := filepath.Join(home, "prefixes", "gd")
+	wineSave := saveDirIn(filepath.Join(wine, "drive_c", "users", "someone", "Documents"))
+	touch(t, wineSave, softcoreStash)
+
+	custom := filepath.Join(home, "custom")
+	touch(t, custom, softcoreStash)
+
+	f := newLinuxFinder(home, map[string]string{"WINEPREFIX": wine})
+	f.Config.SaveDirs = []string{custom}
+	dirs := f.SaveDirs()
+	expected := []string{custom, proton, wineSave}
+	if !slices.Equal(dirs, expected) {
+		t.Errorf("expected save dirs %v, got %v", expected, dirs)
+	}
+}
+
+func TestSaveDirsInOtherSteamLibrary(t *testing.T) {
+	t.Parallel()
+
+	home := t.TempDir()
+	library := filepath.Join(home, "games")
+	vdf := `"libraryfolders"
+{
+	"1"
+	{
+		"path"		"` + library + `"
+	}
+}`
+	vdfFile := touch(t, home, ".steam", "steam", "steamapps", "libraryfolders.vdf")
+	if err := os.WriteFile(vdfFile, []byte(vdf), 0644); err != nil {
+		t.Fatal(err)
+	}
+	save := saveDirIn(filepath.Join(library, "steamapps", "compatdata", SteamAppId, "pfx", "drive_c", "users", "steamuser", "Documents"))
+	touch(t, save, softcoreStash)
+	touch(t, library, "steamapps", "common", "Grim Dawn", "database", "database.arz")
+
+	f := newLinuxFinder(home, nil)
+	if dirs := f.SaveDirs(); !slices.Equal(dirs, []string{save}) {
+		t.Errorf("expected save dir %s, got %v", save, dirs)
+	}
+	game := filepath.Join(library, "steamapps", "common", "Grim Dawn")
+	if dirs := f.GameDirs(); !slices.Equal(dirs, []string{game}) {
+		t.Errorf("expected game dir %s, got %v", game, dirs)
+	}
+}
+
+func TestScanSaveDir(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	touch(t, dir, softcoreStash)
+	touch(t, dir, hardcoreStash)
+	touch(t, dir, "SomeMod", softcoreStash)
+	touch(t, dir, "main", "_Alice", characterFile)
+	touch(t, dir, "user", "_Bob", characterFile)
+	touch(t, dir, "main", "_Incomplete", "levels_world001.map", "map.dat")
+
+	save, err := ScanSaveDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedStashes := []StashFile{
+		{Path: filepath.Join(dir, softcoreStash)},
+		{Path: filepath.Join(dir, hardcoreStash), Hardcore: true},
+		{Path: filepath.Join(dir, "SomeMod", softcoreStash), Mod: "SomeMod"},
+	}
+	if !slices.Equal(save.Stashes, expectedStashes) {
+		t.Errorf("expected stashes %v, got %v", expectedStashes, save.Stashes)
+	}
+
+	expectedCharacters := []Character{
+		{Name: "Alice", Path: filepath.Join(dir, "main", "_Alice", characterFile)},
+		{Name: "Bob", Path: filepath.Join(dir, "user", "_Bob", characterFile), Custom: true},
+	}
+	if !slices.Equal(save.Characters, expectedCharacters) {
+		t.Errorf("expected characters %v, got %v", expectedCharacters, save.Characters)
+	}
+}
+
+func TestScanGameDir(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	base := touch(t, dir, "database", "database.arz")
+	gdx2 := touch(t, dir, "gdx2", "database", "GDX2.arz")
+	gdx1 := touch(t, dir, "gdx1", "database", "GDX1.arz")
+	text := touch(t, dir, "resources", "Text_EN.arc")
+	items := touch(t, dir, "resources", "Items.arc")
+	textDe := touch(t, dir, "resources", "Text_DE.arc")
+	gdx1Text := touch(t, dir, "gdx1", "resources", "Text_EN.arc")
+
+	install, err := ScanGameDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{base, gdx1, gdx2}; !slices.Equal(install.Databases, expected) {
+		t.Errorf("expected databases %v, got %v", expected, install.Databases)
+	}
+	if expected := []string{items, textDe, text, gdx1Text}; !slices.Equal(install.Resources, expected) {
+		t.Errorf("expected resources %v, got %v", expected, install.Resources)
+	}
+	if expected := []string{text, gdx1Text}; !slices.Equal(install.TextArchives("en"), expected) {
+		t.Errorf("expected text archives %v, got %v", expected, install.TextArchives("en"))
+	}
+	if expected := []string{"DE", "EN"}; !slices.Equal(install.Languages(), expected) {
+		t.Errorf("expected languages %v, got %v", expected, install.Languages())
+	}
+}
+
+func TestLoadMissingConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := LoadConfig(filepath.Join(t.TempDir(), "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.SaveDirs) != 0 || len(cfg.GameDirs) != 0 {
+		t.Errorf("expected empty config, got %+v", cfg)
+	}
+}
@@ -0,0 +1,95 @@
+package owned
+
+import (
+	"fmt"
+
+	"github.com/kenranunderscore/grimvault/backend/locate"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+	"github.com/kenranunderscore/grimvault/backend/vault"
+)
+
+// Where an item is.
+type Location struct {
+	// "vault", "stash" or "character".
+	Kind string `json:"kind"`
+	// The stash id or the name of the character.
+	Name string `json:"name,omitempty"`
+	// Where within a character: "equipped", "inventory" or "stash".
+	Place string `json:"place,omitempty"`
+	// The 0-based stash tab or inventory bag, and the index of the item in it.
+	Tab     int    `json:"tab"`
+	Index   int    `json:"index"`
+	VaultId uint64 `json:"vaultId,omitempty"`
+}
+
+func (l Location) String() string {
+	switch {
+	case l.Kind == "vault":
+		return fmt.Sprintf("vault #%d", l.VaultId)
+	case l.Kind == "stash":
+		return fmt.Sprintf("%s stash, tab %d", l.Name, l.Tab+1)
+	case l.Place == "equipped":
+		return l.Name + ", equipped"
+	case l.Place == "inventory":
+		return fmt.Sprintf("%s, bag %d", l.Name, l.Tab+1)
+	}
+	return fmt.Sprintf("%s, personal stash tab %d", l.Name, l.Tab+1)
+}
+
+// An item the user owns, and where it is.
+type Item struct {
+	Item stash.Item `json:"item"`
+	At   Location   `json:"at"`
+	// Whether the item belongs to hardcore characters, which cannot trade
+	// items with softcore ones.
+	Hardcore bool `json:"hardcore"`
+}
+
+func itemsAt(items []stash.Item, at Location, hardcore bool) []Item {
+	res := make([]Item, 0, len(items))
+	for i := range items {
+		at.Index = i
+		res = append(res, Item{Item: items[i], At: at, Hardcore: hardcore})
+	}
+	return res
+}
+
+// Collect the items of the vault, the transfer stashes and the characters of
+// the save directory `save`. Characters that cannot be read are skipped, as
+// the game may be writing them; the errors are returned alongside.
+func Collect(v *vault.Vault, save locate.SaveDir) ([]Item, []error, error) {
+	var res []Item
+	for _, entry := range v.Entries {
+		res = append(res, Item{Item: entry.Item, At: Location{Kind: "vault", VaultId: entry.Id}, Hardcore: entry.Hardcore})
+	}
+
+	for _, file := range save.Stashes {
+		st, err := stash.ReadStash(file.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i, tab := range st.Tabs {
+			res = append(res, itemsAt(tab.Items, Location{Kind: "stash", Name: file.Id(), Tab: i}, file.Hardcore)...)
+		}
+	}
+
+	var skipped []error
+	for _, character := range save.Characters {
+		c, err := stash.ReadCharacter(character.Path)
+		if err != nil {
+			skipped = append(skipped, err)
+			continue
+		}
+		at := Location{Kind: "character", Name: character.Name, Place: "equipped"}
+		res = append(res, itemsAt(c.Equipped, at, c.Hardcore)...)
+		for i, bag := range c.Bags {
+			at := Location{Kind: "character", Name: character.Name, Place: "inventory", Tab: i}
+			res = append(res, itemsAt(bag, at, c.Hardcore)...)
+		}
+		for i, tab := range c.Stash {
+			at := Location{Kind: "character", Name: character.Name, Place: "stash", Tab: i}
+			res = append(res, itemsAt(tab.Items, at, c.Hardcore)...)
+		}
+	}
+	return res, skipped, nil
+}
@@ -0,0 +1,75 @@
+package owned
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kenranunderscore/grimvault/backend/locate"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+	"github.com/kenranunderscore/grimvault/backend/vault"
+)
+
+func TestCollect(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	data, err := os.ReadFile("../test_data/stashes/transfer.gst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "transfer.gst")
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	v, err := vault.Open(filepath.Join(dir, "vault.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := v.Insert(vault.Entry{Item: stash.Item{Base: "records/items/gearhead/d011_head.dbr"}, Hardcore: true})
+	broken := filepath.Join(dir, "player.gdc")
+	if err := os.WriteFile(broken, []byte("not a character"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	save := locate.SaveDir{
+		Path:       dir,
+		Stashes:    []locate.StashFile{{Path: file}},
+		Characters: []locate.Character{{Name: "Alice", Path: broken}},
+	}
+	items, skipped, err := Collect(v, save)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skipped) != 1 {
+		t.Errorf("expected the broken character to be skipped, got %v", skipped)
+	}
+	if len(items) < 2 || items[0].At != (Location{Kind: "vault", VaultId: entry.Id}) || !items[0].Hardcore {
+		t.Fatalf("expected the hardcore vault item first, got %+v", items)
+	}
+	for _, o := range items[1:] {
+		if o.At.Kind != "stash" || o.At.Name != "softcore" || o.Hardcore {
+			t.Errorf("unexpected location %+v", o)
+		}
+	}
+}
+
+func TestLocationString(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		at       Location
+		expected string
+	}{
+		{Location{Kind: "vault", VaultId: 7}, "vault #7"},
+		{Location{Kind: "stash", Name: "softcore", Tab: 2}, "softcore stash, tab 3"},
+		{Location{Kind: "character", Name: "Alice", Place: "equipped"}, "Alice, equipped"},
+		{Location{Kind: "character", Name: "Alice", Place: "inventory"}, "Alice, bag 1"},
+		{Location{Kind: "character", Name: "Alice", Place: "stash", Tab: 1}, "Alice, personal stash tab 2"},
+	}
+	for _, c := range cases {
+		if s := c.at.String(); s != c.expected {
+			t.Errorf("expected %q, got %q", c.expected, s)
+		}
+	}
+}
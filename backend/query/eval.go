@@ -0,0 +1,203 @@
+package query
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/kenranunderscore/grimvault/backend/resolve"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+	"github.com/kenranunderscore/grimvault/backend/tooltip"
+)
+
+// An item with everything queries can refer to resolved.
+type Item struct {
+	Name   string
+	Rarity string
+	Level  int
+	// Attribute requirements.
+	Physique int
+	Cunning  int
+	Spirit   int
+	Slot     string
+	Class    string
+	// All record paths of the item.
+	Records []string
+	// The item's stats, matched by `has`. `Resolve` fills in the lines its
+	// tooltip shows, e.g. "+12 Physique".
+	Stats []string
+	// The 1-based stash tab the item is in, or 0 if it is not in a stash.
+	Tab int
+	// Where the item is, e.g. "vault" or a stash id.
+	Source string
+	Owner  string
+}
+
+// Resolve `item` using `r`, which may be nil if the game data is not
+// available. Only the record paths can be matched then.
+func Resolve(r *resolve.Resolver, item *stash.Item) Item {
+	res := Item{Name: item.Base, Records: item.Records()}
+	if r == nil {
+		return res
+	}
+	d := r.Details(item)
+	res.Name = d.Name
+	res.Rarity = d.Rarity
+	res.Level = int(d.Level)
+	res.Physique, res.Cunning, res.Spirit = int(d.Physique), int(d.Cunning), int(d.Spirit)
+	res.Class = d.Class
+	res.Slot = resolve.Slot(d.Class)
+	res.Stats = tooltip.StatLines(r, item)
+	return res
+}
+
+type fieldKind int
+
+const (
+	textField fieldKind = iota
+	// A text field with several values, which matches if any value contains
+	// the searched text.
+	listField
+	numberField
+	rarityField
+)
+
+type fieldSpec struct {
+	kind fieldKind
+}
+
+var fields = map[string]fieldSpec{
+	"name":     {textField},
+	"rarity":   {rarityField},
+	"level":    {numberField},
+	"physique": {numberField},
+	"cunning":  {numberField},
+	"spirit":   {numberField},
+	"slot":     {textField},
+	"class":    {textField},
+	"record":   {listField},
+	"has":      {listField},
+	"tab":      {numberField},
+	"source":   {textField},
+	"owner":    {textField},
+}
+
+func fieldNames() string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func (f fieldSpec) supports(op string) bool {
+	switch f.kind {
+	case textField:
+		return slices.Contains([]string{":", "=", "!=", "~"}, op)
+	case listField:
+		return op == ":" || op == "~"
+	}
+	return op != "~"
+}
+
+func containsFold(s string, sub string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(sub))
+}
+
+func compareNumbers(a float64, op string, b float64) bool {
+	switch op {
+	case ":", "=":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+func (n *Compare) text(item *Item) string {
+	switch n.Field {
+	case "name":
+		return item.Name
+	case "slot":
+		return item.Slot
+	case "class":
+		return item.Class
+	case "source":
+		return item.Source
+	case "owner":
+		return item.Owner
+	}
+	return ""
+}
+
+func (n *Compare) match(item *Item) bool {
+	switch n.Field {
+	case "level":
+		return compareNumbers(float64(item.Level), n.Op, n.Number)
+	case "physique":
+		return compareNumbers(float64(item.Physique), n.Op, n.Number)
+	case "cunning":
+		return compareNumbers(float64(item.Cunning), n.Op, n.Number)
+	case "spirit":
+		return compareNumbers(float64(item.Spirit), n.Op, n.Number)
+	case "tab":
+		return item.Tab > 0 && compareNumbers(float64(item.Tab), n.Op, n.Number)
+	case "rarity":
+		rank, ok := resolve.RarityRank(item.Rarity)
+		return ok && compareNumbers(float64(rank), n.Op, n.Number)
+	case "record":
+		return slices.ContainsFunc(item.Records, func(s string) bool { return containsFold(s, n.Value) })
+	case "has":
+		return slices.ContainsFunc(item.Stats, func(s string) bool { return containsFold(s, n.Value) })
+	}
+
+	s := n.text(item)
+	switch n.Op {
+	case "~":
+		return containsFold(s, n.Value)
+	case "!=":
+		return !strings.EqualFold(s, n.Value)
+	}
+	return strings.EqualFold(s, n.Value)
+}
+
+func match(node Node, item *Item) bool {
+	switch n := node.(type) {
+	case *And:
+		for _, term := range n.Terms {
+			if !match(term, item) {
+				return false
+			}
+		}
+		return true
+	case *Or:
+		for _, term := range n.Terms {
+			if match(term, item) {
+				return true
+			}
+		}
+		return false
+	case *Not:
+		return !match(n.Term, item)
+	case *Compare:
+		return n.match(item)
+	case *Text:
+		return containsFold(item.Name, n.Value) ||
+			slices.ContainsFunc(item.Records, func(s string) bool { return containsFold(s, n.Value) })
+	}
+	return false
+}
+
+// Whether `item` matches the query.
+func (q *Query) Match(item *Item) bool {
+	return match(q.Root, item)
+}
@@ -0,0 +1,159 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	// A bare word, e.g. a field name or an unquoted value.
+	tokenWord
+	// A double-quoted string, with the quotes and escapes removed.
+	tokenString
+	tokenOperator
+	tokenLeftParen
+	tokenRightParen
+	// A leading `-`, negating the term that follows.
+	tokenMinus
+)
+
+func (k tokenKind) String() string {
+	switch k {
+	case tokenEOF:
+		return "end of query"
+	case tokenWord:
+		return "word"
+	case tokenString:
+		return "string"
+	case tokenOperator:
+		return "operator"
+	case tokenLeftParen:
+		return "'('"
+	case tokenRightParen:
+		return "')'"
+	case tokenMinus:
+		return "'-'"
+	}
+	return "unknown token"
+}
+
+type token struct {
+	kind tokenKind
+	// The unescaped text of the token.
+	text string
+	// The byte offset and length of the token in the query.
+	pos    int
+	length int
+}
+
+// The operators, longest first so that e.g. `>=` is not lexed as `>`.
+var operators = []string{">=", "<=", "!=", ":", "~", "=", ">", "<"}
+
+// Characters that end a bare word.
+func isDelimiter(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune(`()":~=!<>`, r)
+}
+
+// Split `text` into tokens, the last of which is always `tokenEOF`.
+func lex(text string) ([]token, error) {
+	var tokens []token
+	pos := 0
+	// Whether the previous token ends directly before `pos`, so a `-` here
+	// is part of a word rather than a negation.
+	adjacent := false
+	for pos < len(text) {
+		r, size := utf8.DecodeRuneInString(text[pos:])
+		if unicode.IsSpace(r) {
+			pos += size
+			adjacent = false
+			continue
+		}
+
+		start := pos
+		switch {
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLeftParen, text: "(", pos: pos, length: 1})
+			pos++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRightParen, text: ")", pos: pos, length: 1})
+			pos++
+		case r == '-' && !adjacent:
+			tokens = append(tokens, token{kind: tokenMinus, text: "-", pos: pos, length: 1})
+			pos++
+			adjacent = false
+			continue
+		case r == '"':
+			s, end, err := lexString(text, pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: s, pos: pos, length: end - pos})
+			pos = end
+		default:
+			op := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(text[pos:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op != "" {
+				tokens = append(tokens, token{kind: tokenOperator, text: op, pos: pos, length: len(op)})
+				pos += len(op)
+				break
+			}
+			if r == '!' {
+				return nil, newSyntaxError(text, pos, 1, "unexpected '!', did you mean '!='?")
+			}
+			for pos < len(text) {
+				r, size := utf8.DecodeRuneInString(text[pos:])
+				if isDelimiter(r) {
+					break
+				}
+				pos += size
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: text[start:pos], pos: start, length: pos - start})
+		}
+		adjacent = true
+	}
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(text)})
+	return tokens, nil
+}
+
+// Lex the string starting with the quote at `start`, returning its contents
+// and the position after the closing quote. Backslashes escape the next
+// character.
+func lexString(text string, start int) (string, int, error) {
+	var b strings.Builder
+	pos := start + 1
+	for pos < len(text) {
+		switch c := text[pos]; c {
+		case '"':
+			return b.String(), pos + 1, nil
+		case '\\':
+			if pos+1 < len(text) {
+				pos++
+			}
+			b.WriteByte(text[pos])
+		default:
+			b.WriteByte(c)
+		}
+		pos++
+	}
+	return "", 0, newSyntaxError(text, start, len(text)-start, "unterminated string")
+}
+
+func (t token) describe() string {
+	switch t.kind {
+	case tokenEOF:
+		return t.kind.String()
+	case tokenString:
+		return fmt.Sprintf("string %q", t.text)
+	}
+	return fmt.Sprintf("'%s'", t.text)
+}
@@ -0,0 +1,312 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kenranunderscore/grimvault/backend/resolve"
+)
+
+// A query like `rarity:legendary level>=90 name~"dread"`.
+//
+// Terms are either comparisons of a field with a value, or bare words and
+// strings, which match items whose name or record paths contain them. Terms
+// separated by whitespace (or `AND`) must all match, `OR` matches if either
+// side does, and `-` or `NOT` negate the term that follows. Parentheses
+// group terms.
+type Query struct {
+	Text string
+	Root Node
+}
+
+// A node of the syntax tree of a query.
+type Node interface {
+	// Format the node as query text that parses to the same tree.
+	String() string
+}
+
+// Matches if all terms match. Matches everything if there are none.
+type And struct {
+	Terms []Node
+}
+
+// Matches if any term matches.
+type Or struct {
+	Terms []Node
+}
+
+type Not struct {
+	Term Node
+}
+
+// A comparison of a field with a value, e.g. `level>=90`.
+type Compare struct {
+	Field string
+	Op    string
+	Value string
+	// Set for numeric fields, and for rarities as their rank.
+	Number float64
+}
+
+// A bare word or string.
+type Text struct {
+	Value string
+}
+
+func joinNodes(nodes []Node, sep string) string {
+	parts := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		s := node.String()
+		if _, ok := node.(*Or); ok {
+			s = "(" + s + ")"
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, sep)
+}
+
+func (n *And) String() string {
+	return joinNodes(n.Terms, " ")
+}
+
+func (n *Or) String() string {
+	return joinNodes(n.Terms, " OR ")
+}
+
+func (n *Not) String() string {
+	switch n.Term.(type) {
+	case *And, *Or:
+		return "-(" + n.Term.String() + ")"
+	}
+	return "-" + n.Term.String()
+}
+
+// Quote `s` unless it can be written as a bare word.
+func quoteValue(s string) string {
+	if s == "" || strings.IndexFunc(s, isDelimiter) >= 0 || strings.HasPrefix(s, "-") || isKeyword(s) != "" {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+func (n *Compare) String() string {
+	return n.Field + n.Op + quoteValue(n.Value)
+}
+
+func (n *Text) String() string {
+	return quoteValue(n.Value)
+}
+
+// An error in the syntax of a query, pointing at the offending part of it.
+type SyntaxError struct {
+	Query   string
+	Pos     int
+	Length  int
+	Message string
+}
+
+func newSyntaxError(query string, pos int, length int, format string, args ...any) *SyntaxError {
+	return &SyntaxError{Query: query, Pos: pos, Length: max(length, 1), Message: fmt.Sprintf(format, args...)}
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Message, e.Pos+1)
+}
+
+// The query with the offending part underlined on the line below.
+func (e *SyntaxError) Marker() string {
+	return e.Query + "\n" + strings.Repeat(" ", len([]rune(e.Query[:e.Pos]))) + strings.Repeat("^", e.Length)
+}
+
+type parser struct {
+	text   string
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) errorAt(t token, format string, args ...any) *SyntaxError {
+	return newSyntaxError(p.text, t.pos, t.length, format, args...)
+}
+
+// The upper-case keyword `s` is, or "" if it is none. Keywords are case
+// insensitive.
+func isKeyword(s string) string {
+	switch upper := strings.ToUpper(s); upper {
+	case "AND", "OR", "NOT":
+		return upper
+	}
+	return ""
+}
+
+func (p *parser) atKeyword(keyword string) bool {
+	t := p.peek()
+	return t.kind == tokenWord && isKeyword(t.text) == keyword
+}
+
+// Parse `text` into a query. Fields and values are checked as well, so a
+// query that parses can always be evaluated.
+func Parse(text string) (*Query, error) {
+	tokens, err := lex(text)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{text: text, tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		if t.kind == tokenRightParen {
+			return nil, p.errorAt(t, "unmatched ')'")
+		}
+		return nil, p.errorAt(t, "unexpected %s", t.describe())
+	}
+	return &Query{Text: text, Root: root}, nil
+}
+
+func (p *parser) parseOr() (Node, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	terms := []Node{first}
+	for p.atKeyword("OR") {
+		or := p.next()
+		if t := p.peek(); t.kind == tokenEOF || t.kind == tokenRightParen {
+			return nil, p.errorAt(or, "expected a term after OR")
+		}
+		term, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	if len(terms) == 1 {
+		return first, nil
+	}
+	return &Or{Terms: terms}, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	var terms []Node
+	for {
+		t := p.peek()
+		if p.atKeyword("OR") && len(terms) == 0 {
+			return nil, p.errorAt(t, "expected a term before OR")
+		}
+		if t.kind == tokenEOF || t.kind == tokenRightParen || p.atKeyword("OR") {
+			break
+		}
+		if p.atKeyword("AND") {
+			p.next()
+			if next := p.peek(); next.kind == tokenEOF || next.kind == tokenRightParen || len(terms) == 0 {
+				return nil, p.errorAt(t, "AND needs a term on both sides")
+			}
+			continue
+		}
+		term, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return &And{Terms: terms}, nil
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	if t := p.peek(); t.kind == tokenMinus || p.atKeyword("NOT") {
+		p.next()
+		if next := p.peek(); next.kind == tokenEOF || next.kind == tokenRightParen {
+			return nil, p.errorAt(t, "nothing to negate")
+		}
+		term, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Term: term}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenLeftParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRightParen {
+			return nil, p.errorAt(t, "unmatched '('")
+		}
+		if and, ok := node.(*And); ok && len(and.Terms) == 0 {
+			return nil, p.errorAt(t, "empty parentheses")
+		}
+		return node, nil
+	case tokenString:
+		if p.peek().kind == tokenOperator {
+			return nil, p.errorAt(t, "field names cannot be quoted")
+		}
+		return &Text{Value: t.text}, nil
+	case tokenWord:
+		if op := p.peek(); op.kind == tokenOperator {
+			p.next()
+			return p.parseCompare(t, op)
+		}
+		return &Text{Value: t.text}, nil
+	case tokenOperator:
+		return nil, p.errorAt(t, "expected a field name before '%s'", t.text)
+	}
+	return nil, p.errorAt(t, "unexpected %s", t.describe())
+}
+
+func (p *parser) parseCompare(field token, op token) (Node, error) {
+	name := strings.ToLower(field.text)
+	spec, ok := fields[name]
+	if !ok {
+		return nil, p.errorAt(field, "unknown field '%s', expected one of %s", field.text, fieldNames())
+	}
+	value := p.next()
+	if value.kind != tokenWord && value.kind != tokenString {
+		if value.kind == tokenEOF {
+			return nil, p.errorAt(op, "missing value after '%s%s'", field.text, op.text)
+		}
+		return nil, p.errorAt(value, "expected a value after '%s%s', got %s", field.text, op.text, value.describe())
+	}
+	if !spec.supports(op.text) {
+		return nil, p.errorAt(op, "operator '%s' cannot be used with %s", op.text, name)
+	}
+
+	node := &Compare{Field: name, Op: op.text, Value: value.text}
+	switch spec.kind {
+	case numberField:
+		n, err := strconv.ParseFloat(value.text, 64)
+		if err != nil {
+			return nil, p.errorAt(value, "%s needs a number, got %s", name, value.describe())
+		}
+		node.Number = n
+	case rarityField:
+		rank, ok := resolve.RarityRank(value.text)
+		if !ok {
+			return nil, p.errorAt(value, "unknown rarity %s, expected one of %s", value.describe(), strings.Join(resolve.Rarities, ", "))
+		}
+		node.Number = float64(rank)
+	}
+	return node, nil
+}
@@ -0,0 +1,146 @@
+package query
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		query    string
+		expected string
+	}{
+		{``, ``},
+		{`dread`, `dread`},
+		{`rarity:legendary level>=90 slot:amulet`, `rarity:legendary level>=90 slot:amulet`},
+		{`name~"dread" has:"+1 to all skills" tab:3`, `name~dread has:"+1 to all skills" tab:3`},
+		{`SLOT:ring OR slot:amulet`, `slot:ring OR slot:amulet`},
+		{`rarity:epic (slot:ring or slot:amulet)`, `rarity:epic (slot:ring OR slot:amulet)`},
+		{`-slot:ring NOT tab:1`, `-slot:ring -tab:1`},
+		{`a AND b`, `a b`},
+		{`-(a b)`, `-(a b)`},
+		{`chain-mail level<-1`, `chain-mail level<"-1"`},
+		{`"say \"hi\""`, `"say \"hi\""`},
+	}
+	for _, c := range cases {
+		q, err := Parse(c.query)
+		if err != nil {
+			t.Errorf("could not parse %q: %v", c.query, err)
+			continue
+		}
+		if s := q.Root.String(); s != c.expected {
+			t.Errorf("expected %q to parse as %q, got %q", c.query, c.expected, s)
+		}
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		query  string
+		pos    int
+		length int
+	}{
+		{`colour:red`, 0, 6},
+		{`level>=ninety`, 7, 6},
+		{`rarity:shiny`, 7, 5},
+		{`name~"dread`, 5, 6},
+		{`name>dread`, 4, 1},
+		{`slot:`, 4, 1},
+		{`(slot:ring`, 0, 1},
+		{`slot:ring)`, 9, 1},
+		{`slot:ring OR`, 10, 2},
+		{`OR b`, 0, 2},
+		{`( OR b)`, 2, 2},
+		{`a OR OR b`, 5, 2},
+		{`has~`, 3, 1},
+		{`a ! b`, 2, 1},
+		{`-`, 0, 1},
+		{`()`, 0, 1},
+		{`:ring`, 0, 1},
+	}
+	for _, c := range cases {
+		_, err := Parse(c.query)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("expected a syntax error for %q, got %v", c.query, err)
+			continue
+		}
+		if syntaxErr.Pos != c.pos || syntaxErr.Length != c.length {
+			t.Errorf("%q: expected error at %d+%d, got %d+%d: %v\n%s",
+				c.query, c.pos, c.length, syntaxErr.Pos, syntaxErr.Length, err, syntaxErr.Marker())
+		}
+	}
+}
+
+func TestMarker(t *testing.T) {
+	t.Parallel()
+
+	_, err := Parse(`slot:amulet levl>=90`)
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("expected a syntax error, got %v", err)
+	}
+	expected := "slot:amulet levl>=90\n            ^^^^"
+	if m := syntaxErr.Marker(); m != expected {
+		t.Errorf("expected marker\n%s\ngot\n%s", expected, m)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+
+	amulet := Item{
+		Name:    "Dread Amulet of the Night",
+		Rarity:  "Legendary",
+		Level:   94,
+		Spirit:  250,
+		Slot:    "amulet",
+		Class:   "ArmorJewelry_Amulet",
+		Records: []string{"records/items/gearjewelry/necklace/d011_necklace.dbr"},
+		Stats:   []string{"+1 to All Skills", "+8% Health"},
+		Tab:     3,
+		Source:  "softcore",
+	}
+	cases := []struct {
+		query    string
+		expected bool
+	}{
+		{``, true},
+		{`rarity:legendary level>=90 slot:amulet name~"dread" has:"all skills" tab:3`, true},
+		{`rarity>=epic`, true},
+		{`rarity<legendary`, false},
+		{`level<90`, false},
+		{`spirit<=250 physique=0`, true},
+		{`cunning>0`, false},
+		{`slot:ring OR slot:amulet`, true},
+		{`-slot:amulet`, false},
+		{`name:dread`, false},
+		{`name:"dread amulet of the night"`, true},
+		{`name!=dread`, true},
+		{`necklace`, true},
+		{`record:gearjewelry`, true},
+		{`source:vault`, false},
+		{`tab!=3`, false},
+		{`(slot:ring OR level>90) -has:retaliation`, true},
+	}
+	for _, c := range cases {
+		q, err := Parse(c.query)
+		if err != nil {
+			t.Errorf("could not parse %q: %v", c.query, err)
+			continue
+		}
+		if got := q.Match(&amulet); got != c.expected {
+			t.Errorf("%q: expected %v, got %v", c.query, c.expected, got)
+		}
+	}
+
+	// Items outside of stashes never match tab comparisons.
+	q, _ := Parse(`tab<5`)
+	if q.Match(&Item{}) {
+		t.Errorf("expected an item without tab not to match %q", q.Text)
+	}
+}
@@ -0,0 +1,80 @@
+package resolve
+
+import (
+	"github.com/kenranunderscore/grimvault/backend/equation"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+// What a character needs to equip an item.
+type Requirements struct {
+	Level    uint32 `json:"level"`
+	Physique uint32 `json:"physique,omitempty"`
+	Cunning  uint32 `json:"cunning,omitempty"`
+	Spirit   uint32 `json:"spirit,omitempty"`
+}
+
+// The cost record of items that do not name one in `itemCostName`.
+const defaultCostRecord = "records/game/itemcost.dbr"
+
+// The requirements by the record fields they are taken from: a fixed value of
+// the base record, or an equation of the cost record following the slot name.
+var requirementFields = []struct {
+	fixed, equation string
+	field           func(req *Requirements) *uint32
+}{
+	{"levelRequirement", "LevelEquation", func(req *Requirements) *uint32 { return &req.Level }},
+	{"strengthRequirement", "StrengthEquation", func(req *Requirements) *uint32 { return &req.Physique }},
+	{"dexterityRequirement", "DexterityEquation", func(req *Requirements) *uint32 { return &req.Cunning }},
+	{"intelligenceRequirement", "IntelligenceEquation", func(req *Requirements) *uint32 { return &req.Spirit }},
+}
+
+// Compute the requirements of `item`. Fixed requirements of the base record,
+// e.g. `strengthRequirement`, take precedence. Otherwise they are computed by
+// the equations of its cost record, named after the item's slot and the
+// attribute, e.g. `ringIntelligenceEquation`. Equations can use `itemLevel`,
+// `totalAttCount`, the number of stats of all records of the item, and the
+// numeric fields of the base record. Requirements whose equation is missing
+// or cannot be evaluated are 0.
+func (r *Resolver) Requirements(item *stash.Item) Requirements {
+	var req Requirements
+	base, ok := r.DB.Get(item.Base)
+	if !ok {
+		return req
+	}
+
+	costRecord := base.String("itemCostName")
+	if costRecord == "" {
+		costRecord = defaultCostRecord
+	}
+	cost, _ := r.DB.Get(costRecord)
+	slot := Slot(base.String("Class"))
+	attributes := make(map[string]bool)
+	for _, record := range item.Records() {
+		for _, stat := range r.Stats(record) {
+			attributes[stat.Name] = true
+		}
+	}
+	fields := equation.EntryVars(base)
+	vars := func(name string) (float64, bool) {
+		switch name {
+		case "itemLevel":
+			return base.Float("itemLevel"), true
+		case "totalAttCount":
+			return float64(len(attributes)), true
+		}
+		return fields(name)
+	}
+
+	for _, f := range requirementFields {
+		value := base.Float(f.fixed)
+		if value == 0 && cost != nil && slot != "" {
+			if text := cost.String(slot + f.equation); text != "" {
+				value, _ = equation.Eval(text, vars)
+			}
+		}
+		if value > 0 {
+			*f.field(&req) = uint32(value)
+		}
+	}
+	return req
+}
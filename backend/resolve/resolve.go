@@ -0,0 +1,280 @@
+package resolve
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/kenranunderscore/grimvault/backend/arc"
+	"github.com/kenranunderscore/grimvault/backend/database"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+// Combines the game database with a localization to describe items the way
+// the game shows them.
+type Resolver struct {
+	DB   *database.Database
+	Tags arc.Tags
+}
+
+func New(db *database.Database, tags arc.Tags) *Resolver {
+	return &Resolver{DB: db, Tags: tags}
+}
+
+// Get the localized text of the tag stored in the field `field` of `record`.
+func (r *Resolver) Text(record string, field string) string {
+	if record == "" {
+		return ""
+	}
+	entry, ok := r.DB.Get(record)
+	if !ok {
+		return ""
+	}
+	tag := entry.String(field)
+	if tag == "" {
+		return ""
+	}
+	name, _ := r.Tags.Lookup(tag)
+	return name
+}
+
+// The localized name of the base record alone. Most items are named by their
+// `itemNameTag`, but some (e.g. components) only have a `description`.
+func (r *Resolver) BaseName(record string) string {
+	if name := r.Text(record, "itemNameTag"); name != "" {
+		return name
+	}
+	return r.Text(record, "description")
+}
+
+// The full name of `item` as shown in game: the prefix, quality and style,
+// the base name and the suffix. Returns the base record path if the base has
+// no localized name.
+func (r *Resolver) Name(item *stash.Item) string {
+	base := r.BaseName(item.Base)
+	if base == "" {
+		return item.Base
+	}
+
+	parts := []string{
+		r.Text(item.Prefix, "lootRandomizerName"),
+		r.Text(item.Base, "itemQualityTag"),
+		r.Text(item.Base, "itemStyleTag"),
+		base,
+		r.Text(item.Suffix, "lootRandomizerName"),
+	}
+	var name []string
+	for _, part := range parts {
+		if part != "" {
+			name = append(name, part)
+		}
+	}
+	return strings.Join(name, " ")
+}
+
+// A numeric property an item has through one of its records.
+type Stat struct {
+	Name   string  `json:"name"`
+	Value  float64 `json:"value"`
+	Record string  `json:"record"`
+}
+
+// What the game shows about an item, as far as it can be taken from the
+// database as is. Values depending on the item's seed are not rolled: the
+// game's random number generator and how it picks values from the ranges of
+// the records are not documented, and without items whose in-game values are
+// known there is nothing to check a reimplementation against. `Stats` holds
+// the values of the records, which is what the game shows for fixed stats.
+type Details struct {
+	Name   string `json:"name"`
+	Class  string `json:"class"`
+	Rarity string `json:"rarity,omitempty"`
+	Level  uint32 `json:"level"`
+	// Attribute requirements, see `Requirements`.
+	Physique uint32 `json:"physique,omitempty"`
+	Cunning  uint32 `json:"cunning,omitempty"`
+	Spirit   uint32 `json:"spirit,omitempty"`
+	Stats    []Stat `json:"stats"`
+}
+
+// Item rarities, from lowest to highest, as named by `itemClassification` in
+// lower case.
+var Rarities = []string{"broken", "common", "magical", "rare", "epic", "legendary"}
+
+// The position of rarity `s` in `Rarities`, ignoring case.
+func RarityRank(s string) (int, bool) {
+	i := slices.Index(Rarities, strings.ToLower(s))
+	return i, i >= 0
+}
+
+// The field name prefixes of record fields that describe item stats, as
+// opposed to visuals, sounds and the like.
+var statPrefixes = []string{"offensive", "defensive", "retaliation", "character", "skill", "augment"}
+
+func isStat(name string) bool {
+	if strings.HasSuffix(name, "Tag") || strings.HasSuffix(name, "Name") {
+		return false
+	}
+	for _, prefix := range statPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// The stats of `record` with a non-zero value, in database order.
+func (r *Resolver) Stats(record string) []Stat {
+	if record == "" {
+		return nil
+	}
+	entry, ok := r.DB.Get(record)
+	if !ok {
+		return nil
+	}
+	var res []Stat
+	for _, stat := range entry.Stats {
+		if !isStat(stat.Name) {
+			continue
+		}
+		value := database.ToFloat(stat.Value)
+		if value != 0 {
+			res = append(res, Stat{Name: stat.Name, Value: value, Record: record})
+		}
+	}
+	return res
+}
+
+// Describe `item`: its name, rarity, requirements and the stats of all of its
+// records.
+func (r *Resolver) Details(item *stash.Item) Details {
+	d := Details{Name: r.Name(item), Stats: []Stat{}}
+	if base, ok := r.DB.Get(item.Base); ok {
+		d.Class = base.String("Class")
+		d.Rarity = base.String("itemClassification")
+	}
+	req := r.Requirements(item)
+	d.Level, d.Physique, d.Cunning, d.Spirit = req.Level, req.Physique, req.Cunning, req.Spirit
+	for _, record := range item.Records() {
+		d.Stats = append(d.Stats, r.Stats(record)...)
+	}
+	return d
+}
+
+// How many items of the base record `record` fit into one stack. Only
+// stackable items, e.g. components and potions, have a `maxStackSize`; all
+// others stack to 1.
+func (r *Resolver) MaxStack(record string) uint32 {
+	if entry, ok := r.DB.Get(record); ok {
+		if size := entry.Float("maxStackSize"); size >= 1 {
+			return uint32(size)
+		}
+	}
+	return 1
+}
+
+// How many grid cells items of each slot take across and down. The game
+// takes the size of an item from its bitmap, which the database only names, but
+// all items of a slot have the same size. Slots not listed take a single cell.
+var sizes = map[string][2]uint32{
+	"head":      {2, 2},
+	"chest":     {2, 3},
+	"shoulders": {2, 2},
+	"hands":     {2, 2},
+	"legs":      {2, 2},
+	"feet":      {2, 2},
+	"waist":     {2, 1},
+	"offhand":   {2, 2},
+	"shield":    {2, 3},
+	"axe":       {1, 3},
+	"mace":      {1, 3},
+	"sword":     {1, 3},
+	"dagger":    {1, 2},
+	"scepter":   {1, 3},
+	"axe2h":     {2, 4},
+	"mace2h":    {2, 4},
+	"sword2h":   {2, 4},
+	"spear2h":   {2, 4},
+	"ranged1h":  {2, 2},
+	"ranged2h":  {2, 4},
+}
+
+// How many grid cells `item` takes across and down, by the slot of its base.
+// Matches `stash.Size`.
+func (r *Resolver) Size(item *stash.Item) (uint32, uint32) {
+	if base, ok := r.DB.Get(item.Base); ok {
+		if size, ok := sizes[Slot(base.String("Class"))]; ok {
+			return size[0], size[1]
+		}
+	}
+	return 1, 1
+}
+
+// The equipment slot or item kind for each item class, as used in searches
+// and listings.
+var slots = map[string]string{
+	"ArmorProtective_Head":      "head",
+	"ArmorProtective_Chest":     "chest",
+	"ArmorProtective_Shoulders": "shoulders",
+	"ArmorProtective_Hands":     "hands",
+	"ArmorProtective_Legs":      "legs",
+	"ArmorProtective_Feet":      "feet",
+	"ArmorProtective_Waist":     "waist",
+	"ArmorJewelry_Amulet":       "amulet",
+	"ArmorJewelry_Ring":         "ring",
+	"ArmorJewelry_Medal":        "medal",
+	"WeaponArmor_Offhand":       "offhand",
+	"WeaponArmor_Shield":        "shield",
+	"WeaponMelee_Axe":           "axe",
+	"WeaponMelee_Mace":          "mace",
+	"WeaponMelee_Sword":         "sword",
+	"WeaponMelee_Dagger":        "dagger",
+	"WeaponMelee_Scepter":       "scepter",
+	"WeaponMelee_Axe2h":         "axe2h",
+	"WeaponMelee_Mace2h":        "mace2h",
+	"WeaponMelee_Sword2h":       "sword2h",
+	"WeaponMelee_Spear2h":       "spear2h",
+	"WeaponHunting_Ranged1h":    "ranged1h",
+	"WeaponHunting_Ranged2h":    "ranged2h",
+	"ItemArtifact":              "relic",
+	"ItemArtifactFormula":       "blueprint",
+	"ItemRelic":                 "component",
+	"ItemEnchantment":           "augment",
+}
+
+// The slot of items of record class `class`, or "" for classes that are not
+// known to be items.
+func Slot(class string) string {
+	return slots[class]
+}
+
+// All localized texts describing `item`, for full-text search: its name,
+// which includes the affix names, its flavor text, and the names of the
+// components, relics and augments applied to it.
+func (r *Resolver) Texts(item *stash.Item) []string {
+	texts := []string{r.Name(item)}
+	for _, text := range []string{
+		r.Text(item.Base, "itemText"),
+		r.BaseName(item.Material),
+		r.BaseName(item.RelicCompletionBonus),
+		r.BaseName(item.Enchantment),
+	} {
+		if text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return texts
+}
+
+// How many references deep item records are expanded: far enough for a
+// granted skill, the pet it summons and the pet's skills.
+const expandDepth = 4
+
+// Expand each record of `item` by following its references that matter for
+// gameplay, e.g. granted skills, see `database.Expand`.
+func (r *Resolver) Expand(item *stash.Item) []*database.Node {
+	var nodes []*database.Node
+	for _, record := range item.Records() {
+		nodes = append(nodes, r.DB.Expand(record, expandDepth, database.Gameplay))
+	}
+	return nodes
+}
@@ -0,0 +1,191 @@
+package resolve
+
+import (
+	"testing"
+
+	"github.com/kenranunderscore/grimvault/backend/arc"
+	"github.com/kenranunderscore/grimvault/backend/database"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+func testResolver() *Resolver {
+	db := database.New([]database.Entry{
+		{Key: "records/items/gearhead/a06_head005.dbr", Stats: []database.Stat{
+			{Name: "itemNameTag", Value: "tagHeadA06"},
+			{Name: "itemStyleTag", Value: "tagStyleHelm"},
+		}},
+		{Key: "records/items/lootaffixes/prefix/b_ar015_ar.dbr", Stats: []database.Stat{
+			{Name: "lootRandomizerName", Value: "tagPrefixAR015"},
+		}},
+		{Key: "records/items/lootaffixes/suffix/b_ar041_ar_b.dbr", Stats: []database.Stat{
+			{Name: "lootRandomizerName", Value: "tagSuffixAR041"},
+		}},
+		{Key: "records/items/materia/compa_bristlyfur.dbr", Stats: []database.Stat{
+			{Name: "description", Value: "tagCompA05"},
+		}},
+	})
+	tags := arc.NewTags([]arc.Tag{
+		{Tag: "tagHeadA06", Name: "Hood"},
+		{Tag: "tagStyleHelm", Name: "Mystical"},
+		{Tag: "tagPrefixAR015", Name: "Arcane"},
+		{Tag: "tagSuffixAR041", Name: "of Valor"},
+		{Tag: "tagCompA05", Name: "Bristly Fur"},
+	})
+	return New(db, tags)
+}
+
+func TestName(t *testing.T) {
+	t.Parallel()
+
+	r := testResolver()
+	cases := []struct {
+		item     stash.Item
+		expected string
+	}{
+		{stash.Item{Base: "records/items/gearhead/a06_head005.dbr"}, "Mystical Hood"},
+		{stash.Item{
+			Base:   "records/items/gearhead/a06_head005.dbr",
+			Prefix: "records/items/lootaffixes/prefix/b_ar015_ar.dbr",
+			Suffix: "records/items/lootaffixes/suffix/b_ar041_ar_b.dbr",
+		}, "Arcane Mystical Hood of Valor"},
+		{stash.Item{Base: "records/items/materia/compa_bristlyfur.dbr"}, "Bristly Fur"},
+		{stash.Item{Base: "records/items/unknown.dbr"}, "records/items/unknown.dbr"},
+	}
+	for _, c := range cases {
+		if name := r.Name(&c.item); name != c.expected {
+			t.Errorf("expected name %q, got %q", c.expected, name)
+		}
+	}
+}
+
+func TestDetails(t *testing.T) {
+	t.Parallel()
+
+	db := database.New([]database.Entry{
+		{Key: "records/items/gearweapons/caster/b203_scepter.dbr", Stats: []database.Stat{
+			{Name: "Class", Value: "WeaponMelee_Scepter"},
+			{Name: "itemClassification", Value: "Rare"},
+			{Name: "levelRequirement", Value: float32(12)},
+			{Name: "characterBaseAttackSpeed", Value: float32(-0.1)},
+			{Name: "characterBaseAttackSpeedTag", Value: "tagAttackSpeedAverage"},
+			{Name: "offensivePoisonModifier", Value: float32(39)},
+			{Name: "offensiveLifeModifier", Value: float32(0)},
+			{Name: "scale", Value: float32(1)},
+		}},
+		{Key: "records/items/lootaffixes/suffix/b_ar041_ar_b.dbr", Stats: []database.Stat{
+			{Name: "defensiveProtection", Value: uint32(20)},
+		}},
+	})
+	r := New(db, arc.NewTags(nil))
+
+	item := stash.Item{
+		Base:   "records/items/gearweapons/caster/b203_scepter.dbr",
+		Suffix: "records/items/lootaffixes/suffix/b_ar041_ar_b.dbr",
+	}
+	d := r.Details(&item)
+	if d.Class != "WeaponMelee_Scepter" || d.Rarity != "Rare" || d.Level != 12 {
+		t.Errorf("unexpected details %+v", d)
+	}
+	expected := []Stat{
+		{Name: "characterBaseAttackSpeed", Value: float64(float32(-0.1)), Record: item.Base},
+		{Name: "offensivePoisonModifier", Value: 39, Record: item.Base},
+		{Name: "defensiveProtection", Value: 20, Record: item.Suffix},
+	}
+	if len(d.Stats) != len(expected) {
+		t.Fatalf("expected stats %v, got %v", expected, d.Stats)
+	}
+	for i := range expected {
+		if d.Stats[i] != expected[i] {
+			t.Errorf("expected stat %v, got %v", expected[i], d.Stats[i])
+		}
+	}
+}
+
+func TestRequirements(t *testing.T) {
+	t.Parallel()
+
+	db := database.New([]database.Entry{
+		{Key: "records/items/gearaccessories/rings/a001_ring.dbr", Stats: []database.Stat{
+			{Name: "Class", Value: "ArmorJewelry_Ring"},
+			{Name: "itemLevel", Value: float32(50)},
+			{Name: "characterLife", Value: float32(100)},
+			{Name: "defensiveFire", Value: float32(20)},
+		}},
+		{Key: "records/items/gearaccessories/rings/a002_ring.dbr", Stats: []database.Stat{
+			{Name: "Class", Value: "ArmorJewelry_Ring"},
+			{Name: "itemCostName", Value: "records/game/itemcost_cheap.dbr"},
+			{Name: "itemLevel", Value: float32(50)},
+			{Name: "levelRequirement", Value: float32(10)},
+		}},
+		{Key: "records/items/lootaffixes/suffix/a.dbr", Stats: []database.Stat{
+			{Name: "characterStrength", Value: float32(5)},
+		}},
+		{Key: "records/game/itemcost.dbr", Stats: []database.Stat{
+			{Name: "ringLevelEquation", Value: "itemLevel - 2"},
+			{Name: "ringIntelligenceEquation", Value: "(itemLevel * 2) + (totalAttCount * 10)"},
+			{Name: "ringStrengthEquation", Value: "unknownVariable * 2"},
+		}},
+		{Key: "records/game/itemcost_cheap.dbr", Stats: []database.Stat{
+			{Name: "ringLevelEquation", Value: "1"},
+			{Name: "ringIntelligenceEquation", Value: "itemLevel / 5.5"},
+		}},
+	})
+	r := New(db, arc.NewTags(nil))
+
+	cases := []struct {
+		item     stash.Item
+		expected Requirements
+	}{
+		{stash.Item{Base: "records/items/gearaccessories/rings/a001_ring.dbr"}, Requirements{Level: 48, Spirit: 120}},
+		{stash.Item{
+			Base:   "records/items/gearaccessories/rings/a001_ring.dbr",
+			Suffix: "records/items/lootaffixes/suffix/a.dbr",
+		}, Requirements{Level: 48, Spirit: 130}},
+		{stash.Item{Base: "records/items/gearaccessories/rings/a002_ring.dbr"}, Requirements{Level: 10, Spirit: 9}},
+		{stash.Item{Base: "records/items/unknown.dbr"}, Requirements{}},
+	}
+	for _, c := range cases {
+		if req := r.Requirements(&c.item); req != c.expected {
+			t.Errorf("%s: expected %+v, got %+v", c.item.Base, c.expected, req)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	t.Parallel()
+
+	db := database.New([]database.Entry{
+		{Key: "records/items/a001_ring.dbr", Stats: []database.Stat{
+			{Name: "itemSkillName", Value: "records/skills/items/nova.dbr"},
+			{Name: "dropSound", Value: "records/sounds/ring.dbr"},
+		}},
+		{Key: "records/skills/items/nova.dbr"},
+		{Key: "records/sounds/ring.dbr"},
+	})
+	nodes := New(db, arc.NewTags(nil)).Expand(&stash.Item{Base: "records/items/a001_ring.dbr", Suffix: "records/items/unknown.dbr"})
+	if len(nodes) != 2 || len(nodes[0].Refs) != 1 || nodes[0].Refs[0].Key != "records/skills/items/nova.dbr" || !nodes[1].Missing {
+		t.Errorf("expected the granted skill to be followed, got %+v", nodes)
+	}
+}
+
+func TestSize(t *testing.T) {
+	t.Parallel()
+
+	r := New(database.New([]database.Entry{
+		{Key: "records/items/gearweapons/swords1h/a01_sword.dbr", Stats: []database.Stat{{Name: "Class", Value: "WeaponMelee_Sword"}}},
+		{Key: "records/items/materia/compa_bristlyfur.dbr", Stats: []database.Stat{{Name: "Class", Value: "ItemRelic"}}},
+	}), arc.NewTags(nil))
+	cases := []struct {
+		base string
+		w, h uint32
+	}{
+		{"records/items/gearweapons/swords1h/a01_sword.dbr", 1, 3},
+		{"records/items/materia/compa_bristlyfur.dbr", 1, 1},
+		{"records/items/unknown.dbr", 1, 1},
+	}
+	for _, c := range cases {
+		if w, h := r.Size(&stash.Item{Base: c.base}); w != c.w || h != c.h {
+			t.Errorf("%s: expected %dx%d, got %dx%d", c.base, c.w, c.h, w, h)
+		}
+	}
+}
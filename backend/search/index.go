@@ -0,0 +1,231 @@
+package search
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Scores of the ways a term can match a query term. An exact match always
+// ranks above a prefix match, which ranks above a fuzzy match.
+const (
+	exactScore  = 1.0
+	prefixScore = 0.7
+	fuzzyScore  = 0.5
+)
+
+// An in-memory inverted index of documents identified by numbers, e.g. vault
+// item ids. Documents can be added and removed at any time.
+type Index struct {
+	// The terms of each document, with how often they occur.
+	docs map[uint64]map[string]int
+	// The documents containing each term.
+	postings map[string]map[uint64]struct{}
+	// All terms, sorted for prefix lookups. Rebuilt lazily after changes.
+	vocabulary []string
+	dirty      bool
+}
+
+// A document matching a search, with higher scores meaning better matches.
+type Result struct {
+	Id    uint64
+	Score float64
+}
+
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[uint64]map[string]int),
+		postings: make(map[string]map[uint64]struct{}),
+	}
+}
+
+// Index the document `id` with the given texts, replacing what has been
+// indexed for it before.
+func (ix *Index) Add(id uint64, texts ...string) {
+	ix.Remove(id)
+	terms := make(map[string]int)
+	for _, text := range texts {
+		for _, term := range Terms(text) {
+			terms[term]++
+		}
+	}
+	ix.docs[id] = terms
+	for term := range terms {
+		docs, ok := ix.postings[term]
+		if !ok {
+			docs = make(map[uint64]struct{})
+			ix.postings[term] = docs
+			ix.dirty = true
+		}
+		docs[id] = struct{}{}
+	}
+}
+
+// Remove the document `id` from the index, if it has been added.
+func (ix *Index) Remove(id uint64) {
+	terms, ok := ix.docs[id]
+	if !ok {
+		return
+	}
+	delete(ix.docs, id)
+	for term := range terms {
+		docs := ix.postings[term]
+		delete(docs, id)
+		if len(docs) == 0 {
+			delete(ix.postings, term)
+			ix.dirty = true
+		}
+	}
+}
+
+// The number of indexed documents.
+func (ix *Index) Len() int {
+	return len(ix.docs)
+}
+
+func (ix *Index) sortedTerms() []string {
+	if ix.dirty || ix.vocabulary == nil {
+		ix.vocabulary = ix.vocabulary[:0]
+		for term := range ix.postings {
+			ix.vocabulary = append(ix.vocabulary, term)
+		}
+		sort.Strings(ix.vocabulary)
+		ix.dirty = false
+	}
+	return ix.vocabulary
+}
+
+// The number of edits a query term of length `n` may be away from a term
+// to still match. Short terms have to match exactly, or they would match
+// almost anything.
+func maxEdits(n int) int {
+	switch {
+	case n <= 3:
+		return 0
+	case n <= 6:
+		return 1
+	}
+	return 2
+}
+
+// The best score of each term matching `query`.
+func (ix *Index) matchTerm(query string) map[string]float64 {
+	matches := make(map[string]float64)
+	if _, ok := ix.postings[query]; ok {
+		matches[query] = exactScore
+	}
+
+	terms := ix.sortedTerms()
+	for i := sort.SearchStrings(terms, query); i < len(terms) && strings.HasPrefix(terms[i], query); i++ {
+		if terms[i] != query {
+			// Prefer terms that are completed less.
+			matches[terms[i]] = prefixScore * float64(len(query)) / float64(len(terms[i]))
+		}
+	}
+
+	q := []rune(query)
+	limit := maxEdits(len(q))
+	if limit == 0 {
+		return matches
+	}
+	for _, term := range terms {
+		if _, ok := matches[term]; ok {
+			continue
+		}
+		t := []rune(term)
+		if abs(len(t)-len(q)) > limit {
+			continue
+		}
+		if d := distance(q, t, limit); d <= limit {
+			matches[term] = fuzzyScore * (1 - float64(d)/float64(len(q)+1))
+		}
+	}
+	return matches
+}
+
+// Find the documents containing all terms of `query`, each of them exactly,
+// as prefix of a term, or with a few typos. Results are ranked by how well
+// they match, and documents with fewer terms rank higher on ties, as the
+// query covers more of them.
+func (ix *Index) Search(query string) []Result {
+	queryTerms := Terms(query)
+	if len(queryTerms) == 0 {
+		return nil
+	}
+
+	var scores map[uint64]float64
+	for _, q := range queryTerms {
+		best := make(map[uint64]float64)
+		for term, score := range ix.matchTerm(q) {
+			for id := range ix.postings[term] {
+				best[id] = max(best[id], score)
+			}
+		}
+		if scores == nil {
+			scores = best
+			continue
+		}
+		for id, score := range scores {
+			if b, ok := best[id]; ok {
+				scores[id] = score + b
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	results := make([]Result, 0, len(scores))
+	for id, score := range scores {
+		results = append(results, Result{Id: id, Score: score / float64(len(queryTerms))})
+	}
+	slices.SortFunc(results, func(a, b Result) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(len(ix.docs[a.Id]), len(ix.docs[b.Id])); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Id, b.Id)
+	})
+	return results
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// The optimal string alignment distance between `a` and `b`: the edits are
+// insertions, deletions, substitutions and swaps of adjacent characters.
+// Returns `limit`+1 as soon as the distance is known to exceed `limit`.
+func distance(a []rune, b []rune, limit int) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,34 @@
+package search
+
+import (
+	"slices"
+
+	"github.com/kenranunderscore/grimvault/backend/resolve"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+// An index of items by their texts in every loaded language.
+type ItemIndex struct {
+	*Index
+	resolvers []*resolve.Resolver
+}
+
+// Create an index using one resolver per language. Nil resolvers are ignored;
+// without any, items are indexed by their record paths.
+func NewItemIndex(resolvers ...*resolve.Resolver) *ItemIndex {
+	resolvers = slices.DeleteFunc(slices.Clone(resolvers), func(r *resolve.Resolver) bool { return r == nil })
+	return &ItemIndex{Index: NewIndex(), resolvers: resolvers}
+}
+
+// Index `item` as document `id`, replacing what has been indexed for it.
+func (ix *ItemIndex) AddItem(id uint64, item *stash.Item) {
+	if len(ix.resolvers) == 0 {
+		ix.Add(id, item.Records()...)
+		return
+	}
+	var texts []string
+	for _, r := range ix.resolvers {
+		texts = append(texts, r.Texts(item)...)
+	}
+	ix.Add(id, texts...)
+}
@@ -0,0 +1,69 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Letters with diacritics and their base letters, covering the languages the
+// game is localized in. Letters that do not decompose into a base letter and
+// a mark, like "ß", are spelled out.
+var folded = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ą': "a", 'ă': "a",
+	'æ': "ae",
+	'ç': "c", 'ć': "c", 'č': "c",
+	'ď': "d", 'đ': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ę': "e", 'ě': "e",
+	'ğ': "g",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ı': "i",
+	'ł': "l", 'ľ': "l", 'ĺ': "l",
+	'ñ': "n", 'ń': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ő': "o",
+	'œ': "oe",
+	'ř': "r", 'ŕ': "r",
+	'ś': "s", 'š': "s", 'ş': "s", 'ß': "ss",
+	'ť': "t", 'ţ': "t",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ů': "u", 'ű': "u",
+	'ý': "y", 'ÿ': "y",
+	'ź': "z", 'ż': "z", 'ž': "z",
+	'ё': "е", 'й': "и",
+}
+
+// Lower-case `s` and remove diacritics, so that e.g. "Schädel" and "schadel"
+// are the same.
+func Normalize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if f, ok := folded[r]; ok {
+			b.WriteString(f)
+		} else if !unicode.Is(unicode.Mn, r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Split `s` into normalized terms. Everything but letters and digits separates
+// terms, except apostrophes within words, which are dropped: "Korvaak's"
+// becomes "korvaaks".
+func Terms(s string) []string {
+	var terms []string
+	var b strings.Builder
+	flush := func() {
+		if b.Len() > 0 {
+			terms = append(terms, b.String())
+			b.Reset()
+		}
+	}
+	for _, r := range Normalize(s) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case r == '\'' || r == '’':
+		default:
+			flush()
+		}
+	}
+	flush()
+	return terms
+}
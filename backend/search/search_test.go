@@ -0,0 +1,110 @@
+package search
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTerms(t *testing.T) {
+	t.Parallel()
+
+	terms := Terms("Korvaak's Ascended - Schädelbrecher, Œuvre")
+	expected := []string{"korvaaks", "ascended", "schadelbrecher", "oeuvre"}
+	if !slices.Equal(terms, expected) {
+		t.Errorf("expected %v, got %v", expected, terms)
+	}
+}
+
+func ids(results []Result) []uint64 {
+	var res []uint64
+	for _, r := range results {
+		res = append(res, r.Id)
+	}
+	return res
+}
+
+func testIndex() *Index {
+	ix := NewIndex()
+	ix.Add(1, "Stonebreaker", "Steinbrecher")
+	ix.Add(2, "Stone Ward")
+	ix.Add(3, "Mythical Stonebreaker of the Crag")
+	ix.Add(4, "Schädelspalter")
+	ix.Add(5, "Venomspine Greaves")
+	return ix
+}
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+
+	ix := testIndex()
+	cases := []struct {
+		query    string
+		expected []uint64
+	}{
+		{"stonebreaker", []uint64{1, 3}},
+		{"stonebrker", []uint64{1, 3}},
+		{"stnbrkr", nil},
+		{"stone", []uint64{2, 1, 3}},
+		{"STEINBRECHER", []uint64{1}},
+		{"schadelspalter", []uint64{4}},
+		{"schädel", []uint64{4}},
+		{"stonebreaker crag", []uint64{3}},
+		{"venomspine greves", []uint64{5}},
+		{"ward", []uint64{2}},
+		{"war", []uint64{2}},
+		{"", nil},
+	}
+	for _, c := range cases {
+		if got := ids(ix.Search(c.query)); !slices.Equal(got, c.expected) {
+			t.Errorf("%q: expected %v, got %v", c.query, c.expected, got)
+		}
+	}
+}
+
+func TestIncrementalUpdates(t *testing.T) {
+	t.Parallel()
+
+	ix := testIndex()
+	ix.Remove(1)
+	if got := ids(ix.Search("stonebreaker")); !slices.Equal(got, []uint64{3}) {
+		t.Errorf("expected the removed document to be gone, got %v", got)
+	}
+	if got := ix.Search("steinbrecher"); len(got) != 0 {
+		t.Errorf("expected no documents for terms only the removed one had, got %v", got)
+	}
+
+	ix.Add(6, "Steinbrecher")
+	ix.Add(3, "Venomspine Greaves")
+	if got := ids(ix.Search("steinbrecher")); !slices.Equal(got, []uint64{6}) {
+		t.Errorf("expected the added document, got %v", got)
+	}
+	if got := ids(ix.Search("venomspine")); !slices.Equal(got, []uint64{3, 5}) {
+		t.Errorf("expected the replaced document to be found by its new text, got %v", got)
+	}
+	if ix.Len() != 5 {
+		t.Errorf("expected 5 documents, got %d", ix.Len())
+	}
+}
+
+func TestDistance(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"stonebrker", "stonebreaker", 2},
+		{"greves", "greaves", 1},
+		{"ab", "ba", 1},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+	}
+	for _, c := range cases {
+		if d := distance([]rune(c.a), []rune(c.b), 5); d != c.expected {
+			t.Errorf("distance(%q, %q): expected %d, got %d", c.a, c.b, c.expected, d)
+		}
+	}
+	if d := distance([]rune("kitten"), []rune("sitting"), 1); d != 2 {
+		t.Errorf("expected the distance to be cut off at the limit, got %d", d)
+	}
+}
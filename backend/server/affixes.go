@@ -0,0 +1,80 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/kenranunderscore/grimvault/backend/affixes"
+)
+
+// The odds of a vault item to drop with exactly its affixes.
+type ItemOdds struct {
+	Id   uint64  `json:"id"`
+	Odds float64 `json:"odds"`
+}
+
+// The loot and affix tables of the game data, which must be available.
+func (s *Server) affixIndex() (*affixes.Index, error) {
+	if s.Resolver == nil {
+		return nil, errorf(http.StatusServiceUnavailable, "affix tables are not available without the game data")
+	}
+	if s.affixes == nil {
+		s.affixes = affixes.NewIndex(s.Resolver)
+	}
+	return s.affixes, nil
+}
+
+// List the affixes that can roll on the base record given by query parameter
+// "base", at item level "level" if it is given.
+func (s *Server) affixPool(r *http.Request) (int, any, error) {
+	ix, err := s.affixIndex()
+	if err != nil {
+		return 0, nil, err
+	}
+	base := r.URL.Query().Get("base")
+	if base == "" {
+		return 0, nil, errorf(http.StatusBadRequest, "missing query parameter 'base'")
+	}
+	level := uint64(0)
+	if value := r.URL.Query().Get("level"); value != "" {
+		level, err = strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return 0, nil, errorf(http.StatusBadRequest, "invalid level '%s'", value)
+		}
+	}
+	return http.StatusOK, ix.Pool(base, uint32(level)), nil
+}
+
+// List the bases the affix record given by query parameter "affix" can roll
+// on.
+func (s *Server) affixBases(r *http.Request) (int, any, error) {
+	ix, err := s.affixIndex()
+	if err != nil {
+		return 0, nil, err
+	}
+	affix := r.URL.Query().Get("affix")
+	if affix == "" {
+		return 0, nil, errorf(http.StatusBadRequest, "missing query parameter 'affix'")
+	}
+	sources := ix.Bases(affix)
+	if sources == nil {
+		sources = []affixes.Source{}
+	}
+	return http.StatusOK, sources, nil
+}
+
+func (s *Server) getVaultOdds(r *http.Request) (int, any, error) {
+	id, err := s.vaultId(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	entry, ok := s.Vault.Get(id)
+	if !ok {
+		return 0, nil, errorf(http.StatusNotFound, "no item with id %d in the vault", id)
+	}
+	ix, err := s.affixIndex()
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, ItemOdds{Id: id, Odds: ix.Odds(&entry.Item)}, nil
+}
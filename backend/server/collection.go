@@ -0,0 +1,54 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/kenranunderscore/grimvault/backend/collection"
+	"github.com/kenranunderscore/grimvault/backend/locate"
+	"github.com/kenranunderscore/grimvault/backend/owned"
+	"github.com/kenranunderscore/grimvault/backend/resolve"
+)
+
+// List every obtainable item of the rarities given by the comma-separated
+// query parameter "rarity" (epic and legendary by default), grouped by slot,
+// and mark the ones owned. Only missing items are returned if parameter
+// "missing" is set.
+func (s *Server) collectionReport(r *http.Request) (int, any, error) {
+	if s.Resolver == nil {
+		return 0, nil, errorf(http.StatusServiceUnavailable, "the collection is not available without the game data")
+	}
+	rarities := collection.DefaultRarities
+	if param := r.URL.Query().Get("rarity"); param != "" {
+		rarities = strings.Split(strings.ToLower(param), ",")
+	}
+	for _, rarity := range rarities {
+		if _, ok := resolve.RarityRank(rarity); !ok {
+			return 0, nil, errorf(http.StatusBadRequest, "unknown rarity '%s', expected one of %s", rarity, strings.Join(resolve.Rarities, ", "))
+		}
+	}
+
+	key := strings.Join(rarities, ",")
+	catalogue, ok := s.catalogues[key]
+	if !ok {
+		catalogue = collection.NewCatalogue(s.Resolver, rarities...)
+		s.catalogues[key] = catalogue
+	}
+	save, err := locate.ScanSaveDir(s.SaveDir)
+	if err != nil {
+		return 0, nil, err
+	}
+	items, _, err := owned.Collect(s.Vault, save)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	groups := catalogue.Track(items)
+	if r.URL.Query().Has("missing") {
+		groups = collection.OnlyMissing(groups)
+	}
+	if groups == nil {
+		groups = []collection.Group{}
+	}
+	return http.StatusOK, groups, nil
+}
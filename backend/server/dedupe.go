@@ -0,0 +1,102 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/kenranunderscore/grimvault/backend/dedupe"
+	"github.com/kenranunderscore/grimvault/backend/locate"
+	"github.com/kenranunderscore/grimvault/backend/owned"
+)
+
+// Discards all duplicates but the best copy of each item.
+type DiscardRequest struct {
+	// Whether to discard near duplicates too, which may have other stats.
+	Near bool `json:"near"`
+}
+
+// The items that have been moved to the discarded items of the vault.
+type DiscardResult struct {
+	Discarded []owned.Item `json:"discarded"`
+}
+
+func (s *Server) duplicates() (locate.SaveDir, []dedupe.Group, error) {
+	save, err := locate.ScanSaveDir(s.SaveDir)
+	if err != nil {
+		return save, nil, err
+	}
+	// Characters are only reported, so unreadable ones can be left out.
+	items, _, err := owned.Collect(s.Vault, save)
+	if err != nil {
+		return save, nil, err
+	}
+	return save, dedupe.Find(items, s.Resolver), nil
+}
+
+// Report the exact and near duplicates among the items of the vault, the
+// transfer stashes and the characters.
+func (s *Server) listDuplicates(r *http.Request) (int, any, error) {
+	_, groups, err := s.duplicates()
+	if err != nil {
+		return 0, nil, err
+	}
+	if groups == nil {
+		groups = []dedupe.Group{}
+	}
+	return http.StatusOK, groups, nil
+}
+
+func (s *Server) discardDuplicates(r *http.Request) (int, any, error) {
+	var req DiscardRequest
+	if err := readJSON(r, &req); err != nil {
+		return 0, nil, err
+	}
+	save, groups, err := s.duplicates()
+	if err != nil {
+		return 0, nil, err
+	}
+	discard := dedupe.Plan(groups, req.Near)
+	var removed []VaultItem
+	for _, item := range discard {
+		if entry, ok := s.Vault.Get(item.At.VaultId); ok && item.At.Kind == "vault" {
+			removed = append(removed, s.vaultItem(&entry, false))
+		}
+	}
+	if err := dedupe.Discard(s.Vault, save, discard, s.writeStash); err != nil {
+		return 0, nil, err
+	}
+	for _, item := range removed {
+		s.texts.Remove(item.Id)
+		s.events.publish(newEvent(EventVaultRemoved, item))
+	}
+	if discard == nil {
+		discard = []owned.Item{}
+	}
+	return http.StatusOK, DiscardResult{Discarded: discard}, nil
+}
+
+func (s *Server) listDiscarded(r *http.Request) (int, any, error) {
+	found := make([]VaultItem, 0, len(s.Vault.Discarded))
+	for i := range s.Vault.Discarded {
+		found = append(found, s.vaultItem(&s.Vault.Discarded[i], false))
+	}
+	return http.StatusOK, found, nil
+}
+
+// Move a discarded item back into the vault.
+func (s *Server) restoreDiscarded(r *http.Request) (int, any, error) {
+	id, err := s.vaultId(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	entry, ok := s.Vault.Restore(id)
+	if !ok {
+		return 0, nil, errorf(http.StatusNotFound, "no discarded item with id %d", id)
+	}
+	if err := s.Vault.Save(); err != nil {
+		return 0, nil, err
+	}
+	s.texts.AddItem(entry.Id, &entry.Item)
+	item := s.vaultItem(&entry, false)
+	s.events.publish(newEvent(EventVaultAdded, item))
+	return http.StatusOK, item, nil
+}
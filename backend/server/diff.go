@@ -0,0 +1,88 @@
+package server
+
+import (
+	"github.com/kenranunderscore/grimvault/backend/stash"
+	"github.com/kenranunderscore/grimvault/backend/stashjson"
+)
+
+// Where an item is in a stash.
+type Location struct {
+	Tab int     `json:"tab"`
+	X   float32 `json:"x"`
+	Y   float32 `json:"y"`
+}
+
+// The data of item events. `From` is only set for moved items, and `Item`
+// always holds the item at its current position, or the last one for removed
+// items.
+type ItemEvent struct {
+	Stash string         `json:"stash"`
+	Tab   int            `json:"tab"`
+	Item  stashjson.Item `json:"item"`
+	From  *Location      `json:"from,omitempty"`
+}
+
+type located struct {
+	tab  int
+	item stash.Item
+}
+
+// The item without its position, which identifies it when it is moved.
+func identity(item stash.Item) stash.Item {
+	item.X, item.Y = 0, 0
+	return item
+}
+
+// Compute the events turning `old` into `new`. Items that are unchanged are
+// matched first, then the remaining ones by identity, which makes them moves.
+// Identical items (e.g. two stacks of the same size) are interchangeable, so
+// the matching is not unique, but every match yields the same end result.
+func diffStash(id string, old *stash.Stash, new *stash.Stash, name stashjson.Namer) []Event {
+	var removed []located
+	for tab := range old.Tabs {
+		for _, item := range old.Tabs[tab].Items {
+			removed = append(removed, located{tab, item})
+		}
+	}
+
+	var added []located
+	for tab := range new.Tabs {
+		for _, item := range new.Tabs[tab].Items {
+			i := indexOf(removed, func(l located) bool { return l.tab == tab && l.item == item })
+			if i >= 0 {
+				removed = append(removed[:i], removed[i+1:]...)
+				continue
+			}
+			added = append(added, located{tab, item})
+		}
+	}
+
+	var events []Event
+	for _, l := range added {
+		event := ItemEvent{Stash: id, Tab: l.tab, Item: stashjson.FromItem(&l.item, name)}
+		i := indexOf(removed, func(r located) bool { return identity(r.item) == identity(l.item) })
+		if i < 0 {
+			events = append(events, newEvent(EventItemAdded, event))
+			continue
+		}
+		from := removed[i]
+		removed = append(removed[:i], removed[i+1:]...)
+		x, y := from.item.Position()
+		event.From = &Location{Tab: from.tab, X: x, Y: y}
+		events = append(events, newEvent(EventItemMoved, event))
+	}
+	for _, l := range removed {
+		event := ItemEvent{Stash: id, Tab: l.tab, Item: stashjson.FromItem(&l.item, name)}
+		events = append(events, newEvent(EventItemRemoved, event))
+	}
+	return events
+}
+
+func indexOf(items []located, match func(located) bool) int {
+	for i, l := range items {
+		if match(l) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,27 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/kenranunderscore/grimvault/backend/drops"
+)
+
+// List what drops the item record given by query parameter "record", the most
+// likely source first.
+func (s *Server) dropSources(r *http.Request) (int, any, error) {
+	if s.Resolver == nil {
+		return 0, nil, errorf(http.StatusServiceUnavailable, "loot tables are not available without the game data")
+	}
+	record := r.URL.Query().Get("record")
+	if record == "" {
+		return 0, nil, errorf(http.StatusBadRequest, "missing query parameter 'record'")
+	}
+	if s.drops == nil {
+		s.drops = drops.NewIndex(s.Resolver)
+	}
+	sources := s.drops.Sources(record)
+	if sources == nil {
+		sources = []drops.Source{}
+	}
+	return http.StatusOK, sources, nil
+}
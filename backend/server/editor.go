@@ -0,0 +1,81 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/kenranunderscore/grimvault/backend/editor"
+	"github.com/kenranunderscore/grimvault/backend/stashjson"
+)
+
+// Creates an item and puts it into a stash tab, at the given grid position or
+// into the first free cell.
+type CreateItemRequest struct {
+	Item  editor.Spec `json:"item"`
+	Stash string      `json:"stash"`
+	Tab   int         `json:"tab"`
+	X     *float32    `json:"x,omitempty"`
+	Y     *float32    `json:"y,omitempty"`
+}
+
+// The item editor for the game data, which must be available.
+func (s *Server) itemEditor() (*editor.Editor, error) {
+	if s.editor == nil {
+		v, err := s.validator()
+		if err != nil {
+			return nil, err
+		}
+		s.editor = editor.New(s.Resolver, s.affixes, v)
+	}
+	return s.editor, nil
+}
+
+// List what can be chosen for an item of the base record given by query
+// parameter "base".
+func (s *Server) editorOptions(r *http.Request) (int, any, error) {
+	e, err := s.itemEditor()
+	if err != nil {
+		return 0, nil, err
+	}
+	base := r.URL.Query().Get("base")
+	if base == "" {
+		return 0, nil, errorf(http.StatusBadRequest, "missing query parameter 'base'")
+	}
+	if _, ok := s.Resolver.DB.Get(base); !ok {
+		return 0, nil, errorf(http.StatusNotFound, "no record '%s' in the game database", base)
+	}
+	return http.StatusOK, e.Options(base), nil
+}
+
+func (s *Server) createItem(r *http.Request) (int, any, error) {
+	var req CreateItemRequest
+	if err := readJSON(r, &req); err != nil {
+		return 0, nil, err
+	}
+	e, err := s.itemEditor()
+	if err != nil {
+		return 0, nil, err
+	}
+	item, err := e.Create(req.Item)
+	var invalid *editor.InvalidError
+	if errors.As(err, &invalid) {
+		return 0, nil, errorf(http.StatusUnprocessableEntity, "%v", err)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	file, st, err := s.readStash(req.Stash)
+	if err != nil {
+		return 0, nil, err
+	}
+	if req.Tab < 0 || req.Tab >= len(st.Tabs) {
+		return 0, nil, errorf(http.StatusNotFound, "tab %d does not exist, the stash has %d tabs", req.Tab, len(st.Tabs))
+	}
+	if err := editor.Place(&st.Tabs[req.Tab], &item, req.X, req.Y, s.itemSize()); err != nil {
+		return 0, nil, placeError(err)
+	}
+	if err := s.writeStash(file, st); err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, ItemDetails{Item: stashjson.FromItem(&item, s.namer()), Details: s.details(&item)}, nil
+}
@@ -0,0 +1,209 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The types of events sent to subscribers.
+const (
+	EventItemAdded    = "item-added"
+	EventItemRemoved  = "item-removed"
+	EventItemMoved    = "item-moved"
+	EventVaultAdded   = "vault-added"
+	EventVaultRemoved = "vault-removed"
+	// Sent instead of the missed events when a client resumes after events
+	// that are no longer retained. It has to fetch everything anew.
+	EventReset = "reset"
+)
+
+// A change published to subscribers. Sequence numbers start at 1 and increase
+// by one for every event, so clients can tell which events they have missed.
+// They start anew when the server restarts, so event ids also carry the epoch
+// of the hub, as in "3f9a0c1e-42".
+type Event struct {
+	Seq  uint64
+	Type string
+	Data json.RawMessage
+}
+
+// How many past events are kept for clients that resume.
+const retainedEvents = 1024
+
+// How many events may be queued for a subscriber that does not keep up. Slow
+// subscribers are dropped, and have to resume.
+const subscriberBuffer = 64
+
+// Distributes events to subscribers and keeps the most recent ones around.
+type hub struct {
+	mu sync.Mutex
+	// Random for every hub, to tell the events of different server runs
+	// apart.
+	epoch   string
+	seq     uint64
+	events  []Event
+	clients map[chan Event]struct{}
+}
+
+func newHub() *hub {
+	return &hub{epoch: fmt.Sprintf("%08x", rand.Uint32()), clients: make(map[chan Event]struct{})}
+}
+
+// An event of type `typ` with `data` serialized as JSON, yet without a
+// sequence number.
+func newEvent(typ string, data any) Event {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		// Only ever called with plain data types.
+		panic(err)
+	}
+	return Event{Type: typ, Data: raw}
+}
+
+// Assign sequence numbers to `events` and send them to all subscribers.
+func (h *hub) publish(events ...Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, event := range events {
+		h.seq++
+		event.Seq = h.seq
+		h.events = append(h.events, event)
+		for ch := range h.clients {
+			select {
+			case ch <- event:
+			default:
+				delete(h.clients, ch)
+				close(ch)
+			}
+		}
+	}
+	if len(h.events) > retainedEvents {
+		h.events = slices.Clone(h.events[len(h.events)-retainedEvents:])
+	}
+}
+
+// Subscribe to all future events. When resuming, the retained events after
+// `after` of `epoch` are returned to be replayed first, or a single reset
+// event if some of them are gone. The channel is closed when the subscriber
+// falls behind or `unsubscribe` is called.
+func (h *hub) subscribe(resume bool, epoch string, after uint64) (replay []Event, events chan Event, unsubscribe func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch {
+	case !resume:
+	case epoch != h.epoch || after > h.seq:
+		// From before a restart of the server; nothing can be resumed.
+		replay = []Event{{Seq: h.seq, Type: EventReset, Data: json.RawMessage("{}")}}
+	case after < h.seq:
+		oldest := h.seq - uint64(len(h.events)) + 1
+		if after+1 < oldest {
+			replay = []Event{{Seq: h.seq, Type: EventReset, Data: json.RawMessage("{}")}}
+		} else {
+			replay = append(replay, h.events[after+1-oldest:]...)
+		}
+	}
+
+	events = make(chan Event, subscriberBuffer)
+	h.clients[events] = struct{}{}
+	unsubscribe = func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.clients[events]; ok {
+			delete(h.clients, events)
+			close(events)
+		}
+	}
+	return replay, events, unsubscribe
+}
+
+// How often a comment is sent on idle event streams, so that proxies do not
+// close them.
+const keepAliveInterval = 30 * time.Second
+
+// Where a client resumes: the id of the last event it has seen, given by the
+// `Last-Event-ID` header browsers send when reconnecting, or else by the query
+// parameter "after". Ids without an epoch are from an older server, and only
+// ever lead to a reset.
+func resumePoint(r *http.Request) (bool, string, uint64, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("after")
+	}
+	if value == "" {
+		return false, "", 0, nil
+	}
+	epoch, seq, ok := strings.Cut(value, "-")
+	if !ok {
+		epoch, seq = "", value
+	}
+	after, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return false, "", 0, errorf(http.StatusBadRequest, "invalid event id '%s'", value)
+	}
+	return true, epoch, after, nil
+}
+
+func writeEvent(w io.Writer, epoch string, event Event) error {
+	_, err := fmt.Fprintf(w, "id: %s-%d\nevent: %s\ndata: %s\n\n", epoch, event.Seq, event.Type, event.Data)
+	return err
+}
+
+// Stream events to the client as server-sent events until it disconnects or
+// falls behind; in the latter case, it reconnects and resumes.
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		status := http.StatusInternalServerError
+		writeJSON(w, status, ErrorBody{Status: status, Error: "streaming is not supported"})
+		return
+	}
+	resume, epoch, after, err := resumePoint(r)
+	if err != nil {
+		body := newErrorBody(err)
+		writeJSON(w, body.Status, body)
+		return
+	}
+
+	replay, events, unsubscribe := s.events.subscribe(resume, epoch, after)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	for _, event := range replay {
+		if err := writeEvent(w, s.events.epoch, event); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := writeEvent(w, s.events.epoch, event); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,234 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+func eventTypes(events []Event) []string {
+	var types []string
+	for _, event := range events {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+func TestHubResume(t *testing.T) {
+	t.Parallel()
+
+	h := newHub()
+	for range 3 {
+		h.publish(newEvent(EventVaultAdded, nil))
+	}
+
+	replay, _, unsubscribe := h.subscribe(true, h.epoch, 1)
+	defer unsubscribe()
+	if len(replay) != 2 || replay[0].Seq != 2 || replay[1].Seq != 3 {
+		t.Errorf("expected events 2 and 3 to be replayed, got %+v", replay)
+	}
+
+	if replay, _, unsubscribe := h.subscribe(false, "", 0); len(replay) != 0 {
+		t.Errorf("expected no replay for new subscribers, got %+v", replay)
+	} else {
+		unsubscribe()
+	}
+
+	if replay, _, unsubscribe := h.subscribe(true, h.epoch, 7); len(replay) != 1 || replay[0].Type != EventReset {
+		t.Errorf("expected a reset for an unknown event id, got %+v", replay)
+	} else {
+		unsubscribe()
+	}
+
+	// After a restart, the same sequence numbers are used again.
+	if replay, _, unsubscribe := newHub().subscribe(true, h.epoch, 1); len(replay) != 1 || replay[0].Type != EventReset {
+		t.Errorf("expected a reset for an event of another epoch, got %+v", replay)
+	} else {
+		unsubscribe()
+	}
+
+	for range retainedEvents {
+		h.publish(newEvent(EventVaultAdded, nil))
+	}
+	if replay, _, unsubscribe := h.subscribe(true, h.epoch, 1); len(replay) != 1 || replay[0].Type != EventReset {
+		t.Errorf("expected a reset for events that are no longer retained, got %d events", len(replay))
+	} else {
+		unsubscribe()
+	}
+}
+
+func TestHubDropsSlowSubscribers(t *testing.T) {
+	t.Parallel()
+
+	h := newHub()
+	_, events, unsubscribe := h.subscribe(false, "", 0)
+	defer unsubscribe()
+	for range subscriberBuffer + 1 {
+		h.publish(newEvent(EventVaultAdded, nil))
+	}
+	count := 0
+	for range events {
+		count++
+	}
+	if count != subscriberBuffer {
+		t.Errorf("expected %d buffered events before the channel is closed, got %d", subscriberBuffer, count)
+	}
+}
+
+func TestDiffStash(t *testing.T) {
+	t.Parallel()
+
+	old, err := stash.ReadStash("../test_data/stashes/transfer.gst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if events := diffStash("softcore", old, old, nil); len(events) != 0 {
+		t.Errorf("expected no events for an unchanged stash, got %v", eventTypes(events))
+	}
+
+	new, err := stash.ReadStash("../test_data/stashes/transfer.gst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	moved := new.Tabs[0].Items[0]
+	new.Tabs[0].Items = new.Tabs[0].Items[1:]
+	moved.SetPosition(7, 7)
+	new.Tabs[1].Items = append(new.Tabs[1].Items, moved)
+	removed := new.Tabs[2].Items[0]
+	new.Tabs[2].Items = new.Tabs[2].Items[1:]
+	new.Tabs[2].Items = append(new.Tabs[2].Items, stash.Item{Base: "records/items/materia/compa_bristlyfur.dbr", StackSize: 1})
+
+	events := diffStash("softcore", old, new, nil)
+	types := strings.Join(eventTypes(events), ",")
+	if types != "item-moved,item-added,item-removed" {
+		t.Fatalf("unexpected events %s", types)
+	}
+
+	var move ItemEvent
+	if err := json.Unmarshal(events[0].Data, &move); err != nil {
+		t.Fatal(err)
+	}
+	x, y := old.Tabs[0].Items[0].Position()
+	if move.Tab != 1 || move.Item.X != 7 || move.From == nil || *move.From != (Location{Tab: 0, X: x, Y: y}) {
+		t.Errorf("unexpected move %+v", move)
+	}
+	var remove ItemEvent
+	if err := json.Unmarshal(events[2].Data, &remove); err != nil {
+		t.Fatal(err)
+	}
+	if remove.Tab != 2 || remove.Item.Base != removed.Base {
+		t.Errorf("unexpected removal %+v", remove)
+	}
+}
+
+type sseEvent struct {
+	id   string
+	typ  string
+	data string
+}
+
+// Read the next event from a server-sent event stream, skipping comments.
+func readEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+	var event sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("could not read event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && event.typ != "":
+			return event
+		case strings.HasPrefix(line, "id: "):
+			event.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			event.typ = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			event.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	t.Parallel()
+	s, file := testServer(t)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", ts.URL+"/api/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type '%s'", ct)
+	}
+	events := bufio.NewReader(resp.Body)
+
+	body := strings.NewReader(`{"stash": "softcore", "tab": 0, "item": 0}`)
+	post, err := http.Post(ts.URL+"/api/transfers/to-vault", "application/json", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	post.Body.Close()
+
+	id := func(seq int) string { return fmt.Sprintf("%s-%d", s.events.epoch, seq) }
+	first, second := readEvent(t, events), readEvent(t, events)
+	if first.typ != EventVaultAdded || first.id != id(1) || second.typ != EventItemRemoved || second.id != id(2) {
+		t.Errorf("unexpected events %+v, %+v", first, second)
+	}
+
+	// Changes made by the game are picked up by `Watch`.
+	st, err := stash.ReadStash(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.Tabs[2].Items = st.Tabs[2].Items[1:]
+	if err := stash.WriteStash(file, st); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(file, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	go s.Watch(ctx, 10*time.Millisecond)
+	if event := readEvent(t, events); event.typ != EventItemRemoved || event.id != id(3) {
+		t.Errorf("unexpected event %+v", event)
+	}
+
+	// Resuming replays what has been missed.
+	req.Header.Set("Last-Event-ID", id(1))
+	resumed, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resumed.Body.Close()
+	replayed := bufio.NewReader(resumed.Body)
+	if a, b := readEvent(t, replayed), readEvent(t, replayed); a.id != id(2) || b.id != id(3) {
+		t.Errorf("expected events 2 and 3 to be replayed, got %+v, %+v", a, b)
+	}
+
+	// Ids of an earlier server run are not resumed, even if they are known.
+	req.Header.Set("Last-Event-ID", "1")
+	stale, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stale.Body.Close()
+	if event := readEvent(t, bufio.NewReader(stale.Body)); event.typ != EventReset || event.id != id(3) {
+		t.Errorf("expected a reset, got %+v", event)
+	}
+}
@@ -0,0 +1,282 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kenranunderscore/grimvault/backend/resolve"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+	"github.com/kenranunderscore/grimvault/backend/stashjson"
+	"github.com/kenranunderscore/grimvault/backend/vault"
+)
+
+// A stash file as listed by `GET /api/stashes`.
+type StashInfo struct {
+	Id       string `json:"id"`
+	Mod      string `json:"mod,omitempty"`
+	Hardcore bool   `json:"hardcore"`
+	Tabs     int    `json:"tabs"`
+}
+
+// An item together with everything known about it.
+type ItemDetails struct {
+	Item    stashjson.Item   `json:"item"`
+	Details *resolve.Details `json:"details,omitempty"`
+}
+
+// A vault entry as returned by the API.
+type VaultItem struct {
+	Id       uint64           `json:"id"`
+	Item     stashjson.Item   `json:"item"`
+	Source   string           `json:"source"`
+	Owner    string           `json:"owner,omitempty"`
+	Hardcore bool             `json:"hardcore,omitempty"`
+	Added    time.Time        `json:"added"`
+	Details  *resolve.Details `json:"details,omitempty"`
+}
+
+// Moves an item of a stash tab into the vault.
+type ToVaultRequest struct {
+	Stash string `json:"stash"`
+	Tab   int    `json:"tab"`
+	Item  int    `json:"item"`
+}
+
+// Moves a vault item into a stash tab, at the given grid position. Items the
+// game would not produce are refused unless `Force` is set.
+type ToStashRequest struct {
+	Id    uint64  `json:"id"`
+	Stash string  `json:"stash"`
+	Tab   int     `json:"tab"`
+	X     float32 `json:"x"`
+	Y     float32 `json:"y"`
+	Force bool    `json:"force,omitempty"`
+}
+
+func (s *Server) details(item *stash.Item) *resolve.Details {
+	if s.Resolver == nil {
+		return nil
+	}
+	d := s.Resolver.Details(item)
+	return &d
+}
+
+func (s *Server) vaultItem(entry *vault.Entry, details bool) VaultItem {
+	res := VaultItem{
+		Id:       entry.Id,
+		Item:     stashjson.FromItem(&entry.Item, s.namer()),
+		Source:   entry.Source,
+		Owner:    entry.Owner,
+		Hardcore: entry.Hardcore,
+		Added:    entry.Added,
+	}
+	if details {
+		res.Details = s.details(&entry.Item)
+	}
+	return res
+}
+
+func (s *Server) listStashes(r *http.Request) (int, any, error) {
+	files, err := s.stashFiles()
+	if err != nil {
+		return 0, nil, err
+	}
+	infos := make([]StashInfo, 0, len(files))
+	for _, file := range files {
+		st, err := stash.ReadStash(file.Path)
+		if err != nil {
+			return 0, nil, err
+		}
+		infos = append(infos, StashInfo{Id: file.Id(), Mod: file.Mod, Hardcore: file.Hardcore, Tabs: len(st.Tabs)})
+	}
+	return http.StatusOK, infos, nil
+}
+
+func (s *Server) getStash(r *http.Request) (int, any, error) {
+	_, st, err := s.readStash(r.PathValue("stash"))
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, stashjson.Export(st, s.namer()), nil
+}
+
+func (s *Server) getTab(r *http.Request) (int, any, error) {
+	_, st, err := s.readStash(r.PathValue("stash"))
+	if err != nil {
+		return 0, nil, err
+	}
+	tab, err := tabAt(r, st)
+	if err != nil {
+		return 0, nil, err
+	}
+	items := make([]stashjson.Item, 0, len(tab.Items))
+	for i := range tab.Items {
+		items = append(items, stashjson.FromItem(&tab.Items[i], s.namer()))
+	}
+	return http.StatusOK, stashjson.Tab{Width: tab.Width, Height: tab.Height, Items: items}, nil
+}
+
+// Look up the stash item given by the path parameters "stash", "tab" and
+// "item".
+func (s *Server) stashItem(r *http.Request) (*stash.Item, error) {
+	_, st, err := s.readStash(r.PathValue("stash"))
+	if err != nil {
+		return nil, err
+	}
+	tab, err := tabAt(r, st)
+	if err != nil {
+		return nil, err
+	}
+	i, err := pathIndex(r, "item")
+	if err != nil {
+		return nil, err
+	}
+	if i >= len(tab.Items) {
+		return nil, errorf(http.StatusNotFound, "item %d does not exist, the tab has %d items", i, len(tab.Items))
+	}
+	return &tab.Items[i], nil
+}
+
+func (s *Server) getStashItem(r *http.Request) (int, any, error) {
+	item, err := s.stashItem(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, ItemDetails{Item: stashjson.FromItem(item, s.namer()), Details: s.details(item)}, nil
+}
+
+// Find vault items whose record paths or names contain the query parameter
+// "q". All items are returned if it is missing.
+func (s *Server) searchVault(r *http.Request) (int, any, error) {
+	query := r.URL.Query().Get("q")
+	found := make([]VaultItem, 0)
+	for i := range s.Vault.Entries {
+		entry := &s.Vault.Entries[i]
+		item := s.vaultItem(entry, false)
+		if query == "" || containsFold(item.Item.Name, query) || entry.Matches(query) {
+			found = append(found, item)
+		}
+	}
+	return http.StatusOK, found, nil
+}
+
+// Find vault items by the text given by query parameter "q", allowing for
+// typos, missing diacritics and unfinished words. The best matches come
+// first.
+func (s *Server) findVaultItems(r *http.Request) (int, any, error) {
+	found := make([]VaultItem, 0)
+	for _, result := range s.texts.Search(r.URL.Query().Get("q")) {
+		if entry, ok := s.Vault.Get(result.Id); ok {
+			found = append(found, s.vaultItem(&entry, false))
+		}
+	}
+	return http.StatusOK, found, nil
+}
+
+func (s *Server) vaultId(r *http.Request) (uint64, error) {
+	value := r.PathValue("id")
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, errorf(http.StatusBadRequest, "invalid item id '%s'", value)
+	}
+	return id, nil
+}
+
+func (s *Server) getVaultItem(r *http.Request) (int, any, error) {
+	id, err := s.vaultId(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	entry, ok := s.Vault.Get(id)
+	if !ok {
+		return 0, nil, errorf(http.StatusNotFound, "no item with id %d in the vault", id)
+	}
+	return http.StatusOK, s.vaultItem(&entry, true), nil
+}
+
+func (s *Server) transferToVault(r *http.Request) (int, any, error) {
+	var req ToVaultRequest
+	if err := readJSON(r, &req); err != nil {
+		return 0, nil, err
+	}
+	file, st, err := s.readStash(req.Stash)
+	if err != nil {
+		return 0, nil, err
+	}
+	if req.Tab < 0 || req.Tab >= len(st.Tabs) {
+		return 0, nil, errorf(http.StatusNotFound, "tab %d does not exist, the stash has %d tabs", req.Tab, len(st.Tabs))
+	}
+	tab := &st.Tabs[req.Tab]
+	if req.Item < 0 || req.Item >= len(tab.Items) {
+		return 0, nil, errorf(http.StatusNotFound, "item %d does not exist, the tab has %d items", req.Item, len(tab.Items))
+	}
+
+	item := tab.Items[req.Item]
+	tab.Items = append(tab.Items[:req.Item], tab.Items[req.Item+1:]...)
+	// The item is only removed from the stash once the vault has it, so it can
+	// at worst be duplicated, but never lost.
+	entry := s.Vault.Insert(vault.Entry{Item: item, Source: file.Path, Hardcore: file.Hardcore})
+	if err := s.Vault.Save(); err != nil {
+		s.Vault.Remove(entry.Id)
+		return 0, nil, err
+	}
+	s.texts.AddItem(entry.Id, &entry.Item)
+	s.events.publish(newEvent(EventVaultAdded, s.vaultItem(&entry, false)))
+	if err := s.writeStash(file, st); err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, s.vaultItem(&entry, false), nil
+}
+
+func (s *Server) transferToStash(r *http.Request) (int, any, error) {
+	var req ToStashRequest
+	if err := readJSON(r, &req); err != nil {
+		return 0, nil, err
+	}
+	entry, ok := s.Vault.Get(req.Id)
+	if !ok {
+		return 0, nil, errorf(http.StatusNotFound, "no item with id %d in the vault", req.Id)
+	}
+	file, st, err := s.readStash(req.Stash)
+	if err != nil {
+		return 0, nil, err
+	}
+	if entry.Hardcore != file.Hardcore {
+		return 0, nil, errorf(http.StatusConflict, "item %d and stash '%s' are not of the same mode", req.Id, file.Id())
+	}
+	if req.Tab < 0 || req.Tab >= len(st.Tabs) {
+		return 0, nil, errorf(http.StatusNotFound, "tab %d does not exist, the stash has %d tabs", req.Tab, len(st.Tabs))
+	}
+	if !req.Force {
+		if err := s.checkWritable(&entry.Item); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	item := entry.Item
+	item.SetPosition(req.X, req.Y)
+	if err := st.Tabs[req.Tab].Put(&item, s.itemSize()); err != nil {
+		return 0, nil, placeError(err)
+	}
+	if err := s.writeStash(file, st); err != nil {
+		return 0, nil, err
+	}
+	s.Vault.Remove(entry.Id)
+	s.texts.Remove(entry.Id)
+	if err := s.Vault.Save(); err != nil {
+		return 0, nil, err
+	}
+	s.events.publish(newEvent(EventVaultRemoved, s.vaultItem(&entry, false)))
+	return http.StatusOK, ItemDetails{Item: stashjson.FromItem(&item, s.namer())}, nil
+}
+
+// The error response for an item that could not be placed into a tab: a
+// conflict if the cells are taken, and a bad request otherwise.
+func placeError(err error) error {
+	if errors.Is(err, stash.ErrNoRoom) {
+		return errorf(http.StatusConflict, "%v", err)
+	}
+	return errorf(http.StatusBadRequest, "%v", err)
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/kenranunderscore/grimvault/backend/query"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+	"github.com/kenranunderscore/grimvault/backend/stashjson"
+)
+
+// An item matching a search. Exactly one of `VaultId` and `At` is set.
+type SearchResult struct {
+	Item    stashjson.Item `json:"item"`
+	VaultId uint64         `json:"vaultId,omitempty"`
+	At      *ItemPosition  `json:"at,omitempty"`
+}
+
+// Where an item is in a stash: the stash id, the tab, and the index of the
+// item within the tab.
+type ItemPosition struct {
+	Stash string `json:"stash"`
+	Tab   int    `json:"tab"`
+	Index int    `json:"index"`
+}
+
+// A saved search query.
+type SavedSearch struct {
+	Query string `json:"query"`
+}
+
+// Find the items of the vault and all stashes matching the query given by
+// parameter "q", or the saved query named by parameter "saved".
+func (s *Server) search(r *http.Request) (int, any, error) {
+	text := r.URL.Query().Get("q")
+	if name := r.URL.Query().Get("saved"); name != "" {
+		var ok bool
+		if text, ok = s.Vault.Searches[name]; !ok {
+			return 0, nil, errorf(http.StatusNotFound, "no saved search '%s'", name)
+		}
+	}
+	q, err := query.Parse(text)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	results := make([]SearchResult, 0)
+	for i := range s.Vault.Entries {
+		entry := &s.Vault.Entries[i]
+		item := query.Resolve(s.Resolver, &entry.Item)
+		item.Source, item.Owner = "vault", entry.Owner
+		if q.Match(&item) {
+			results = append(results, SearchResult{Item: stashjson.FromItem(&entry.Item, s.namer()), VaultId: entry.Id})
+		}
+	}
+
+	files, err := s.stashFiles()
+	if err != nil {
+		return 0, nil, err
+	}
+	for _, file := range files {
+		id := file.Id()
+		st, err := stash.ReadStash(file.Path)
+		if err != nil {
+			return 0, nil, err
+		}
+		for i := range st.Tabs {
+			for j := range st.Tabs[i].Items {
+				stashItem := &st.Tabs[i].Items[j]
+				item := query.Resolve(s.Resolver, stashItem)
+				// Tabs are counted from 1 in queries, like in game.
+				item.Source, item.Tab = id, i+1
+				if q.Match(&item) {
+					at := &ItemPosition{Stash: id, Tab: i, Index: j}
+					results = append(results, SearchResult{Item: stashjson.FromItem(stashItem, s.namer()), At: at})
+				}
+			}
+		}
+	}
+	return http.StatusOK, results, nil
+}
+
+func (s *Server) listSearches(r *http.Request) (int, any, error) {
+	return http.StatusOK, s.Vault.Searches, nil
+}
+
+func (s *Server) saveSearch(r *http.Request) (int, any, error) {
+	var saved SavedSearch
+	if err := readJSON(r, &saved); err != nil {
+		return 0, nil, err
+	}
+	if _, err := query.Parse(saved.Query); err != nil {
+		return 0, nil, err
+	}
+	s.Vault.Searches[r.PathValue("name")] = saved.Query
+	if err := s.Vault.Save(); err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, saved, nil
+}
+
+func (s *Server) deleteSearch(r *http.Request) (int, any, error) {
+	name := r.PathValue("name")
+	saved, ok := s.Vault.Searches[name]
+	if !ok {
+		return 0, nil, errorf(http.StatusNotFound, "no saved search '%s'", name)
+	}
+	delete(s.Vault.Searches, name)
+	if err := s.Vault.Save(); err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, SavedSearch{Query: saved}, nil
+}
@@ -0,0 +1,311 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kenranunderscore/grimvault/backend/affixes"
+	"github.com/kenranunderscore/grimvault/backend/backup"
+	"github.com/kenranunderscore/grimvault/backend/collection"
+	"github.com/kenranunderscore/grimvault/backend/drops"
+	"github.com/kenranunderscore/grimvault/backend/editor"
+	"github.com/kenranunderscore/grimvault/backend/locate"
+	"github.com/kenranunderscore/grimvault/backend/query"
+	"github.com/kenranunderscore/grimvault/backend/resolve"
+	"github.com/kenranunderscore/grimvault/backend/search"
+	"github.com/kenranunderscore/grimvault/backend/sets"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+	"github.com/kenranunderscore/grimvault/backend/stashjson"
+	"github.com/kenranunderscore/grimvault/backend/tooltip"
+	"github.com/kenranunderscore/grimvault/backend/validate"
+	"github.com/kenranunderscore/grimvault/backend/vault"
+)
+
+// Serves the JSON API used by the frontend. All routes live below `/api`.
+//
+// Requests are handled one at a time, as most of them read or write the
+// stash and vault files. Changes to stashes and the vault are published as
+// server-sent events on `/api/events`; changes made by the game are only
+// noticed while `Watch` is running.
+type Server struct {
+	SaveDir string
+	Vault   *vault.Vault
+	// Used for item names and details; nil if the game data is not available.
+	Resolver *resolve.Resolver
+	// Stash files are backed up here before being written, unless it is nil.
+	Backups *backup.Store
+
+	mu        sync.Mutex
+	mux       *http.ServeMux
+	events    *hub
+	snapshots map[string]snapshot
+	// Full-text index of the vault items, by vault id.
+	texts *search.ItemIndex
+	// Built on first use, as it needs the whole database to be scanned.
+	sets *sets.Index
+	// Built along with `sets`.
+	tooltips *tooltip.Builder
+	// Built on first use, as it needs the whole database to be scanned.
+	affixes *affixes.Index
+	// Built on first use for the same reason.
+	drops *drops.Index
+	// Built on first use along with `affixes`.
+	validate *validate.Validator
+	// Built on first use along with `validate`.
+	editor *editor.Editor
+	// Catalogues of obtainable items by the rarities they list, built on
+	// first use for the same reason.
+	catalogues map[string]*collection.Catalogue
+}
+
+func New(saveDir string, v *vault.Vault, resolver *resolve.Resolver, backups *backup.Store) *Server {
+	s := &Server{
+		SaveDir:    saveDir,
+		Vault:      v,
+		Resolver:   resolver,
+		Backups:    backups,
+		mux:        http.NewServeMux(),
+		events:     newHub(),
+		snapshots:  make(map[string]snapshot),
+		catalogues: make(map[string]*collection.Catalogue),
+	}
+	s.refresh()
+	s.IndexLanguages(resolver)
+	s.handle("GET /api/stashes", s.listStashes)
+	s.handle("GET /api/stashes/{stash}", s.getStash)
+	s.handle("GET /api/stashes/{stash}/tabs/{tab}", s.getTab)
+	s.handle("GET /api/stashes/{stash}/tabs/{tab}/items/{item}", s.getStashItem)
+	s.handle("GET /api/stashes/{stash}/tabs/{tab}/items/{item}/tooltip", s.getStashTooltip)
+	s.handle("POST /api/stashes/{stash}/tabs/{tab}/items/{item}/split", s.splitStack)
+	s.handle("GET /api/vault", s.searchVault)
+	s.handle("GET /api/vault/find", s.findVaultItems)
+	s.handle("GET /api/vault/{id}", s.getVaultItem)
+	s.handle("GET /api/vault/{id}/tooltip", s.getVaultTooltip)
+	s.handle("GET /api/vault/{id}/odds", s.getVaultOdds)
+	s.handle("GET /api/vault/{id}/check", s.getVaultCheck)
+	s.handle("GET /api/discarded", s.listDiscarded)
+	s.handle("POST /api/discarded/{id}/restore", s.restoreDiscarded)
+	s.handle("GET /api/duplicates", s.listDuplicates)
+	s.handle("POST /api/duplicates/discard", s.discardDuplicates)
+	s.handle("GET /api/search", s.search)
+	s.handle("GET /api/searches", s.listSearches)
+	s.handle("PUT /api/searches/{name}", s.saveSearch)
+	s.handle("DELETE /api/searches/{name}", s.deleteSearch)
+	s.handle("GET /api/sets", s.setReport)
+	s.handle("GET /api/collection", s.collectionReport)
+	s.handle("GET /api/affixes/pool", s.affixPool)
+	s.handle("GET /api/affixes/bases", s.affixBases)
+	s.handle("GET /api/drops", s.dropSources)
+	s.handle("GET /api/editor/options", s.editorOptions)
+	s.handle("POST /api/editor/items", s.createItem)
+	s.handle("POST /api/transfers/to-vault", s.transferToVault)
+	s.handle("POST /api/transfers/to-stash", s.transferToStash)
+	s.handle("POST /api/stacks/consolidate", s.consolidateStacks)
+	// Streams for as long as the client is connected, so it must not hold the
+	// lock like the other handlers.
+	s.mux.HandleFunc("GET /api/events", s.streamEvents)
+	return s
+}
+
+// Rebuild the full-text index of the vault items with the texts of the given
+// resolvers, one per language. Without any, items are found by their record
+// paths only.
+func (s *Server) IndexLanguages(resolvers ...*resolve.Resolver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.texts = search.NewItemIndex(resolvers...)
+	for i := range s.Vault.Entries {
+		entry := &s.Vault.Entries[i]
+		s.texts.AddItem(entry.Id, &entry.Item)
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// An error that is reported to the client with the given status code.
+type apiError struct {
+	status int
+	err    error
+}
+
+func (e *apiError) Error() string {
+	return e.err.Error()
+}
+
+func (e *apiError) Unwrap() error {
+	return e.err
+}
+
+func errorf(status int, format string, args ...any) error {
+	return &apiError{status: status, err: fmt.Errorf(format, args...)}
+}
+
+// The status code to report `err` with. Errors that are not explicitly
+// classified are mapped based on what they wrap: files that do not exist are
+// not found, stashes that cannot be decoded are unprocessable, and invalid
+// queries are bad requests.
+func statusOf(err error) int {
+	var apiErr *apiError
+	var syntaxErr *query.SyntaxError
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr.status
+	case errors.As(err, &syntaxErr):
+		return http.StatusBadRequest
+	case errors.Is(err, fs.ErrNotExist):
+		return http.StatusNotFound
+	case errors.Is(err, stash.ErrInvalid):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+// The body of every error response.
+type ErrorBody struct {
+	Status int    `json:"status"`
+	Error  string `json:"error"`
+	// Where the syntax error is, for invalid search queries.
+	Syntax *SyntaxErrorPosition `json:"syntax,omitempty"`
+}
+
+// The part of a query a syntax error refers to, as byte offset and length.
+type SyntaxErrorPosition struct {
+	Pos    int `json:"pos"`
+	Length int `json:"length"`
+}
+
+func newErrorBody(err error) ErrorBody {
+	body := ErrorBody{Status: statusOf(err), Error: err.Error()}
+	var syntaxErr *query.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		body.Syntax = &SyntaxErrorPosition{Pos: syntaxErr.Pos, Length: syntaxErr.Length}
+	}
+	return body
+}
+
+type handlerFunc func(r *http.Request) (int, any, error)
+
+// Register `h` for `pattern`. Handlers return the status and value to respond
+// with, or an error that is turned into an error body.
+func (s *Server) handle(pattern string, h handlerFunc) {
+	s.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		status, value, err := h(r)
+		s.mu.Unlock()
+		if err != nil {
+			body := newErrorBody(err)
+			status, value = body.Status, body
+		}
+		writeJSON(w, status, value)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// Decode the JSON request body into `value`, rejecting unknown fields.
+func readJSON(r *http.Request, value any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(value); err != nil {
+		return errorf(http.StatusBadRequest, "invalid request body: %w", err)
+	}
+	return nil
+}
+
+// Parse the non-negative integer path parameter `name`.
+func pathIndex(r *http.Request, name string) (int, error) {
+	value := r.PathValue(name)
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, errorf(http.StatusBadRequest, "invalid %s '%s'", name, value)
+	}
+	return n, nil
+}
+
+func (s *Server) stashFiles() ([]locate.StashFile, error) {
+	save, err := locate.ScanSaveDir(s.SaveDir)
+	if err != nil {
+		return nil, err
+	}
+	return save.Stashes, nil
+}
+
+func (s *Server) findStashFile(id string) (locate.StashFile, error) {
+	files, err := s.stashFiles()
+	if err != nil {
+		return locate.StashFile{}, err
+	}
+	for _, file := range files {
+		if file.Id() == id {
+			return file, nil
+		}
+	}
+	return locate.StashFile{}, errorf(http.StatusNotFound, "no stash '%s'", id)
+}
+
+func (s *Server) readStash(id string) (locate.StashFile, *stash.Stash, error) {
+	file, err := s.findStashFile(id)
+	if err != nil {
+		return file, nil, err
+	}
+	st, err := stash.ReadStash(file.Path)
+	return file, st, err
+}
+
+// Look up the tab with the index given by the path parameter "tab".
+func tabAt(r *http.Request, st *stash.Stash) (*stash.StashTab, error) {
+	i, err := pathIndex(r, "tab")
+	if err != nil {
+		return nil, err
+	}
+	if i >= len(st.Tabs) {
+		return nil, errorf(http.StatusNotFound, "tab %d does not exist, the stash has %d tabs", i, len(st.Tabs))
+	}
+	return &st.Tabs[i], nil
+}
+
+func (s *Server) namer() stashjson.Namer {
+	if s.Resolver == nil {
+		return nil
+	}
+	return s.Resolver.Name
+}
+
+// The grid cells items take, or a single cell each without the game data.
+func (s *Server) itemSize() stash.Size {
+	if s.Resolver == nil {
+		return stash.SingleCell
+	}
+	return s.Resolver.Size
+}
+
+// Write `st` to `file`, backing up the current file first. Subscribers are
+// notified right away instead of on the next check for changes.
+func (s *Server) writeStash(file locate.StashFile, st *stash.Stash) error {
+	if s.Backups != nil {
+		if _, _, err := s.Backups.Backup(file.Path); err != nil {
+			return err
+		}
+	}
+	if err := stash.WriteStash(file.Path, st); err != nil {
+		return err
+	}
+	s.observe(file)
+	return nil
+}
+
+func containsFold(s string, sub string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(sub))
+}
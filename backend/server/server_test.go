@@ -0,0 +1,421 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kenranunderscore/grimvault/backend/arc"
+	"github.com/kenranunderscore/grimvault/backend/backup"
+	"github.com/kenranunderscore/grimvault/backend/database"
+	"github.com/kenranunderscore/grimvault/backend/dedupe"
+	"github.com/kenranunderscore/grimvault/backend/internal/testdb"
+	"github.com/kenranunderscore/grimvault/backend/resolve"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+	"github.com/kenranunderscore/grimvault/backend/stashjson"
+	"github.com/kenranunderscore/grimvault/backend/vault"
+)
+
+// A server on a temporary save directory containing a copy of the test stash.
+func testServer(t *testing.T) (*Server, string) {
+	t.Helper()
+	dir := t.TempDir()
+	data, err := os.ReadFile("../test_data/stashes/transfer.gst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "transfer.gst")
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	v, err := vault.Open(filepath.Join(dir, "vault.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	backups, err := backup.NewStore(filepath.Join(dir, "backups"), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(dir, v, nil, backups), file
+}
+
+func request(t *testing.T, s *Server, method string, path string, body string, result any) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("%s %s: unexpected content type '%s'", method, path, ct)
+	}
+	if result != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), result); err != nil {
+			t.Fatalf("%s %s: invalid response body: %v", method, path, err)
+		}
+	}
+	return rec.Code
+}
+
+func TestListAndGetStashes(t *testing.T) {
+	t.Parallel()
+	s, _ := testServer(t)
+
+	var infos []StashInfo
+	if status := request(t, s, "GET", "/api/stashes", "", &infos); status != http.StatusOK {
+		t.Fatalf("unexpected status %d", status)
+	}
+	if len(infos) != 1 || infos[0].Id != "softcore" || infos[0].Tabs == 0 {
+		t.Fatalf("unexpected stashes %+v", infos)
+	}
+
+	var doc stashjson.Document
+	if status := request(t, s, "GET", "/api/stashes/softcore", "", &doc); status != http.StatusOK {
+		t.Fatalf("unexpected status %d", status)
+	}
+	if len(doc.Tabs) != infos[0].Tabs {
+		t.Errorf("expected %d tabs, got %d", infos[0].Tabs, len(doc.Tabs))
+	}
+
+	var tab stashjson.Tab
+	if status := request(t, s, "GET", "/api/stashes/softcore/tabs/0", "", &tab); status != http.StatusOK {
+		t.Fatalf("unexpected status %d", status)
+	}
+	if len(tab.Items) != len(doc.Tabs[0].Items) {
+		t.Errorf("expected %d items, got %d", len(doc.Tabs[0].Items), len(tab.Items))
+	}
+
+	var item ItemDetails
+	if status := request(t, s, "GET", "/api/stashes/softcore/tabs/0/items/0", "", &item); status != http.StatusOK {
+		t.Fatalf("unexpected status %d", status)
+	}
+	if item.Item != doc.Tabs[0].Items[0] {
+		t.Errorf("expected item %+v, got %+v", doc.Tabs[0].Items[0], item.Item)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	t.Parallel()
+	s, file := testServer(t)
+
+	cases := []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"GET", "/api/stashes/hardcore", "", http.StatusNotFound},
+		{"GET", "/api/stashes/softcore/tabs/99", "", http.StatusNotFound},
+		{"GET", "/api/stashes/softcore/tabs/x", "", http.StatusBadRequest},
+		{"GET", "/api/vault/1", "", http.StatusNotFound},
+		{"GET", "/api/vault/abc", "", http.StatusBadRequest},
+		{"POST", "/api/transfers/to-vault", "{", http.StatusBadRequest},
+		{"POST", "/api/transfers/to-vault", `{"unknown": 1}`, http.StatusBadRequest},
+		{"POST", "/api/transfers/to-stash", `{"id": 5, "stash": "softcore"}`, http.StatusNotFound},
+	}
+	for _, c := range cases {
+		var body ErrorBody
+		status := request(t, s, c.method, c.path, c.body, &body)
+		if status != c.status || body.Status != c.status || body.Error == "" {
+			t.Errorf("%s %s: expected status %d, got %d with body %+v", c.method, c.path, c.status, status, body)
+		}
+	}
+
+	// Truncated stashes are reported as such.
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, data[:100], 0644); err != nil {
+		t.Fatal(err)
+	}
+	var body ErrorBody
+	if status := request(t, s, "GET", "/api/stashes/softcore", "", &body); status != http.StatusUnprocessableEntity {
+		t.Errorf("expected status %d for a truncated stash, got %d: %+v", http.StatusUnprocessableEntity, status, body)
+	}
+}
+
+func TestTransferRoundTrip(t *testing.T) {
+	t.Parallel()
+	s, file := testServer(t)
+
+	before, err := stash.ReadStash(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := before.Tabs[0].Items[0]
+
+	var moved VaultItem
+	status := request(t, s, "POST", "/api/transfers/to-vault", `{"stash": "softcore", "tab": 0, "item": 0}`, &moved)
+	if status != http.StatusCreated {
+		t.Fatalf("unexpected status %d", status)
+	}
+	if moved.Item.Base != original.Base {
+		t.Errorf("expected %s to be moved, got %s", original.Base, moved.Item.Base)
+	}
+
+	after, err := stash.ReadStash(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after.Tabs[0].Items) != len(before.Tabs[0].Items)-1 {
+		t.Errorf("expected the item to be removed from the stash")
+	}
+	if backups, err := s.Backups.List("transfer.gst"); err != nil || len(backups) != 1 {
+		t.Errorf("expected the stash to be backed up before writing, got %v (%v)", backups, err)
+	}
+
+	var found []VaultItem
+	query := "/api/vault?q=" + filepath.Base(original.Base)
+	if status := request(t, s, "GET", query, "", &found); status != http.StatusOK || len(found) != 1 {
+		t.Fatalf("expected to find the moved item, got %d: %+v", status, found)
+	}
+	find := "/api/vault/find?q=" + strings.TrimSuffix(filepath.Base(original.Base), ".dbr")
+	if status := request(t, s, "GET", find, "", &found); status != http.StatusOK || len(found) != 1 || found[0].Id != moved.Id {
+		t.Fatalf("expected the full-text index to find the moved item, got %d: %+v", status, found)
+	}
+
+	var conflict ErrorBody
+	taken := slices.IndexFunc(after.Tabs, func(tab stash.StashTab) bool { return len(tab.Items) > 0 })
+	ox, oy := after.Tabs[taken].Items[0].Position()
+	body, _ := json.Marshal(ToStashRequest{Id: moved.Id, Stash: "softcore", Tab: taken, X: ox, Y: oy})
+	if status := request(t, s, "POST", "/api/transfers/to-stash", string(body), &conflict); status != http.StatusConflict {
+		t.Errorf("expected a taken cell to be a conflict, got %d: %+v", status, conflict)
+	}
+	hardcore := s.Vault.Insert(vault.Entry{Item: original, Hardcore: true})
+	body, _ = json.Marshal(ToStashRequest{Id: hardcore.Id, Stash: "softcore", Tab: 0})
+	if status := request(t, s, "POST", "/api/transfers/to-stash", string(body), &conflict); status != http.StatusConflict {
+		t.Errorf("expected a hardcore item not to go into a softcore stash, got %d: %+v", status, conflict)
+	}
+	s.Vault.Remove(hardcore.Id)
+
+	x, y := original.Position()
+	body, _ = json.Marshal(ToStashRequest{Id: moved.Id, Stash: "softcore", Tab: 0, X: x, Y: y})
+	var placed ItemDetails
+	if status := request(t, s, "POST", "/api/transfers/to-stash", string(body), &placed); status != http.StatusOK {
+		t.Fatalf("unexpected status %d", status)
+	}
+	if len(s.Vault.Entries) != 0 {
+		t.Errorf("expected the item to be removed from the vault")
+	}
+	if status := request(t, s, "GET", find, "", &found); status != http.StatusOK || len(found) != 0 {
+		t.Errorf("expected the item to be removed from the full-text index, got %d: %+v", status, found)
+	}
+
+	after, err = stash.ReadStash(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := after.Tabs[0].Items
+	if len(items) != len(before.Tabs[0].Items) || items[len(items)-1] != original {
+		t.Errorf("expected the original item back in the stash, got %+v", items[len(items)-1])
+	}
+}
+
+func TestSplitStack(t *testing.T) {
+	t.Parallel()
+	s, file := testServer(t)
+
+	before, err := stash.ReadStash(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tab := -1
+	index := -1
+	for i, items := range before.Tabs {
+		for j, item := range items.Items {
+			if item.StackSize > 1 && tab < 0 {
+				tab, index = i, j
+			}
+		}
+	}
+	if tab < 0 {
+		t.Fatal("expected a stack in the test stash")
+	}
+	stack := before.Tabs[tab].Items[index]
+
+	path := fmt.Sprintf("/api/stashes/softcore/tabs/%d/items/%d/split", tab, index)
+	var split ItemDetails
+	if status := request(t, s, "POST", path, `{"count": 1}`, &split); status != http.StatusCreated {
+		t.Fatalf("unexpected status %d", status)
+	}
+	if split.Item.Base != stack.Base || split.Item.StackSize != 1 {
+		t.Errorf("expected a stack of one %s, got %+v", stack.Base, split.Item)
+	}
+
+	after, err := stash.ReadStash(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := after.Tabs[tab].Items
+	if items[index].StackSize != stack.StackSize-1 || len(items) != len(before.Tabs[tab].Items)+1 {
+		t.Errorf("expected the stack to be split in the stash, got %+v", items)
+	}
+	for _, item := range items[:len(items)-1] {
+		if item.X == items[len(items)-1].X && item.Y == items[len(items)-1].Y {
+			t.Errorf("expected the new stack in a free cell, got it on %+v", item)
+		}
+	}
+
+	var taken ErrorBody
+	x, y := stack.Position()
+	at := fmt.Sprintf(`{"count": 1, "x": %v, "y": %v}`, x, y)
+	if status := request(t, s, "POST", path, at, &taken); status != http.StatusConflict {
+		t.Errorf("expected a taken cell to be a conflict, got %d: %+v", status, taken)
+	}
+
+	var body ErrorBody
+	all := fmt.Sprintf(`{"count": %d}`, stack.StackSize-1)
+	if status := request(t, s, "POST", path, all, &body); status != http.StatusBadRequest {
+		t.Errorf("expected taking the whole stack to fail, got %d: %+v", status, body)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	t.Parallel()
+	s, _ := testServer(t)
+
+	var results []SearchResult
+	if status := request(t, s, "GET", "/api/search?q=record:bristlyfur+tab:3", "", &results); status != http.StatusOK {
+		t.Fatalf("unexpected status %d", status)
+	}
+	if len(results) == 0 {
+		t.Fatal("expected results")
+	}
+	for _, result := range results {
+		if result.At == nil || result.At.Stash != "softcore" || result.At.Tab != 2 {
+			t.Errorf("unexpected result %+v", result)
+		}
+	}
+
+	var body ErrorBody
+	status := request(t, s, "GET", "/api/search?q=level>=high", "", &body)
+	if status != http.StatusBadRequest || body.Syntax == nil || *body.Syntax != (SyntaxErrorPosition{Pos: 7, Length: 4}) {
+		t.Errorf("expected the syntax error to be located, got %d: %+v", status, body)
+	}
+
+	if status := request(t, s, "PUT", "/api/searches/fur", `{"query": "record:bristlyfur"}`, nil); status != http.StatusOK {
+		t.Fatalf("unexpected status %d", status)
+	}
+	var saved []SearchResult
+	if status := request(t, s, "GET", "/api/search?saved=fur", "", &saved); status != http.StatusOK || len(saved) < len(results) {
+		t.Errorf("expected the saved search to find at least %d items, got %d (%d)", len(results), len(saved), status)
+	}
+	if status := request(t, s, "DELETE", "/api/searches/fur", "", nil); status != http.StatusOK {
+		t.Errorf("unexpected status %d", status)
+	}
+	if status := request(t, s, "GET", "/api/search?saved=fur", "", &body); status != http.StatusNotFound {
+		t.Errorf("expected the deleted search to be gone, got %d", status)
+	}
+}
+
+func TestReportsNeedGameData(t *testing.T) {
+	t.Parallel()
+	s, _ := testServer(t)
+
+	for _, path := range []string{"/api/sets", "/api/collection", "/api/stashes/softcore/tabs/0/items/0/tooltip", "/api/affixes/pool?base=x.dbr", "/api/drops?record=x.dbr", "/api/editor/options?base=x.dbr"} {
+		var body ErrorBody
+		if status := request(t, s, "GET", path, "", &body); status != http.StatusServiceUnavailable || body.Error == "" {
+			t.Errorf("%s: expected the report to be unavailable, got %d: %+v", path, status, body)
+		}
+	}
+	var body ErrorBody
+	if status := request(t, s, "POST", "/api/editor/items", `{"item":{"base":"x.dbr"},"stash":"softcore"}`, &body); status != http.StatusServiceUnavailable {
+		t.Errorf("expected items not to be created without the game data, got %d: %+v", status, body)
+	}
+	if status := request(t, s, "POST", "/api/stacks/consolidate", `{"stash":"softcore"}`, &body); status != http.StatusServiceUnavailable {
+		t.Errorf("expected stacks not to be consolidated without the game data, got %d: %+v", status, body)
+	}
+}
+
+func TestConsolidateKeepsVaultIfStashIsNotWritten(t *testing.T) {
+	t.Parallel()
+	s, file := testServer(t)
+
+	st, err := stash.ReadStash(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fur := st.Tabs[2].Items[0]
+	db := database.New([]database.Entry{{Key: fur.Base, Stats: testdb.Stats("maxStackSize", uint32(1000))}})
+	s.Resolver = resolve.New(db, arc.NewTags(nil))
+	fur.StackSize = 1
+	entry := s.Vault.Insert(vault.Entry{Item: fur, Source: file})
+
+	// Backups cannot be made into a file, so the stash is not written.
+	blocked := filepath.Join(t.TempDir(), "blocked")
+	if err := os.WriteFile(blocked, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	s.Backups.Dir = blocked
+	if status := request(t, s, "POST", "/api/stacks/consolidate", `{"stash":"softcore"}`, nil); status != http.StatusInternalServerError {
+		t.Fatalf("expected the stash not to be written, got %d", status)
+	}
+	if err := s.Vault.Save(); err != nil {
+		t.Fatal(err)
+	}
+	v, err := vault.Open(s.Vault.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Entries) != 1 || v.Entries[0].Id != entry.Id || v.Entries[0].Item.StackSize != 1 {
+		t.Errorf("expected the vault item to be kept, got %+v", v.Entries)
+	}
+
+	s.Backups.Dir = filepath.Join(t.TempDir(), "backups")
+	var res ConsolidateResult
+	if status := request(t, s, "POST", "/api/stacks/consolidate", `{"stash":"softcore"}`, &res); status != http.StatusOK {
+		t.Fatalf("unexpected status %d", status)
+	}
+	if len(res.Removed) != 1 || len(s.Vault.Entries) != 0 {
+		t.Errorf("expected the vault item to be merged into the stash, got %+v", res)
+	}
+}
+
+func TestDiscardDuplicates(t *testing.T) {
+	t.Parallel()
+	s, file := testServer(t)
+
+	st, err := stash.ReadStash(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied := s.Vault.Insert(vault.Entry{Item: st.Tabs[2].Items[5], Source: file})
+
+	var groups []dedupe.Group
+	if status := request(t, s, "GET", "/api/duplicates", "", &groups); status != http.StatusOK || len(groups) != 1 {
+		t.Fatalf("expected one group of duplicates, got %d: %+v", status, groups)
+	}
+	var result DiscardResult
+	if status := request(t, s, "POST", "/api/duplicates/discard", `{"near": false}`, &result); status != http.StatusOK {
+		t.Fatalf("unexpected status %d", status)
+	}
+	if len(result.Discarded) != 1 || result.Discarded[0].At.Kind != "stash" {
+		t.Fatalf("expected the stash copy to be discarded, got %+v", result)
+	}
+	after, err := stash.ReadStash(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after.Tabs[2].Items) != len(st.Tabs[2].Items)-1 {
+		t.Errorf("expected the copy to be taken out of the stash")
+	}
+
+	var discarded []VaultItem
+	if status := request(t, s, "GET", "/api/discarded", "", &discarded); status != http.StatusOK || len(discarded) != 1 {
+		t.Fatalf("expected one discarded item, got %d: %+v", status, discarded)
+	}
+	path := fmt.Sprintf("/api/discarded/%d/restore", discarded[0].Id)
+	if status := request(t, s, "POST", path, "", nil); status != http.StatusOK {
+		t.Errorf("unexpected status %d", status)
+	}
+	if len(s.Vault.Entries) != 2 || len(s.Vault.Discarded) != 0 || s.Vault.Entries[0].Id != copied.Id {
+		t.Errorf("expected the item to be back in the vault, got %+v", s.Vault.Entries)
+	}
+	if status := request(t, s, "POST", path, "", nil); status != http.StatusNotFound {
+		t.Errorf("expected restoring twice to fail, got %d", status)
+	}
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/kenranunderscore/grimvault/backend/locate"
+	"github.com/kenranunderscore/grimvault/backend/owned"
+	"github.com/kenranunderscore/grimvault/backend/sets"
+)
+
+// Report which pieces of item sets are owned across the vault, the transfer
+// stashes and the characters, and which are missing. Only incomplete sets are
+// returned if query parameter "incomplete" is set.
+func (s *Server) setReport(r *http.Request) (int, any, error) {
+	if s.Resolver == nil {
+		return 0, nil, errorf(http.StatusServiceUnavailable, "item sets are not available without the game data")
+	}
+	save, err := locate.ScanSaveDir(s.SaveDir)
+	if err != nil {
+		return 0, nil, err
+	}
+	// Characters the game is writing right now are left out rather than
+	// failing the whole report.
+	owned, _, err := owned.Collect(s.Vault, save)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	incomplete := r.URL.Query().Has("incomplete")
+	report := make([]sets.Completion, 0)
+	for _, c := range s.setIndex().Report(owned, s.Resolver.BaseName) {
+		if !incomplete || !c.Complete() {
+			report = append(report, c)
+		}
+	}
+	return http.StatusOK, report, nil
+}
+
+// The item sets of the game data, which must be available.
+func (s *Server) setIndex() *sets.Index {
+	if s.sets == nil {
+		s.sets = sets.NewIndex(s.Resolver)
+	}
+	return s.sets
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/kenranunderscore/grimvault/backend/editor"
+	"github.com/kenranunderscore/grimvault/backend/stacks"
+	"github.com/kenranunderscore/grimvault/backend/stashjson"
+)
+
+// Merges the stacks of the same items in a stash and the vault, only those of
+// `Base` if it is given.
+type ConsolidateRequest struct {
+	Stash string `json:"stash"`
+	Base  string `json:"base,omitempty"`
+}
+
+// How many stacks consolidating has freed, and the vault items it has
+// emptied.
+type ConsolidateResult struct {
+	Freed   int         `json:"freed"`
+	Removed []VaultItem `json:"removed"`
+}
+
+// Takes `Count` items off a stack into a new one in the same tab, at the
+// given grid position or in the first free cell.
+type SplitRequest struct {
+	Count uint32   `json:"count"`
+	X     *float32 `json:"x,omitempty"`
+	Y     *float32 `json:"y,omitempty"`
+}
+
+func (s *Server) consolidateStacks(r *http.Request) (int, any, error) {
+	var req ConsolidateRequest
+	if err := readJSON(r, &req); err != nil {
+		return 0, nil, err
+	}
+	if s.Resolver == nil {
+		return 0, nil, errorf(http.StatusServiceUnavailable, "stack sizes are not available without the game data")
+	}
+	file, st, err := s.readStash(req.Stash)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Consolidate a copy of the vault entries, which only replaces them once
+	// the stash is written; otherwise the next save of the vault would lose
+	// the items merged into the stash.
+	consolidated := *s.Vault
+	consolidated.Entries = slices.Clone(s.Vault.Entries)
+	res := stacks.Consolidate(st, &consolidated, file.Hardcore, s.Resolver.MaxStack, req.Base)
+	// The stash is written first, so that items are at worst duplicated if
+	// the vault cannot be saved, but never lost.
+	if err := s.writeStash(file, st); err != nil {
+		return 0, nil, err
+	}
+	s.Vault.Entries = consolidated.Entries
+	if err := s.Vault.Save(); err != nil {
+		return 0, nil, err
+	}
+	removed := []VaultItem{}
+	for i := range res.Removed {
+		item := s.vaultItem(&res.Removed[i], false)
+		s.texts.Remove(item.Id)
+		s.events.publish(newEvent(EventVaultRemoved, item))
+		removed = append(removed, item)
+	}
+	return http.StatusOK, ConsolidateResult{Freed: res.Freed, Removed: removed}, nil
+}
+
+func (s *Server) splitStack(r *http.Request) (int, any, error) {
+	var req SplitRequest
+	if err := readJSON(r, &req); err != nil {
+		return 0, nil, err
+	}
+	file, st, err := s.readStash(r.PathValue("stash"))
+	if err != nil {
+		return 0, nil, err
+	}
+	tab, err := tabAt(r, st)
+	if err != nil {
+		return 0, nil, err
+	}
+	i, err := pathIndex(r, "item")
+	if err != nil {
+		return 0, nil, err
+	}
+	if i >= len(tab.Items) {
+		return 0, nil, errorf(http.StatusNotFound, "item %d does not exist, the tab has %d items", i, len(tab.Items))
+	}
+
+	split, err := stacks.Split(&tab.Items[i], req.Count)
+	if err != nil {
+		return 0, nil, errorf(http.StatusBadRequest, "%v", err)
+	}
+	if err := editor.Place(tab, &split, req.X, req.Y, s.itemSize()); err != nil {
+		return 0, nil, placeError(err)
+	}
+	if err := s.writeStash(file, st); err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, ItemDetails{Item: stashjson.FromItem(&split, s.namer()), Details: s.details(&split)}, nil
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/kenranunderscore/grimvault/backend/stash"
+	"github.com/kenranunderscore/grimvault/backend/tooltip"
+)
+
+// The tooltip of an item, both as model and rendered for the frontend.
+type TooltipResponse struct {
+	Tooltip tooltip.Tooltip `json:"tooltip"`
+	Html    string          `json:"html"`
+}
+
+func (s *Server) tooltip(item *stash.Item) (TooltipResponse, error) {
+	if s.Resolver == nil {
+		return TooltipResponse{}, errorf(http.StatusServiceUnavailable, "tooltips are not available without the game data")
+	}
+	if s.tooltips == nil {
+		s.tooltips = tooltip.New(s.Resolver, s.setIndex())
+	}
+	t := s.tooltips.Build(item)
+	return TooltipResponse{Tooltip: t, Html: t.HTML()}, nil
+}
+
+func (s *Server) getStashTooltip(r *http.Request) (int, any, error) {
+	item, err := s.stashItem(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	res, err := s.tooltip(item)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, res, nil
+}
+
+func (s *Server) getVaultTooltip(r *http.Request) (int, any, error) {
+	id, err := s.vaultId(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	entry, ok := s.Vault.Get(id)
+	if !ok {
+		return 0, nil, errorf(http.StatusNotFound, "no item with id %d in the vault", id)
+	}
+	res, err := s.tooltip(&entry.Item)
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, res, nil
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/kenranunderscore/grimvault/backend/stash"
+	"github.com/kenranunderscore/grimvault/backend/validate"
+)
+
+// What is wrong with a vault item, if anything.
+type ItemCheck struct {
+	Id       uint64             `json:"id"`
+	Findings []validate.Finding `json:"findings"`
+}
+
+// The validator for the game data, which must be available.
+func (s *Server) validator() (*validate.Validator, error) {
+	if s.Resolver == nil {
+		return nil, errorf(http.StatusServiceUnavailable, "items cannot be checked without the game data")
+	}
+	if s.validate == nil {
+		ix, err := s.affixIndex()
+		if err != nil {
+			return nil, err
+		}
+		s.validate = validate.New(s.Resolver, ix)
+	}
+	return s.validate, nil
+}
+
+// Refuse to write `item` into a stash if the game would not produce it.
+// Without the game data, items cannot be checked and are written as they
+// are.
+func (s *Server) checkWritable(item *stash.Item) error {
+	if s.Resolver == nil {
+		return nil
+	}
+	v, err := s.validator()
+	if err != nil {
+		return err
+	}
+	var problems []string
+	for _, f := range v.Check(item) {
+		if f.Severity == validate.Error {
+			problems = append(problems, f.Message)
+		}
+	}
+	if len(problems) > 0 {
+		return errorf(http.StatusUnprocessableEntity, "the item cannot exist in the game (%s), force the transfer to write it anyway", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (s *Server) getVaultCheck(r *http.Request) (int, any, error) {
+	id, err := s.vaultId(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	entry, ok := s.Vault.Get(id)
+	if !ok {
+		return 0, nil, errorf(http.StatusNotFound, "no item with id %d in the vault", id)
+	}
+	v, err := s.validator()
+	if err != nil {
+		return 0, nil, err
+	}
+	findings := v.Check(&entry.Item)
+	if findings == nil {
+		findings = []validate.Finding{}
+	}
+	return http.StatusOK, ItemCheck{Id: id, Findings: findings}, nil
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/kenranunderscore/grimvault/backend/locate"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+// The last seen contents of a stash file, which changes are computed against.
+type snapshot struct {
+	stash   *stash.Stash
+	modTime time.Time
+	size    int64
+}
+
+// Read the stash `file` if it changed since its last snapshot, and publish the
+// differences. Stashes seen for the first time only serve as the baseline.
+// Files that cannot be decoded are skipped, as the game might be in the middle
+// of writing them; they are read again next time. Must be called with `s.mu`
+// held.
+func (s *Server) observe(file locate.StashFile) {
+	info, err := os.Stat(file.Path)
+	if err != nil {
+		return
+	}
+	id := file.Id()
+	old, ok := s.snapshots[id]
+	if ok && info.ModTime().Equal(old.modTime) && info.Size() == old.size {
+		return
+	}
+
+	st, err := stash.ReadStash(file.Path)
+	if err != nil {
+		return
+	}
+	s.snapshots[id] = snapshot{stash: st, modTime: info.ModTime(), size: info.Size()}
+	if ok {
+		s.events.publish(diffStash(id, old.stash, st, s.namer())...)
+	}
+}
+
+// Check all stash files of the save directory for changes. Must be called with
+// `s.mu` held.
+func (s *Server) refresh() {
+	files, err := s.stashFiles()
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		s.observe(file)
+	}
+}
+
+// Check the stash files for changes every `interval` until `ctx` is done,
+// publishing what changed to the subscribers of the event stream.
+func (s *Server) Watch(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.mu.Lock()
+		s.refresh()
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
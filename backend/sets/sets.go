@@ -0,0 +1,118 @@
+package sets
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/kenranunderscore/grimvault/backend/database"
+	"github.com/kenranunderscore/grimvault/backend/owned"
+	"github.com/kenranunderscore/grimvault/backend/resolve"
+)
+
+// An item set as defined by a record listing its members in `setMembers`.
+// Member items point back to it with `itemSetName`.
+type Set struct {
+	Record  string   `json:"record"`
+	Name    string   `json:"name"`
+	Members []string `json:"members"`
+}
+
+// All item sets of a database, and which set each item belongs to.
+type Index struct {
+	Sets     []Set
+	memberOf map[string]int
+}
+
+// Find the item sets in the database of `r`, naming them and their members
+// with its localization.
+func NewIndex(r *resolve.Resolver) *Index {
+	ix := &Index{memberOf: make(map[string]int)}
+	for _, key := range r.DB.Keys() {
+		entry := r.DB.Entries[key]
+		var members []string
+		for _, value := range entry.All("setMembers") {
+			if member, ok := value.(string); ok {
+				members = append(members, database.NormalizeKey(member))
+			}
+		}
+		if len(members) == 0 {
+			continue
+		}
+		name, _ := r.Tags.Lookup(entry.String("setName"))
+		if name == "" {
+			name = key
+		}
+		for _, member := range members {
+			ix.memberOf[member] = len(ix.Sets)
+		}
+		ix.Sets = append(ix.Sets, Set{Record: key, Name: name, Members: members})
+	}
+	return ix
+}
+
+// The set `record` belongs to.
+func (ix *Index) SetOf(record string) (*Set, bool) {
+	i, ok := ix.memberOf[database.NormalizeKey(record)]
+	if !ok {
+		return nil, false
+	}
+	return &ix.Sets[i], true
+}
+
+// A member of a set, and where the user has it.
+type Piece struct {
+	Record    string           `json:"record"`
+	Name      string           `json:"name"`
+	Locations []owned.Location `json:"locations"`
+}
+
+// How much of a set the user owns.
+type Completion struct {
+	Set     Set     `json:"set"`
+	Owned   []Piece `json:"owned"`
+	Missing []Piece `json:"missing"`
+}
+
+func (c *Completion) Complete() bool {
+	return len(c.Missing) == 0
+}
+
+// Report the completion of every set of which at least one piece is owned,
+// the most complete sets first. Pieces are named by `name`, or by their record
+// if it returns "".
+func (ix *Index) Report(items []owned.Item, name func(record string) string) []Completion {
+	found := make(map[string][]owned.Location)
+	for _, o := range items {
+		base := database.NormalizeKey(o.Item.Base)
+		if _, ok := ix.memberOf[base]; ok {
+			found[base] = append(found[base], o.At)
+		}
+	}
+
+	var report []Completion
+	for _, set := range ix.Sets {
+		c := Completion{Set: set, Owned: []Piece{}, Missing: []Piece{}}
+		for _, member := range set.Members {
+			piece := Piece{Record: member, Name: name(member), Locations: found[member]}
+			if piece.Name == "" {
+				piece.Name = member
+			}
+			if len(piece.Locations) > 0 {
+				c.Owned = append(c.Owned, piece)
+			} else {
+				piece.Locations = []owned.Location{}
+				c.Missing = append(c.Missing, piece)
+			}
+		}
+		if len(c.Owned) > 0 {
+			report = append(report, c)
+		}
+	}
+	slices.SortStableFunc(report, func(a, b Completion) int {
+		if c := cmp.Compare(len(a.Missing), len(b.Missing)); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Set.Name, b.Set.Name)
+	})
+	return report
+}
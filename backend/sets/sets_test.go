@@ -0,0 +1,86 @@
+package sets
+
+import (
+	"testing"
+
+	"github.com/kenranunderscore/grimvault/backend/arc"
+	"github.com/kenranunderscore/grimvault/backend/database"
+	"github.com/kenranunderscore/grimvault/backend/owned"
+	"github.com/kenranunderscore/grimvault/backend/resolve"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+const (
+	head  = "records/items/gearhead/d011_head.dbr"
+	chest = "records/items/gearchest/d011_chest.dbr"
+	ring  = "records/items/gearaccessories/rings/d011_ring.dbr"
+	fur   = "records/items/materia/compa_bristlyfur.dbr"
+	other = "records/items/gearhead/d012_head.dbr"
+)
+
+func testResolver() *resolve.Resolver {
+	db := database.New([]database.Entry{
+		{Key: "records/items/lootsets/itemset_d011.dbr", Stats: []database.Stat{
+			{Name: "setName", Value: "tagSetD011"},
+			{Name: "setMembers", Value: head},
+			{Name: "setMembers", Value: "Records\\Items\\GearChest\\d011_chest.dbr"},
+			{Name: "setMembers", Value: ring},
+		}},
+		{Key: "records/items/lootsets/itemset_d012.dbr", Stats: []database.Stat{
+			{Name: "setMembers", Value: other},
+		}},
+		{Key: head, Stats: []database.Stat{{Name: "itemNameTag", Value: "tagHead"}}},
+	})
+	tags := arc.NewTags([]arc.Tag{
+		{Tag: "tagSetD011", Name: "Stonebreaker's Legacy"},
+		{Tag: "tagHead", Name: "Stonebreaker Crown"},
+	})
+	return resolve.New(db, tags)
+}
+
+func TestIndex(t *testing.T) {
+	t.Parallel()
+
+	ix := NewIndex(testResolver())
+	if len(ix.Sets) != 2 {
+		t.Fatalf("expected 2 sets, got %+v", ix.Sets)
+	}
+	set, ok := ix.SetOf("records\\items\\gearchest\\D011_chest.dbr")
+	if !ok || set.Name != "Stonebreaker's Legacy" || len(set.Members) != 3 {
+		t.Errorf("unexpected set %+v", set)
+	}
+	if set, ok := ix.SetOf(other); !ok || set.Name != "records/items/lootsets/itemset_d012.dbr" {
+		t.Errorf("expected unnamed sets to be named by their record, got %+v", set)
+	}
+	if _, ok := ix.SetOf(fur); ok {
+		t.Errorf("expected %s not to belong to a set", fur)
+	}
+}
+
+func TestReport(t *testing.T) {
+	t.Parallel()
+
+	r := testResolver()
+	ix := NewIndex(r)
+	items := []owned.Item{
+		{Item: stash.Item{Base: head}, At: owned.Location{Kind: "vault", VaultId: 3}},
+		{Item: stash.Item{Base: head}, At: owned.Location{Kind: "character", Name: "Alice", Place: "equipped"}},
+		{Item: stash.Item{Base: ring}, At: owned.Location{Kind: "stash", Name: "softcore", Tab: 2}},
+		{Item: stash.Item{Base: fur}, At: owned.Location{Kind: "stash", Name: "softcore"}},
+	}
+	report := ix.Report(items, r.BaseName)
+	if len(report) != 1 {
+		t.Fatalf("expected only the set with owned pieces, got %+v", report)
+	}
+
+	c := report[0]
+	if c.Complete() || len(c.Owned) != 2 || len(c.Missing) != 1 || c.Missing[0].Record != chest {
+		t.Fatalf("unexpected completion %+v", c)
+	}
+	if c.Owned[0].Name != "Stonebreaker Crown" || len(c.Owned[0].Locations) != 2 {
+		t.Errorf("expected the head in two places, got %+v", c.Owned[0])
+	}
+	if at := c.Owned[1].Locations[0]; at != (owned.Location{Kind: "stash", Name: "softcore", Tab: 2}) {
+		t.Errorf("unexpected location %+v", at)
+	}
+}
@@ -0,0 +1,180 @@
+package sharecode
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strings"
+
+	"github.com/kenranunderscore/grimvault/backend/database"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+// Every code starts with this prefix, which also names the format version.
+const Prefix = "GV1"
+
+const formatVersion = 1
+
+var (
+	ErrInvalidCode   = errors.New("invalid item code")
+	ErrTableMismatch = errors.New("item code was created with different game data")
+	ErrUnknownRecord = errors.New("record not in the game database")
+)
+
+// The record paths of the loaded game database, which codes refer to by index.
+// Codes can thus only be decoded with the same game version and expansions
+// they have been created with; this is checked via `fingerprint`.
+type Table struct {
+	db          *database.Database
+	keys        []string
+	index       map[string]uint64
+	fingerprint uint32
+}
+
+func NewTable(db *database.Database) *Table {
+	keys := db.Keys()
+	index := make(map[string]uint64, len(keys))
+	hash := sha256.New()
+	for i, key := range keys {
+		index[key] = uint64(i)
+		hash.Write([]byte(key))
+		hash.Write([]byte{'\n'})
+	}
+	return &Table{
+		db:          db,
+		keys:        keys,
+		index:       index,
+		fingerprint: binary.LittleEndian.Uint32(hash.Sum(nil)),
+	}
+}
+
+// The record fields of an item, in the order they are encoded.
+func recordFields(item *stash.Item) []*string {
+	return []*string{
+		&item.Base,
+		&item.Prefix,
+		&item.Suffix,
+		&item.Modifier,
+		&item.Transmute,
+		&item.Material,
+		&item.RelicCompletionBonus,
+		&item.Enchantment,
+	}
+}
+
+func numberFields(item *stash.Item) []*uint32 {
+	return []*uint32{
+		&item.Seed,
+		&item.RelicSeed,
+		&item.EnchantmentSeed,
+		&item.MaterialCombines,
+		&item.StackSize,
+	}
+}
+
+// Encode `item` as a short, URL-safe text code. Its position is not part of
+// the code.
+//
+// The binary layout is: the format version, the table fingerprint, a bit mask
+// of the record fields that are set, the table index of each of those, the
+// seeds and counts as varints, and finally a CRC-32 of everything before.
+func (t *Table) Encode(item *stash.Item) (string, error) {
+	if item.Base == "" {
+		return "", fmt.Errorf("%w: item has no base record", ErrUnknownRecord)
+	}
+
+	var buf []byte
+	buf = append(buf, formatVersion)
+	buf = binary.LittleEndian.AppendUint32(buf, t.fingerprint)
+
+	var mask byte
+	var indices []uint64
+	for i, field := range recordFields(item) {
+		if *field == "" {
+			continue
+		}
+		index, ok := t.index[database.NormalizeKey(*field)]
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrUnknownRecord, *field)
+		}
+		mask |= 1 << i
+		indices = append(indices, index)
+	}
+	buf = append(buf, mask)
+	for _, index := range indices {
+		buf = binary.AppendUvarint(buf, index)
+	}
+
+	for _, field := range numberFields(item) {
+		buf = binary.AppendUvarint(buf, uint64(*field))
+	}
+
+	buf = binary.LittleEndian.AppendUint32(buf, crc32.ChecksumIEEE(buf))
+	return Prefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// Recreate the item encoded in `code`, making sure it refers to records of the
+// table's database.
+func (t *Table) Decode(code string) (stash.Item, error) {
+	var item stash.Item
+	payload, found := strings.CutPrefix(strings.TrimSpace(code), Prefix)
+	if !found {
+		return item, fmt.Errorf("%w: missing prefix %s", ErrInvalidCode, Prefix)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return item, fmt.Errorf("%w: %v", ErrInvalidCode, err)
+	}
+	if len(data) < 10 {
+		return item, fmt.Errorf("%w: too short", ErrInvalidCode)
+	}
+
+	body, checksum := data[:len(data)-4], binary.LittleEndian.Uint32(data[len(data)-4:])
+	if crc32.ChecksumIEEE(body) != checksum {
+		return item, fmt.Errorf("%w: checksum mismatch", ErrInvalidCode)
+	}
+	if body[0] != formatVersion {
+		return item, fmt.Errorf("%w: unsupported version %d", ErrInvalidCode, body[0])
+	}
+	if binary.LittleEndian.Uint32(body[1:5]) != t.fingerprint {
+		return item, ErrTableMismatch
+	}
+
+	mask := body[5]
+	r := bytes.NewReader(body[6:])
+	for i, field := range recordFields(&item) {
+		if mask&(1<<i) == 0 {
+			continue
+		}
+		index, err := binary.ReadUvarint(r)
+		if err != nil {
+			return item, fmt.Errorf("%w: %v", ErrInvalidCode, err)
+		}
+		if index >= uint64(len(t.keys)) {
+			return item, fmt.Errorf("%w: record index %d out of range", ErrInvalidCode, index)
+		}
+		*field = t.keys[index]
+	}
+	for _, field := range numberFields(&item) {
+		n, err := binary.ReadUvarint(r)
+		if err != nil || n > uint64(^uint32(0)) {
+			return item, fmt.Errorf("%w: bad number", ErrInvalidCode)
+		}
+		*field = uint32(n)
+	}
+	if r.Len() != 0 {
+		return item, fmt.Errorf("%w: trailing data", ErrInvalidCode)
+	}
+
+	if item.Base == "" {
+		return item, fmt.Errorf("%w: no base record", ErrInvalidCode)
+	}
+	if base, ok := t.db.Get(item.Base); !ok || base.String("Class") == "" {
+		return item, fmt.Errorf("%w: base record '%s' is not an item", ErrInvalidCode, item.Base)
+	}
+	return item, nil
+}
@@ -0,0 +1,106 @@
+package sharecode
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kenranunderscore/grimvault/backend/database"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+func testTable(t *testing.T) *Table {
+	db, err := database.Load("../test_data/arz/some.arz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewTable(db)
+}
+
+func TestEncodeDecode(t *testing.T) {
+	t.Parallel()
+
+	table := testTable(t)
+	item := stash.Item{
+		Base:      "records/items/gearweapons/caster/b203_scepter.dbr",
+		Modifier:  "records/skills/nonplayerskillsgdx2/bossskills/final/pet_eldritchrift.dbr",
+		Seed:      1234567890,
+		StackSize: 1,
+	}
+	item.SetPosition(3, 4)
+
+	code, err := table.Encode(&item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(code, Prefix) || len(code) > 40 {
+		t.Errorf("expected a short code with prefix, got %q", code)
+	}
+	for _, c := range code {
+		if !strings.ContainsRune("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_", c) {
+			t.Errorf("code contains character %q that is not URL-safe", c)
+		}
+	}
+
+	decoded, err := table.Decode(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item.X, item.Y = 0, 0
+	if decoded != item {
+		t.Errorf("expected %+v, got %+v", item, decoded)
+	}
+}
+
+func TestEncodeUnknownRecord(t *testing.T) {
+	t.Parallel()
+
+	table := testTable(t)
+	item := stash.Item{Base: "records/items/does/not/exist.dbr"}
+	if _, err := table.Encode(&item); !errors.Is(err, ErrUnknownRecord) {
+		t.Errorf("expected ErrUnknownRecord, got %v", err)
+	}
+}
+
+func TestDecodeCorruptCode(t *testing.T) {
+	t.Parallel()
+
+	table := testTable(t)
+	item := stash.Item{Base: "records/items/gearweapons/melee2h/b201_axe2h.dbr", Seed: 42, StackSize: 1}
+	code, err := table.Encode(&item)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flipped := []byte(code)
+	last := len(flipped) - 5
+	if flipped[last] == 'A' {
+		flipped[last] = 'B'
+	} else {
+		flipped[last] = 'A'
+	}
+	if _, err := table.Decode(string(flipped)); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("expected ErrInvalidCode for a corrupt code, got %v", err)
+	}
+	if _, err := table.Decode("hello"); !errors.Is(err, ErrInvalidCode) {
+		t.Errorf("expected ErrInvalidCode for garbage, got %v", err)
+	}
+}
+
+func TestDecodeWithOtherGameData(t *testing.T) {
+	t.Parallel()
+
+	table := testTable(t)
+	item := stash.Item{Base: "records/items/gearweapons/melee2h/b201_axe2h.dbr", StackSize: 1}
+	code, err := table.Encode(&item)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := NewTable(database.New([]database.Entry{
+		{Key: "records/items/gearweapons/melee2h/b201_axe2h.dbr", Stats: []database.Stat{{Name: "Class", Value: "WeaponHunting_Axe2h"}}},
+	}))
+	if _, err := other.Decode(code); !errors.Is(err, ErrTableMismatch) {
+		t.Errorf("expected ErrTableMismatch, got %v", err)
+	}
+}
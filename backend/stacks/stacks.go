@@ -0,0 +1,173 @@
+// Merges and splits stacks of stackable items, e.g. crafting materials and
+// components, up to the stack sizes of the game database.
+package stacks
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/kenranunderscore/grimvault/backend/database"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+	"github.com/kenranunderscore/grimvault/backend/vault"
+)
+
+// The largest stack of items of a base record, e.g. `Resolver.MaxStack`.
+type Limit func(base string) uint32
+
+// Whether `a` and `b` can share a stack: they have the same records and, for
+// partial components, the same number of pieces. Seeds do not matter, as the
+// game keeps the seed of the stack items are added to.
+func Same(a *stash.Item, b *stash.Item) bool {
+	return a.Base != "" &&
+		a.MaterialCombines == b.MaterialCombines &&
+		slices.Equal(records(a), records(b))
+}
+
+func records(item *stash.Item) []string {
+	res := []string{item.Base, item.Prefix, item.Suffix, item.Modifier, item.Transmute, item.Material, item.RelicCompletionBonus, item.Enchantment}
+	for i := range res {
+		res[i] = database.NormalizeKey(res[i])
+	}
+	return res
+}
+
+// Pour the items of later stacks into earlier ones, each up to `limit`, and
+// add the emptied ones, which are left with a size of 0, to `emptied`.
+func pour(stacks []*stash.Item, limit uint32, emptied map[*stash.Item]bool) {
+	for _, s := range stacks {
+		s.StackSize = max(s.StackSize, 1)
+	}
+	lo, hi := 0, len(stacks)-1
+	for lo < hi {
+		if stacks[lo].StackSize >= limit {
+			lo++
+			continue
+		}
+		n := min(limit-stacks[lo].StackSize, stacks[hi].StackSize)
+		stacks[lo].StackSize += n
+		stacks[hi].StackSize -= n
+		if stacks[hi].StackSize == 0 {
+			emptied[stacks[hi]] = true
+			hi--
+		}
+	}
+}
+
+// Remove the items of `items` that are in `emptied`, and return how many.
+func remove(items *[]stash.Item, emptied map[*stash.Item]bool) int {
+	kept := (*items)[:0]
+	for i := range *items {
+		if !emptied[&(*items)[i]] {
+			kept = append(kept, (*items)[i])
+		}
+	}
+	n := len(*items) - len(kept)
+	*items = kept
+	return n
+}
+
+// Group the stackable ones of `items` into stacks of the same items, in the
+// order they first appear. Items of other bases than `base` are left out,
+// unless it is empty.
+func group(items []*stash.Item, limit Limit, base string) [][]*stash.Item {
+	var groups [][]*stash.Item
+	for _, item := range items {
+		if limit(item.Base) <= 1 || (base != "" && database.NormalizeKey(item.Base) != database.NormalizeKey(base)) {
+			continue
+		}
+		i := slices.IndexFunc(groups, func(g []*stash.Item) bool { return Same(g[0], item) })
+		if i < 0 {
+			groups = append(groups, []*stash.Item{item})
+		} else {
+			groups[i] = append(groups[i], item)
+		}
+	}
+	return groups
+}
+
+// Merge the stacks of the same items in `items` up to their limit, keeping
+// the position and seed of the stacks that are filled up. Returns the items
+// without the emptied stacks.
+func Merge(items []stash.Item, limit Limit) []stash.Item {
+	ptrs := make([]*stash.Item, len(items))
+	for i := range items {
+		ptrs[i] = &items[i]
+	}
+	emptied := make(map[*stash.Item]bool)
+	for _, g := range group(ptrs, limit, "") {
+		pour(g, limit(g[0].Base), emptied)
+	}
+	remove(&items, emptied)
+	return items
+}
+
+// Take `count` items off the stack `item` into a new stack with its own seed,
+// which still needs a position.
+func Split(item *stash.Item, count uint32) (stash.Item, error) {
+	if count == 0 || count >= item.StackSize {
+		return stash.Item{}, fmt.Errorf("cannot take %d items off a stack of %d", count, item.StackSize)
+	}
+	split := *item
+	split.StackSize = count
+	split.Seed = stash.NewSeed()
+	item.StackSize -= count
+	return split, nil
+}
+
+// What consolidating stacks has changed.
+type Result struct {
+	// How many stacks have been emptied and removed.
+	Freed int `json:"freed"`
+	// The vault entries that have been emptied and removed.
+	Removed []vault.Entry `json:"removed"`
+}
+
+// Merge all stacks of the same items in the tabs of `st` and in `v`, up to
+// their limit. The stacks in the stash are merged first, so that they take as
+// little grid space as possible, and then topped up from the vault. Only
+// stacks of `base` are merged unless it is empty. Vault entries only take
+// part if they are of the same mode as the stash, given by `hardcore`.
+func Consolidate(st *stash.Stash, v *vault.Vault, hardcore bool, limit Limit, base string) Result {
+	var items []*stash.Item
+	inStash := make(map[*stash.Item]bool)
+	for i := range st.Tabs {
+		for j := range st.Tabs[i].Items {
+			items = append(items, &st.Tabs[i].Items[j])
+			inStash[&st.Tabs[i].Items[j]] = true
+		}
+	}
+	for i := range v.Entries {
+		if v.Entries[i].Hardcore == hardcore {
+			items = append(items, &v.Entries[i].Item)
+		}
+	}
+	emptied := make(map[*stash.Item]bool)
+	for _, g := range group(items, limit, base) {
+		// Stash items come first, so they are a prefix of the group.
+		n := 0
+		for n < len(g) && inStash[g[n]] {
+			n++
+		}
+		pour(g[:n], limit(g[0].Base), emptied)
+		rest := slices.DeleteFunc(g, func(item *stash.Item) bool { return emptied[item] })
+		pour(rest, limit(rest[0].Base), emptied)
+	}
+
+	var res Result
+	var ids []uint64
+	for i := range v.Entries {
+		if emptied[&v.Entries[i].Item] {
+			ids = append(ids, v.Entries[i].Id)
+		}
+	}
+	for i := range st.Tabs {
+		res.Freed += remove(&st.Tabs[i].Items, emptied)
+	}
+	for _, id := range ids {
+		if entry, ok := v.Remove(id); ok {
+			res.Removed = append(res.Removed, entry)
+			res.Freed++
+		}
+	}
+	return res
+}
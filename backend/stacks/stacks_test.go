@@ -0,0 +1,112 @@
+package stacks
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/kenranunderscore/grimvault/backend/database"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+	"github.com/kenranunderscore/grimvault/backend/vault"
+)
+
+const (
+	fur   = "records/items/materia/compa_bristlyfur.dbr"
+	scrap = "records/items/crafting/materials/craft_scrapmetal.dbr"
+	ring  = "records/items/gearaccessories/rings/a001_ring.dbr"
+)
+
+func limit(base string) uint32 {
+	switch database.NormalizeKey(base) {
+	case fur:
+		return 100
+	case scrap:
+		return 10
+	}
+	return 1
+}
+
+func sizes(items []stash.Item) []uint32 {
+	var res []uint32
+	for _, item := range items {
+		res = append(res, item.StackSize)
+	}
+	return res
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+
+	items := []stash.Item{
+		{Base: scrap, StackSize: 6, Seed: 1},
+		{Base: fur, StackSize: 3},
+		{Base: ring, StackSize: 0},
+		{Base: scrap, StackSize: 7, Seed: 2},
+		{Base: ring, StackSize: 0},
+		{Base: fur, StackSize: 4, MaterialCombines: 2},
+		{Base: "Records\\Items\\Crafting\\Materials\\craft_scrapmetal.dbr", StackSize: 2, Seed: 3},
+	}
+	merged := Merge(items, limit)
+	if expected := []uint32{10, 3, 0, 5, 0, 4}; !slices.Equal(sizes(merged), expected) {
+		t.Fatalf("expected stack sizes %v, got %+v", expected, merged)
+	}
+	if merged[0].Seed != 1 || merged[3].Seed != 2 {
+		t.Errorf("expected the filled up stacks to keep their seeds, got %+v", merged)
+	}
+}
+
+func TestSplit(t *testing.T) {
+	t.Parallel()
+
+	item := stash.Item{Base: fur, StackSize: 10, Seed: 5}
+	split, err := Split(&item, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.StackSize != 6 || split.StackSize != 4 || split.Base != fur || split.Seed == 5 {
+		t.Errorf("expected stacks of 6 and 4 with their own seeds, got %+v and %+v", item, split)
+	}
+	for _, count := range []uint32{0, 6} {
+		if _, err := Split(&item, count); err == nil {
+			t.Errorf("expected taking %d off a stack of 6 to fail", count)
+		}
+	}
+}
+
+func TestConsolidate(t *testing.T) {
+	t.Parallel()
+
+	st := &stash.Stash{Tabs: []stash.StashTab{
+		{Items: []stash.Item{{Base: fur, StackSize: 90}, {Base: scrap, StackSize: 4}}},
+		{Items: []stash.Item{{Base: ring}, {Base: fur, StackSize: 5}}},
+	}}
+	v, err := vault.Open(filepath.Join(t.TempDir(), "vault.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := v.Add(stash.Item{Base: fur, StackSize: 8}, "test")
+	b := v.Add(stash.Item{Base: scrap, StackSize: 3}, "test")
+	c := v.Insert(vault.Entry{Item: stash.Item{Base: fur, StackSize: 1}, Hardcore: true})
+
+	res := Consolidate(st, v, false, limit, fur)
+	if res.Freed != 1 || len(res.Removed) != 0 {
+		t.Errorf("expected one stash stack to be freed, got %+v", res)
+	}
+	if expected := []uint32{100, 4}; !slices.Equal(sizes(st.Tabs[0].Items), expected) {
+		t.Errorf("expected the first stack to be filled up, got %+v", st.Tabs[0].Items)
+	}
+	if expected := []uint32{0}; !slices.Equal(sizes(st.Tabs[1].Items), expected) {
+		t.Errorf("expected only the ring to be left in the second tab, got %+v", st.Tabs[1].Items)
+	}
+	if entry, _ := v.Get(a.Id); entry.Item.StackSize != 3 {
+		t.Errorf("expected the rest of the fur in the vault, got %+v", entry)
+	}
+
+	res = Consolidate(st, v, false, limit, "")
+	if res.Freed != 1 || len(res.Removed) != 1 || res.Removed[0].Id != b.Id || st.Tabs[0].Items[1].StackSize != 7 {
+		t.Errorf("expected the vault scrap to be moved into the stash, got %+v", res)
+	}
+	if entry, ok := v.Get(c.Id); !ok || entry.Item.StackSize != 1 {
+		t.Errorf("expected hardcore items to be left alone, got %+v", entry)
+	}
+}
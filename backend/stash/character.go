@@ -0,0 +1,216 @@
+package stash
+
+import (
+	"fmt"
+
+	"github.com/kenranunderscore/grimvault/backend/rawreader"
+)
+
+// The items of a character save file (`player.gdc`), which is encrypted the
+// same way as transfer stashes. Only the header and the blocks holding items
+// are decoded.
+type Character struct {
+	Name     string
+	Class    string
+	Level    uint32
+	Hardcore bool
+	// The items of each inventory bag, the main inventory first. Their `X`
+	// and `Y` are grid cells, not the raw bits of floats.
+	Bags [][]Item
+	// The equipped items, including both weapon sets. Empty slots are left
+	// out.
+	Equipped []Item
+	// The tabs of the character's personal stash.
+	Stash []StashTab
+}
+
+const (
+	characterMagic = 0x58434447 // "GDCX"
+	// Blocks are stored in this order, and the items are in the last two.
+	infoBlock      = 1
+	bioBlock       = 2
+	inventoryBlock = 3
+	stashBlock     = 4
+	// The number of equipment slots before the two weapon sets.
+	equipmentSlots = 12
+)
+
+// A string of UTF-16 code units, as used for character names.
+func (d *decoder) readWideString() string {
+	length := d.readUint()
+	runes := make([]rune, 0, length)
+	for range length {
+		lo := d.readByte()
+		hi := d.readByte()
+		runes = append(runes, rune(uint16(lo)|uint16(hi)<<8))
+	}
+	return string(runes)
+}
+
+// Skip the rest of `block`, updating the key as if it had been read. Only
+// valid for blocks without nested blocks, whose lengths do not update the key.
+func (d *decoder) skipBlock(block block) {
+	for _, b := range d.reader.Bytes(block.end - d.cursor()) {
+		d.key ^= d.keyTable[b]
+	}
+}
+
+// Items in character files lack the stash position; it is stored after them
+// in whatever form the containing block uses.
+func (d *decoder) readCharacterItem() Item {
+	var item Item
+	_, item.Base = d.readString()
+	_, item.Prefix = d.readString()
+	_, item.Suffix = d.readString()
+	_, item.Modifier = d.readString()
+	_, item.Transmute = d.readString()
+	item.Seed = d.readUint()
+	_, item.Material = d.readString()
+	_, item.RelicCompletionBonus = d.readString()
+	item.RelicSeed = d.readUint()
+	_, item.Enchantment = d.readString()
+	_ = d.readUint()
+	item.EnchantmentSeed = d.readUint()
+	item.MaterialCombines = d.readUint()
+	item.StackSize = d.readUint()
+	return item
+}
+
+func (d *decoder) readEquipment(c *Character, count int) {
+	for range count {
+		item := d.readCharacterItem()
+		_ = d.readByte() // whether the slot is in use
+		if item.Base != "" {
+			c.Equipped = append(c.Equipped, item)
+		}
+	}
+}
+
+func (d *decoder) readInventory(c *Character) error {
+	block := d.readBlock()
+	if block.result != inventoryBlock {
+		return fmt.Errorf("expected inventory block %d, got %d", inventoryBlock, block.result)
+	}
+	if version := d.readUint(); version != 4 {
+		return fmt.Errorf("unsupported inventory version %d", version)
+	}
+	if d.readBool() {
+		bagCount := d.readUint()
+		_ = d.readUint() // focused bag
+		_ = d.readUint() // selected bag
+		for range bagCount {
+			bag := d.readBlock()
+			_ = d.readBool()
+			itemCount := d.readUint()
+			items := make([]Item, 0, itemCount)
+			for range itemCount {
+				item := d.readCharacterItem()
+				item.X = d.readUint()
+				item.Y = d.readUint()
+				items = append(items, item)
+			}
+			if err := d.readBlockEnd(bag); err != nil {
+				return err
+			}
+			c.Bags = append(c.Bags, items)
+		}
+		_ = d.readBool() // which weapon set is used
+		d.readEquipment(c, equipmentSlots)
+		for range 2 {
+			_ = d.readBool()
+			d.readEquipment(c, 2)
+		}
+	}
+	return d.readBlockEnd(block)
+}
+
+func (d *decoder) readCharacterStash(c *Character) error {
+	block := d.readBlock()
+	if block.result != stashBlock {
+		return fmt.Errorf("expected stash block %d, got %d", stashBlock, block.result)
+	}
+	version := d.readUint()
+	if version != 5 && version != 6 {
+		return fmt.Errorf("unsupported stash version %d", version)
+	}
+	tabCount := uint32(1)
+	if version >= 6 {
+		tabCount = d.readUint()
+	}
+	for range tabCount {
+		tab, err := d.readStashTab()
+		if err != nil {
+			return err
+		}
+		c.Stash = append(c.Stash, tab)
+	}
+	return d.readBlockEnd(block)
+}
+
+func (d *decoder) readCharacter() (*Character, error) {
+	if magic := d.readUint(); magic != characterMagic {
+		return nil, fmt.Errorf("not a character file, got magic %#x", magic)
+	}
+	headerVersion := d.readUint()
+	if headerVersion != 1 && headerVersion != 2 {
+		return nil, fmt.Errorf("unsupported header version %d", headerVersion)
+	}
+
+	c := &Character{Name: d.readWideString()}
+	_ = d.readByte() // sex
+	_, c.Class = d.readString()
+	c.Level = d.readUint()
+	c.Hardcore = d.readBool()
+	if headerVersion >= 2 {
+		_ = d.readByte() // expansions
+	}
+
+	if zero := d.readUintEx(false); zero != 0 {
+		return nil, fmt.Errorf("expected literal 0, got %d", zero)
+	}
+	if version := d.readUint(); version < 6 || version > 8 {
+		return nil, fmt.Errorf("unsupported character version %d", version)
+	}
+	for range 16 {
+		_ = d.readByte() // unique id
+	}
+
+	for _, expected := range []uint32{infoBlock, bioBlock} {
+		block := d.readBlock()
+		if block.result != expected {
+			return nil, fmt.Errorf("expected block %d, got %d", expected, block.result)
+		}
+		d.skipBlock(block)
+		if err := d.readBlockEnd(block); err != nil {
+			return nil, err
+		}
+	}
+	if err := d.readInventory(c); err != nil {
+		return nil, fmt.Errorf("could not read inventory: %w", err)
+	}
+	if err := d.readCharacterStash(c); err != nil {
+		return nil, fmt.Errorf("could not read personal stash: %w", err)
+	}
+	return c, nil
+}
+
+// Read the items of the character save file `file`.
+func ReadCharacter(file string) (c *Character, err error) {
+	reader, err := rawreader.FromFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not open character file '%s': %w", file, err)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			c, err = nil, fmt.Errorf("%w: truncated or corrupt data: %v", ErrInvalid, r)
+		}
+	}()
+	key, keyTable := readKeyTable(reader)
+	d := &decoder{reader: reader, key: key, keyTable: &keyTable}
+	c, err = d.readCharacter()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	return c, nil
+}
@@ -0,0 +1,153 @@
+package stash
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func (e *encoder) writeCharacterItem(item *Item) {
+	e.writeString(item.Base)
+	e.writeString(item.Prefix)
+	e.writeString(item.Suffix)
+	e.writeString(item.Modifier)
+	e.writeString(item.Transmute)
+	e.writeUint(item.Seed)
+	e.writeString(item.Material)
+	e.writeString(item.RelicCompletionBonus)
+	e.writeUint(item.RelicSeed)
+	e.writeString(item.Enchantment)
+	e.writeUint(0)
+	e.writeUint(item.EnchantmentSeed)
+	e.writeUint(item.MaterialCombines)
+	e.writeUint(item.StackSize)
+}
+
+func (e *encoder) writeEquipment(items []Item, count int) {
+	for i := range count {
+		var item Item
+		if i < len(items) {
+			item = items[i]
+		}
+		e.writeCharacterItem(&item)
+		e.writeByte(1)
+	}
+}
+
+// Encode the parts of a character file `ReadCharacter` looks at, with some
+// filler in the blocks it skips.
+func encodeCharacter(c *Character) []byte {
+	e := newEncoder(0x12345678)
+	e.writeUint(characterMagic)
+	e.writeUint(2)
+	e.writeUint(uint32(len([]rune(c.Name))))
+	for _, r := range c.Name {
+		e.writeByte(byte(r))
+		e.writeByte(byte(r >> 8))
+	}
+	e.writeByte(1)
+	e.writeString(c.Class)
+	e.writeUint(c.Level)
+	hardcore := byte(0)
+	if c.Hardcore {
+		hardcore = 1
+	}
+	e.writeByte(hardcore)
+	e.writeByte(3)
+	e.writeUintEx(0, false)
+	e.writeUint(8)
+	for i := range 16 {
+		e.writeByte(byte(i))
+	}
+
+	for _, id := range []uint32{infoBlock, bioBlock} {
+		block := e.writeBlockStart(id)
+		e.writeUint(5)
+		e.writeString("filler")
+		e.writeByte(7)
+		e.writeBlockEnd(block)
+	}
+
+	inventory := e.writeBlockStart(inventoryBlock)
+	e.writeUint(4)
+	e.writeByte(1)
+	e.writeUint(uint32(len(c.Bags)))
+	e.writeUint(0)
+	e.writeUint(0)
+	for _, bag := range c.Bags {
+		block := e.writeBlockStart(0)
+		e.writeByte(0)
+		e.writeUint(uint32(len(bag)))
+		for i := range bag {
+			e.writeCharacterItem(&bag[i])
+			e.writeUint(bag[i].X)
+			e.writeUint(bag[i].Y)
+		}
+		e.writeBlockEnd(block)
+	}
+	e.writeByte(0)
+	e.writeEquipment(c.Equipped, equipmentSlots)
+	for i := range 2 {
+		e.writeByte(0)
+		e.writeEquipment(c.Equipped[min(len(c.Equipped), equipmentSlots+2*i):], 2)
+	}
+	e.writeBlockEnd(inventory)
+
+	personal := e.writeBlockStart(stashBlock)
+	e.writeUint(6)
+	e.writeUint(uint32(len(c.Stash)))
+	for i := range c.Stash {
+		e.writeStashTab(&c.Stash[i])
+	}
+	e.writeBlockEnd(personal)
+	return e.data
+}
+
+func TestReadCharacter(t *testing.T) {
+	t.Parallel()
+
+	expected := &Character{
+		Name:     "Élodie",
+		Class:    "tagSkillClassName01",
+		Level:    94,
+		Hardcore: true,
+		Bags: [][]Item{
+			{{Base: "records/items/gearhead/a.dbr", Seed: 1, StackSize: 1, X: 2, Y: 3}},
+			{{Base: "records/items/materia/compa_bristlyfur.dbr", StackSize: 5}},
+		},
+		Equipped: []Item{
+			{Base: "records/items/gearhead/b.dbr", Prefix: "records/items/lootaffixes/prefix/p.dbr", StackSize: 1},
+			{Base: "records/items/gearweapons/c.dbr", Enchantment: "records/items/enchants/e.dbr", StackSize: 1},
+		},
+		Stash: []StashTab{
+			{Width: 8, Height: 16, Items: []Item{{Base: "records/items/gearrelic/d.dbr", StackSize: 1}}},
+			{Width: 8, Height: 16, Items: []Item{}},
+		},
+	}
+	file := filepath.Join(t.TempDir(), "player.gdc")
+	if err := os.WriteFile(file, encodeCharacter(expected), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := ReadCharacter(file)
+	if err != nil {
+		t.Fatalf("could not read character: %v", err)
+	}
+	for i := range c.Stash {
+		c.Stash[i].Block = block{}
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Errorf("expected %+v, got %+v", expected, c)
+	}
+}
+
+func TestReadCharacterRejectsStashes(t *testing.T) {
+	t.Parallel()
+
+	_, err := ReadCharacter("../test_data/stashes/transfer.gst")
+	if !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected an invalid data error, got %v", err)
+	}
+}
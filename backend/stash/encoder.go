@@ -0,0 +1,159 @@
+package stash
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// The mirror image of `decoder`: every value is XORed with the current key,
+// and the key is updated with the bytes that end up in the file.
+type encoder struct {
+	data     []byte
+	key      uint32
+	keyTable *[TableLength]uint32
+}
+
+func newEncoder(key uint32) *encoder {
+	const XorKey uint32 = 1431655765
+	var raw [4]byte
+	binary.LittleEndian.PutUint32(raw[:], key^XorKey)
+	table := keyTableFor(key)
+	return &encoder{
+		data:     raw[:],
+		key:      key,
+		keyTable: &table,
+	}
+}
+
+func (e *encoder) writeUintEx(n uint32, updateKey bool) {
+	encoded := n ^ e.key
+	start := len(e.data)
+	e.data = binary.LittleEndian.AppendUint32(e.data, encoded)
+	if updateKey {
+		for _, b := range e.data[start:] {
+			e.key ^= e.keyTable[b]
+		}
+	}
+}
+
+func (e *encoder) writeUint(n uint32) {
+	e.writeUintEx(n, true)
+}
+
+func (e *encoder) writeByte(b byte) {
+	encoded := byte(uint32(b) ^ e.key)
+	e.data = append(e.data, encoded)
+	e.key ^= e.keyTable[encoded]
+}
+
+func (e *encoder) writeString(s string) {
+	e.writeUint(uint32(len(s)))
+	for i := range len(s) {
+		e.writeByte(s[i])
+	}
+}
+
+// A block whose length is only known once its contents have been written.
+type openBlock struct {
+	lengthAt uint32
+	key      uint32
+	start    uint32
+}
+
+func (e *encoder) writeBlockStart(result uint32) openBlock {
+	e.writeUint(result)
+	b := openBlock{lengthAt: uint32(len(e.data)), key: e.key}
+	// The length does not influence the key, so it can be patched in later.
+	e.writeUintEx(0, false)
+	b.start = uint32(len(e.data))
+	return b
+}
+
+func (e *encoder) writeBlockEnd(b openBlock) {
+	length := uint32(len(e.data)) - b.start
+	binary.LittleEndian.PutUint32(e.data[b.lengthAt:], length^b.key)
+	e.writeUintEx(0, false)
+}
+
+func (e *encoder) writeItem(item *Item) {
+	e.writeString(item.Base)
+	e.writeString(item.Prefix)
+	e.writeString(item.Suffix)
+	e.writeString(item.Modifier)
+	e.writeString(item.Transmute)
+	e.writeUint(item.Seed)
+	e.writeString(item.Material)
+	e.writeString(item.RelicCompletionBonus)
+	e.writeUint(item.RelicSeed)
+	e.writeString(item.Enchantment)
+	e.writeUint(0)
+	e.writeUint(item.EnchantmentSeed)
+	e.writeUint(item.MaterialCombines)
+	e.writeUint(item.StackSize)
+	e.writeUint(item.X)
+	e.writeUint(item.Y)
+}
+
+func (e *encoder) writeStashTab(tab *StashTab) {
+	block := e.writeBlockStart(tab.Block.result)
+	e.writeUint(tab.Width)
+	e.writeUint(tab.Height)
+	e.writeUint(uint32(len(tab.Items)))
+	for i := range tab.Items {
+		e.writeItem(&tab.Items[i])
+	}
+	e.writeBlockEnd(block)
+}
+
+func encode(st *Stash, key uint32) []byte {
+	e := newEncoder(key)
+	e.writeUint(2)
+	mainBlock := e.writeBlockStart(18)
+	e.writeUint(st.Version)
+	e.writeUintEx(0, false)
+	e.writeString(st.Mod)
+	if st.Version >= 5 {
+		e.writeByte(st.Expansions)
+	}
+	e.writeUint(uint32(len(st.Tabs)))
+	for i := range st.Tabs {
+		e.writeStashTab(&st.Tabs[i])
+	}
+	e.writeBlockEnd(mainBlock)
+	return e.data
+}
+
+// Encode `st` in the game's transfer stash format, using a random key.
+func Encode(st *Stash) ([]byte, error) {
+	var raw [4]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return nil, fmt.Errorf("could not generate stash key: %w", err)
+	}
+	return encode(st, binary.LittleEndian.Uint32(raw[:])), nil
+}
+
+// Write `st` to `file`. The data is written to a temporary file first and then
+// renamed, so the game never sees a partially written stash.
+func WriteStash(file string, st *Stash) error {
+	data, err := Encode(st)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("could not write stash file '%s': %w", file, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("could not write stash file '%s': %w", file, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("could not write stash file '%s': %w", file, err)
+	}
+	return os.Rename(tmp.Name(), file)
+}
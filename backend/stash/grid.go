@@ -0,0 +1,95 @@
+package stash
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Wrapped by the errors of items that do not fit where they are put.
+var ErrNoRoom = errors.New("no room for the item")
+
+// How many grid cells an item takes across and down.
+type Size func(item *Item) (uint32, uint32)
+
+// Let every item take a single cell, for when item sizes are not known.
+func SingleCell(*Item) (uint32, uint32) {
+	return 1, 1
+}
+
+// The grid cell of the top left corner of the item.
+func (item *Item) cell() (uint32, uint32) {
+	x, y := item.Position()
+	return uint32(max(x, 0)), uint32(max(y, 0))
+}
+
+// Which cells of the tab its items take, row by row.
+func (tab *StashTab) taken(size Size) []bool {
+	cells := make([]bool, tab.Width*tab.Height)
+	for i := range tab.Items {
+		x, y := tab.Items[i].cell()
+		w, h := size(&tab.Items[i])
+		for row := y; row < min(y+h, tab.Height); row++ {
+			for col := x; col < min(x+w, tab.Width); col++ {
+				cells[row*tab.Width+col] = true
+			}
+		}
+	}
+	return cells
+}
+
+// Whether a `w`x`h` item at cell (`x`, `y`) is within the tab and takes none
+// of the `taken` cells.
+func (tab *StashTab) free(taken []bool, x uint32, y uint32, w uint32, h uint32) bool {
+	if x+w > tab.Width || y+h > tab.Height {
+		return false
+	}
+	for row := y; row < y+h; row++ {
+		for col := x; col < x+w; col++ {
+			if taken[row*tab.Width+col] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Check that `item` fits into the tab at its position: within the tab and
+// on none of its items.
+func (tab *StashTab) Fits(item *Item, size Size) error {
+	px, py := item.Position()
+	w, h := size(item)
+	x, y := item.cell()
+	if px < 0 || py < 0 || x+w > tab.Width || y+h > tab.Height {
+		return fmt.Errorf("%w: a %dx%d item at (%v, %v) is outside of the %dx%d tab", ErrNoRoom, w, h, px, py, tab.Width, tab.Height)
+	}
+	if !tab.free(tab.taken(size), x, y, w, h) {
+		return fmt.Errorf("%w: (%v, %v) is taken by another item", ErrNoRoom, px, py)
+	}
+	return nil
+}
+
+// Put a copy of `item` into the tab at its position, if it `Fits` there.
+func (tab *StashTab) Put(item *Item, size Size) error {
+	if err := tab.Fits(item, size); err != nil {
+		return err
+	}
+	tab.Items = append(tab.Items, *item)
+	return nil
+}
+
+// Move `item` to the first free cell of the tab, row by row, and put a copy
+// of it there.
+func (tab *StashTab) PutFirstFree(item *Item, size Size) error {
+	w, h := size(item)
+	taken := tab.taken(size)
+	for y := range tab.Height {
+		for x := range tab.Width {
+			if tab.free(taken, x, y, w, h) {
+				item.SetPosition(float32(x), float32(y))
+				tab.Items = append(tab.Items, *item)
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: the %dx%d tab has no room for a %dx%d item", ErrNoRoom, tab.Width, tab.Height, w, h)
+}
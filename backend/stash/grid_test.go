@@ -0,0 +1,52 @@
+package stash
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPut(t *testing.T) {
+	t.Parallel()
+
+	size := func(item *Item) (uint32, uint32) {
+		if item.Base == "sword" {
+			return 1, 3
+		}
+		return 1, 1
+	}
+	tab := StashTab{Width: 3, Height: 3}
+	sword := Item{Base: "sword"}
+	sword.SetPosition(1, 0)
+	if err := tab.Put(&sword, size); err != nil {
+		t.Fatal(err)
+	}
+
+	ring := Item{Base: "ring"}
+	for _, at := range [][2]float32{{1, 2}, {3, 0}, {0, 3}, {-1, 0}} {
+		ring.SetPosition(at[0], at[1])
+		if err := tab.Put(&ring, size); !errors.Is(err, ErrNoRoom) {
+			t.Errorf("expected no room at %v, got %v", at, err)
+		}
+	}
+	ring.SetPosition(2, 2)
+	if err := tab.Put(&ring, size); err != nil {
+		t.Fatal(err)
+	}
+
+	other := Item{Base: "sword"}
+	if err := tab.PutFirstFree(&other, size); err != nil {
+		t.Fatal(err)
+	}
+	if x, y := other.Position(); x != 0 || y != 0 {
+		t.Errorf("expected the first free cell to be (0, 0), got (%v, %v)", x, y)
+	}
+	if err := tab.PutFirstFree(&other, size); !errors.Is(err, ErrNoRoom) {
+		t.Errorf("expected no room for a third sword, got %v", err)
+	}
+	if err := tab.PutFirstFree(&ring, size); err != nil {
+		t.Fatal(err)
+	}
+	if x, y := ring.Position(); x != 2 || y != 0 || len(tab.Items) != 4 {
+		t.Errorf("expected the ring at (2, 0), got (%v, %v)", x, y)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"math"
+	"math/rand/v2"
 	"strconv"
 	"strings"
 
@@ -12,6 +14,10 @@ import (
 
 const TableLength = 256
 
+// Wrapped by all errors about the contents of a stash file, as opposed to
+// errors reading the file in the first place.
+var ErrInvalid = errors.New("invalid stash data")
+
 type decoder struct {
 	reader   *rawreader.T
 	key      uint32
@@ -31,31 +37,21 @@ func decodeKey(r *rawreader.T) uint32 {
 	return res ^ XorKey
 }
 
-func readKeyTable(r *rawreader.T) (uint32, [TableLength]uint32) {
+func keyTableFor(key uint32) [TableLength]uint32 {
 	const Prime uint32 = 39916801
 	var res [TableLength]uint32
-	key := decodeKey(r)
 	x := key
 	for i := range TableLength {
 		x = x>>1 | x<<31
 		x *= Prime
 		res[i] = x
 	}
-	return key, res
+	return res
 }
 
-func newDecoder(file string) (*decoder, error) {
-	reader, err := rawreader.FromFile(file)
-	if err != nil {
-		return nil, fmt.Errorf("cannot create stash decoder: %w", err)
-	}
-
-	key, keyTable := readKeyTable(reader)
-	return &decoder{
-		reader:   reader,
-		key:      key,
-		keyTable: &keyTable,
-	}, nil
+func readKeyTable(r *rawreader.T) (uint32, [TableLength]uint32) {
+	key := decodeKey(r)
+	return key, keyTableFor(key)
 }
 
 func (d *decoder) decodeEx(encoded uint32, updateKey bool) uint32 {
@@ -84,12 +80,16 @@ func (d *decoder) readUint() uint32 {
 	return d.readUintEx(true)
 }
 
-func (d *decoder) readBool() bool {
+func (d *decoder) readByte() byte {
 	b := d.reader.Byte()
 	// FIXME: consolidate with `DecodeEx`
 	n := byte(uint32(b) ^ d.key)
 	d.key ^= d.keyTable[b]
-	return n == 1
+	return n
+}
+
+func (d *decoder) readBool() bool {
+	return d.readByte() == 1
 }
 
 type block struct {
@@ -180,6 +180,33 @@ func (item *Item) Pretty() string {
 	return b.String()
 }
 
+// The position of the item within its tab. The game stores the coordinates as
+// floats, and `X` and `Y` hold their raw bits.
+func (item *Item) Position() (float32, float32) {
+	return math.Float32frombits(item.X), math.Float32frombits(item.Y)
+}
+
+func (item *Item) SetPosition(x float32, y float32) {
+	item.X = math.Float32bits(x)
+	item.Y = math.Float32bits(y)
+}
+
+// A random seed, as the game rolls them: positive 32-bit integers.
+func NewSeed() uint32 {
+	return uint32(rand.Int32N(math.MaxInt32)) + 1
+}
+
+// All non-empty record paths the item refers to, starting with its base.
+func (item *Item) Records() []string {
+	var records []string
+	for _, record := range []string{item.Base, item.Prefix, item.Suffix, item.Modifier, item.Transmute, item.Material, item.RelicCompletionBonus, item.Enchantment} {
+		if record != "" {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
 func (d *decoder) readItem() (Item, error) {
 	err, base := d.readString()
 	err, prefix := d.readString()
@@ -250,15 +277,36 @@ func (d *decoder) readStashTab() (StashTab, error) {
 }
 
 type Stash struct {
-	Tabs []StashTab
+	Version uint32
+	Mod     string
+	// Which expansions the stash has been saved with. This is not a bool as
+	// initially thought: stashes saved with both expansions contain 3.
+	Expansions byte
+	Tabs       []StashTab
 }
 
-func ReadStash(file string) (*Stash, error) {
-	d, err := newDecoder(file)
+func ReadStash(file string) (st *Stash, err error) {
+	reader, err := rawreader.FromFile(file)
 	if err != nil {
 		return nil, fmt.Errorf("could not open stash file '%s': %w", file, err)
 	}
 
+	// The decoder does not check bounds, so truncated files make it panic.
+	defer func() {
+		if r := recover(); r != nil {
+			st, err = nil, fmt.Errorf("%w: truncated or corrupt data: %v", ErrInvalid, r)
+		}
+	}()
+	key, keyTable := readKeyTable(reader)
+	d := &decoder{reader: reader, key: key, keyTable: &keyTable}
+	st, err = d.readStash()
+	if err != nil {
+		return st, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	return st, nil
+}
+
+func (d *decoder) readStash() (*Stash, error) {
 	if x := d.readUint(); x != 2 {
 		return nil, fmt.Errorf("expected literal 2, got %d", x)
 	}
@@ -273,15 +321,23 @@ func ReadStash(file string) (*Stash, error) {
 		return nil, fmt.Errorf("expected literal 0, got %d", zero)
 	}
 
-	d.readString()
+	err, mod := d.readString()
+	if err != nil {
+		return nil, fmt.Errorf("could not read mod name: %w", err)
+	}
 
+	var expansions byte
 	if version >= 5 {
-		// is it an expansion stash file?
-		d.readBool()
+		expansions = d.readByte()
 	}
 
 	tabCount := d.readUint()
-	stash := Stash{Tabs: make([]StashTab, 0, tabCount)}
+	stash := Stash{
+		Version:    version,
+		Mod:        mod,
+		Expansions: expansions,
+		Tabs:       make([]StashTab, 0, tabCount),
+	}
 	for i := range tabCount {
 		tab, err := d.readStashTab()
 		if err != nil {
@@ -1,9 +1,14 @@
 package stash
 
 import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/kenranunderscore/grimvault/backend/golden"
+	"github.com/kenranunderscore/grimvault/backend/rawreader"
 )
 
 func TestDecodeEmptyStashFile(t *testing.T) {
@@ -36,3 +41,61 @@ func TestDecodeNonEmptyStashFile(t *testing.T) {
 		return stash
 	})
 }
+
+func TestEncodeReproducesStashFiles(t *testing.T) {
+	t.Parallel()
+
+	for _, file := range []string{"../test_data/stashes/transfer.gst", "../test_data/stashes/transfer_empty.gst"} {
+		original, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stash, err := ReadStash(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		key := decodeKey(rawreader.New(original))
+		if encoded := encode(stash, key); !bytes.Equal(encoded, original) {
+			t.Errorf("%s: encoded stash differs from the original", file)
+		}
+	}
+}
+
+func TestWriteStashRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	stash, err := ReadStash("../test_data/stashes/transfer.gst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stash.Tabs[0].Items = append(stash.Tabs[0].Items, Item{Base: "records/items/materia/compa_bristlyfur.dbr", StackSize: 3})
+
+	file := filepath.Join(t.TempDir(), "transfer.gst")
+	if err := WriteStash(file, stash); err != nil {
+		t.Fatal(err)
+	}
+	written, err := ReadStash(file)
+	if err != nil {
+		t.Fatalf("could not read written stash: %v", err)
+	}
+	if n := len(written.Tabs[0].Items); n != len(stash.Tabs[0].Items) {
+		t.Errorf("expected %d items in first tab, got %d", len(stash.Tabs[0].Items), n)
+	}
+}
+
+func TestReadTruncatedStash(t *testing.T) {
+	t.Parallel()
+
+	data, err := os.ReadFile("../test_data/stashes/transfer.gst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "transfer.gst")
+	if err := os.WriteFile(file, data[:len(data)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadStash(file); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected an invalid stash error, got %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package stashjson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+// The version of the JSON schema. It is increased whenever a change would
+// break existing consumers; adding optional fields does not count as such.
+const SchemaVersion = 1
+
+// The top-level JSON object describing a transfer stash.
+type Document struct {
+	Schema     int    `json:"schema"`
+	Version    uint32 `json:"version"`
+	Mod        string `json:"mod,omitempty"`
+	Expansions byte   `json:"expansions"`
+	Tabs       []Tab  `json:"tabs"`
+}
+
+type Tab struct {
+	Width  uint32 `json:"width"`
+	Height uint32 `json:"height"`
+	Items  []Item `json:"items"`
+}
+
+// A single item. All record fields hold database record paths. `X` and `Y` are
+// grid coordinates within the tab. `Name` is informational only and ignored
+// when importing.
+type Item struct {
+	Name                 string  `json:"name,omitempty"`
+	Base                 string  `json:"base"`
+	Prefix               string  `json:"prefix,omitempty"`
+	Suffix               string  `json:"suffix,omitempty"`
+	Modifier             string  `json:"modifier,omitempty"`
+	Transmute            string  `json:"transmute,omitempty"`
+	Material             string  `json:"material,omitempty"`
+	RelicCompletionBonus string  `json:"relicCompletionBonus,omitempty"`
+	Enchantment          string  `json:"enchantment,omitempty"`
+	Seed                 uint32  `json:"seed"`
+	RelicSeed            uint32  `json:"relicSeed,omitempty"`
+	EnchantmentSeed      uint32  `json:"enchantmentSeed,omitempty"`
+	MaterialCombines     uint32  `json:"materialCombines,omitempty"`
+	StackSize            uint32  `json:"stackSize"`
+	X                    float32 `json:"x"`
+	Y                    float32 `json:"y"`
+}
+
+// Resolves the display name of an item; see `resolve.Resolver.Name`.
+type Namer func(item *stash.Item) string
+
+var ErrUnsupportedSchema = errors.New("unsupported schema version")
+
+// Convert `item` to its JSON representation, naming it using `name` unless
+// that is nil.
+func FromItem(item *stash.Item, name Namer) Item {
+	x, y := item.Position()
+	res := Item{
+		Base:                 item.Base,
+		Prefix:               item.Prefix,
+		Suffix:               item.Suffix,
+		Modifier:             item.Modifier,
+		Transmute:            item.Transmute,
+		Material:             item.Material,
+		RelicCompletionBonus: item.RelicCompletionBonus,
+		Enchantment:          item.Enchantment,
+		Seed:                 item.Seed,
+		RelicSeed:            item.RelicSeed,
+		EnchantmentSeed:      item.EnchantmentSeed,
+		MaterialCombines:     item.MaterialCombines,
+		StackSize:            item.StackSize,
+		X:                    x,
+		Y:                    y,
+	}
+	if name != nil {
+		res.Name = name(item)
+	}
+	return res
+}
+
+// Convert the JSON representation back to a `stash.Item`.
+func (item *Item) ToItem() stash.Item {
+	res := stash.Item{
+		Base:                 item.Base,
+		Prefix:               item.Prefix,
+		Suffix:               item.Suffix,
+		Modifier:             item.Modifier,
+		Transmute:            item.Transmute,
+		Material:             item.Material,
+		RelicCompletionBonus: item.RelicCompletionBonus,
+		Enchantment:          item.Enchantment,
+		Seed:                 item.Seed,
+		RelicSeed:            item.RelicSeed,
+		EnchantmentSeed:      item.EnchantmentSeed,
+		MaterialCombines:     item.MaterialCombines,
+		StackSize:            item.StackSize,
+	}
+	res.SetPosition(item.X, item.Y)
+	return res
+}
+
+// Convert `st` into a document, naming items using `name` unless that is nil.
+func Export(st *stash.Stash, name Namer) Document {
+	doc := Document{
+		Schema:     SchemaVersion,
+		Version:    st.Version,
+		Mod:        st.Mod,
+		Expansions: st.Expansions,
+		Tabs:       make([]Tab, 0, len(st.Tabs)),
+	}
+	for _, tab := range st.Tabs {
+		items := make([]Item, 0, len(tab.Items))
+		for i := range tab.Items {
+			items = append(items, FromItem(&tab.Items[i], name))
+		}
+		doc.Tabs = append(doc.Tabs, Tab{Width: tab.Width, Height: tab.Height, Items: items})
+	}
+	return doc
+}
+
+// Rebuild a stash from `doc`.
+func Import(doc *Document) (*stash.Stash, error) {
+	if doc.Schema < 1 || doc.Schema > SchemaVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedSchema, doc.Schema)
+	}
+
+	st := &stash.Stash{
+		Version:    doc.Version,
+		Mod:        doc.Mod,
+		Expansions: doc.Expansions,
+		Tabs:       make([]stash.StashTab, 0, len(doc.Tabs)),
+	}
+	for i, tab := range doc.Tabs {
+		items := make([]stash.Item, 0, len(tab.Items))
+		for j, item := range tab.Items {
+			if item.Base == "" {
+				return nil, fmt.Errorf("item %d in tab %d has no base record", j, i)
+			}
+			if item.X < 0 || item.Y < 0 || uint32(item.X) >= tab.Width || uint32(item.Y) >= tab.Height {
+				return nil, fmt.Errorf("item %d in tab %d is outside of the tab at (%v, %v)", j, i, item.X, item.Y)
+			}
+			items = append(items, item.ToItem())
+		}
+		st.Tabs = append(st.Tabs, stash.StashTab{Width: tab.Width, Height: tab.Height, Items: items})
+	}
+	return st, nil
+}
+
+// Write `st` as indented JSON to `w`.
+func Write(w io.Writer, st *stash.Stash, name Namer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(Export(st, name))
+}
+
+// Read a stash from JSON in `r`.
+func Read(r io.Reader) (*stash.Stash, error) {
+	var doc Document
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("could not parse stash JSON: %w", err)
+	}
+	return Import(&doc)
+}
+
+// Read a stash from the JSON file `file`.
+func ReadFile(file string) (*stash.Stash, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
@@ -0,0 +1,75 @@
+package stashjson
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+func TestRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	st, err := stash.ReadStash("../test_data/stashes/transfer.gst")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, st, nil); err != nil {
+		t.Fatal(err)
+	}
+	imported, err := Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if imported.Version != st.Version || imported.Mod != st.Mod || imported.Expansions != st.Expansions {
+		t.Errorf("stash metadata differs after round trip")
+	}
+	if len(imported.Tabs) != len(st.Tabs) {
+		t.Fatalf("expected %d tabs, got %d", len(st.Tabs), len(imported.Tabs))
+	}
+	for i := range st.Tabs {
+		original, got := st.Tabs[i], imported.Tabs[i]
+		if original.Width != got.Width || original.Height != got.Height {
+			t.Errorf("tab %d: size differs after round trip", i)
+		}
+		if !slices.Equal(original.Items, got.Items) {
+			t.Errorf("tab %d: items differ after round trip", i)
+		}
+	}
+}
+
+func TestExportNamesItems(t *testing.T) {
+	t.Parallel()
+
+	st := &stash.Stash{Tabs: []stash.StashTab{{Width: 10, Height: 18, Items: []stash.Item{{Base: "records/a.dbr"}}}}}
+	doc := Export(st, func(item *stash.Item) string { return "Name of " + item.Base })
+	if name := doc.Tabs[0].Items[0].Name; name != "Name of records/a.dbr" {
+		t.Errorf("unexpected name %q", name)
+	}
+}
+
+func TestImportRejectsInvalidDocuments(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"future schema": `{"schema": 99, "tabs": []}`,
+		"missing base":  `{"schema": 1, "tabs": [{"width": 2, "height": 2, "items": [{"x": 0, "y": 0}]}]}`,
+		"outside tab":   `{"schema": 1, "tabs": [{"width": 2, "height": 2, "items": [{"base": "records/a.dbr", "x": 2, "y": 0}]}]}`,
+	}
+	for name, doc := range cases {
+		if _, err := Read(strings.NewReader(doc)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	_, err := Read(strings.NewReader(`{"schema": 2}`))
+	if !errors.Is(err, ErrUnsupportedSchema) {
+		t.Errorf("expected ErrUnsupportedSchema, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package tooltip
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+)
+
+// The lines of a tooltip with how they are to be styled, shared by the
+// renderers.
+type line struct {
+	text  string
+	style string
+}
+
+// Line styles.
+const (
+	styleName   = "name"
+	styleInfo   = "info"
+	styleTitle  = "title"
+	styleStat   = "stat"
+	styleSet    = "set"
+	styleFlavor = "flavor"
+)
+
+func (t *Tooltip) lines() []line {
+	lines := []line{{t.Name, styleName}}
+	if t.Type != "" {
+		lines = append(lines, line{t.Type, styleInfo})
+	}
+	req := t.Requirements
+	for _, r := range []struct {
+		name  string
+		value uint32
+	}{{"Level", req.Level}, {"Physique", req.Physique}, {"Cunning", req.Cunning}, {"Spirit", req.Spirit}} {
+		if r.value > 0 {
+			lines = append(lines, line{fmt.Sprintf("Required %s: %d", r.name, r.value), styleInfo})
+		}
+	}
+	for _, section := range t.Sections {
+		if section.Title != "" {
+			lines = append(lines, line{section.Title, styleTitle})
+		}
+		for _, text := range section.Lines {
+			lines = append(lines, line{text, styleStat})
+		}
+	}
+	if t.Set != nil {
+		lines = append(lines, line{t.Set.Name, styleSet})
+		for _, member := range t.Set.Members {
+			lines = append(lines, line{"  " + member, styleSet})
+		}
+		for _, bonus := range t.Set.Bonuses {
+			for _, text := range bonus.Lines {
+				lines = append(lines, line{fmt.Sprintf("(%d) %s", bonus.Pieces, text), styleSet})
+			}
+		}
+	}
+	if t.Flavor != "" {
+		lines = append(lines, line{t.Flavor, styleFlavor})
+	}
+	return lines
+}
+
+// Render the tooltip as plain text, one line per stat.
+func (t *Tooltip) Text() string {
+	var b strings.Builder
+	for _, l := range t.lines() {
+		b.WriteString(l.text)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// ANSI escape sequences by line style, except for the name, which is coloured
+// by rarity.
+var ansiStyles = map[string]string{
+	styleInfo:   "\x1b[90m",
+	styleTitle:  "\x1b[1m",
+	styleSet:    "\x1b[32m",
+	styleFlavor: "\x1b[3;90m",
+}
+
+// The 24-bit ANSI foreground colour of a hex RGB colour.
+func ansiColor(hex string) string {
+	rgb, err := strconv.ParseUint(strings.TrimPrefix(hex, "#"), 16, 32)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("\x1b[1;38;2;%d;%d;%dm", rgb>>16, rgb>>8&0xff, rgb&0xff)
+}
+
+// Render the tooltip as text coloured with ANSI escape sequences, for
+// terminals.
+func (t *Tooltip) ANSI() string {
+	var b strings.Builder
+	for _, l := range t.lines() {
+		style := ansiStyles[l.style]
+		if l.style == styleName {
+			style = ansiColor(t.Color)
+		}
+		if style == "" {
+			b.WriteString(l.text)
+		} else {
+			b.WriteString(style + l.text + "\x1b[0m")
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Render the tooltip as an HTML fragment. Elements have classes named after
+// their line style, e.g. `tooltip-stat`, so the frontend can style them; only
+// the colour of the name is set inline.
+func (t *Tooltip) HTML() string {
+	var b strings.Builder
+	b.WriteString(`<div class="tooltip">`)
+	for _, l := range t.lines() {
+		fmt.Fprintf(&b, `<div class="tooltip-%s"`, l.style)
+		if l.style == styleName {
+			fmt.Fprintf(&b, ` style="color: %s"`, html.EscapeString(t.Color))
+		}
+		fmt.Fprintf(&b, ">%s</div>", html.EscapeString(strings.TrimSpace(l.text)))
+	}
+	b.WriteString("</div>")
+	return b.String()
+}
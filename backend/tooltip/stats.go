@@ -0,0 +1,136 @@
+package tooltip
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kenranunderscore/grimvault/backend/resolve"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+// Damage types by their name in record fields, e.g. `offensiveFireMin`.
+var damageTypes = map[string]string{
+	"Physical":  "Physical",
+	"Pierce":    "Pierce",
+	"Fire":      "Fire",
+	"Cold":      "Cold",
+	"Lightning": "Lightning",
+	"Poison":    "Acid",
+	"Life":      "Vitality",
+	"Aether":    "Aether",
+	"Chaos":     "Chaos",
+	"Elemental": "Elemental",
+	"Bleeding":  "Bleeding",
+}
+
+// How the game words fields, with `%s` for the value. A leading "+" is
+// dropped for negative values. Fields ending in "Modifier" whose base field
+// is listed here are worded as a percentage of it, unless listed themselves.
+var wordings = map[string]string{
+	"characterStrength":               "+%s Physique",
+	"characterDexterity":              "+%s Cunning",
+	"characterIntelligence":           "+%s Spirit",
+	"characterLife":                   "+%s Health",
+	"characterMana":                   "+%s Energy",
+	"characterLifeRegen":              "+%s Health Regenerated per second",
+	"characterManaRegen":              "+%s Energy Regenerated per second",
+	"characterLifeRegenModifier":      "+%s%% Health Regeneration",
+	"characterManaRegenModifier":      "+%s%% Energy Regeneration",
+	"characterOffensiveAbility":       "+%s Offensive Ability",
+	"characterDefensiveAbility":       "+%s Defensive Ability",
+	"characterAttackSpeedModifier":    "+%s%% Attack Speed",
+	"characterSpellCastSpeedModifier": "+%s%% Casting Speed",
+	"characterRunSpeedModifier":       "+%s%% Movement Speed",
+	"characterTotalSpeedModifier":     "+%s%% Total Speed",
+	"defensiveProtection":             "%s Armor",
+	"defensiveProtectionModifier":     "+%s%% Armor",
+	"defensiveElementalResistance":    "+%s%% Elemental Resistance",
+	"offensiveCritDamageModifier":     "+%s%% Crit Damage",
+	"offensiveTotalDamageModifier":    "+%s%% to All Damage",
+	"retaliationTotalDamageModifier":  "+%s%% to All Retaliation Damage",
+}
+
+// Format `values` the way the game shows them, several ones separated by
+// slashes.
+func number(values []float64) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.FormatFloat(v, 'f', -1, 32)
+	}
+	return strings.Join(parts, "/")
+}
+
+func word(wording string, values []float64) string {
+	if strings.HasPrefix(wording, "+") && values[0] < 0 {
+		wording = wording[1:]
+	}
+	return fmt.Sprintf(wording, number(values))
+}
+
+// Word a single field, or return false for fields that are not known.
+func wordField(name string, values []float64, all map[string][]float64) (string, bool) {
+	if wording, ok := wordings[name]; ok {
+		return word(wording, values), true
+	}
+	if base, ok := strings.CutSuffix(name, "Modifier"); ok {
+		if wording, ok := wordings[base]; ok {
+			_, rest, _ := strings.Cut(wording, "%s")
+			return word("+%s%%"+rest, values), true
+		}
+	}
+
+	if rest, ok := strings.CutPrefix(name, "offensive"); ok {
+		if kind, ok := strings.CutSuffix(rest, "Min"); ok && damageTypes[kind] != "" {
+			if max := all["offensive"+kind+"Max"]; len(max) > 0 && max[0] > values[0] {
+				return fmt.Sprintf("%s-%s %s Damage", number(values), number(max), damageTypes[kind]), true
+			}
+			return word("+%s "+damageTypes[kind]+" Damage", values), true
+		}
+		if kind, ok := strings.CutSuffix(rest, "Modifier"); ok && damageTypes[kind] != "" {
+			return word("+%s%% "+damageTypes[kind]+" Damage", values), true
+		}
+	}
+	if kind, ok := strings.CutPrefix(name, "defensive"); ok && damageTypes[kind] != "" {
+		return word("+%s%% "+damageTypes[kind]+" Resistance", values), true
+	}
+	return "", false
+}
+
+// Word `stats` the way the game does, one line per field. Minimum and maximum
+// damage are combined into a range. Fields that are not known are shown with
+// their record field name.
+func formatStats(stats []resolve.Stat) []string {
+	var names []string
+	all := make(map[string][]float64)
+	for _, stat := range stats {
+		if _, ok := all[stat.Name]; !ok {
+			names = append(names, stat.Name)
+		}
+		all[stat.Name] = append(all[stat.Name], stat.Value)
+	}
+
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		if kind, ok := strings.CutSuffix(strings.TrimPrefix(name, "offensive"), "Max"); ok && damageTypes[kind] != "" {
+			if _, ok := all["offensive"+kind+"Min"]; ok {
+				continue
+			}
+		}
+		line, ok := wordField(name, all[name], all)
+		if !ok {
+			line = fmt.Sprintf("%s: %s", name, number(all[name]))
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// The worded stats of all records of `item`, as its tooltip shows them.
+func StatLines(r *resolve.Resolver, item *stash.Item) []string {
+	var lines []string
+	for _, record := range item.Records() {
+		lines = append(lines, formatStats(r.Stats(record))...)
+	}
+	return lines
+}
@@ -0,0 +1,237 @@
+package tooltip
+
+import (
+	"strings"
+
+	"github.com/kenranunderscore/grimvault/backend/database"
+	"github.com/kenranunderscore/grimvault/backend/resolve"
+	"github.com/kenranunderscore/grimvault/backend/sets"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+// Kinds of sections, in the order the game shows them.
+const (
+	Base       = "base"
+	Prefix     = "prefix"
+	Suffix     = "suffix"
+	Modifier   = "modifier"
+	Transmute  = "transmute"
+	Component  = "component"
+	Completion = "completion"
+	Augment    = "augment"
+	// A skill granted by one of the item's records.
+	Skill = "skill"
+)
+
+// What the game shows when hovering over an item, independent of how it is
+// rendered.
+type Tooltip struct {
+	Name   string `json:"name"`
+	Rarity string `json:"rarity,omitempty"`
+	// The colour of the name, as hex RGB.
+	Color string `json:"color"`
+	// The rarity and kind of item, e.g. "Legendary Ring".
+	Type         string               `json:"type,omitempty"`
+	Requirements resolve.Requirements `json:"requirements"`
+	Sections     []Section            `json:"sections"`
+	Set          *SetInfo             `json:"set,omitempty"`
+	Flavor       string               `json:"flavor,omitempty"`
+}
+
+// The stats one record of the item adds, e.g. its prefix.
+type Section struct {
+	Kind string `json:"kind"`
+	// The name of the record, if it has one of its own, e.g. the name of the
+	// prefix or of the attached component.
+	Title string   `json:"title,omitempty"`
+	Lines []string `json:"lines"`
+}
+
+// The set an item belongs to.
+type SetInfo struct {
+	Name    string     `json:"name"`
+	Members []string   `json:"members"`
+	Bonuses []SetBonus `json:"bonuses"`
+}
+
+// The stats gained by equipping `Pieces` members of a set.
+type SetBonus struct {
+	Pieces int      `json:"pieces"`
+	Lines  []string `json:"lines"`
+}
+
+// The colour of item names by rarity, as used in game.
+var colors = map[string]string{
+	"broken":    "#999999",
+	"common":    "#FFFFFF",
+	"magical":   "#FFF52B",
+	"rare":      "#40FF40",
+	"epic":      "#3ACAFF",
+	"legendary": "#D96EFF",
+}
+
+// How item kinds are called in game.
+var typeNames = map[string]string{
+	"head":      "Head",
+	"chest":     "Chest",
+	"shoulders": "Shoulders",
+	"hands":     "Hands",
+	"legs":      "Legs",
+	"feet":      "Feet",
+	"waist":     "Waist",
+	"amulet":    "Amulet",
+	"ring":      "Ring",
+	"medal":     "Medal",
+	"offhand":   "Off-Hand",
+	"shield":    "Shield",
+	"axe":       "One-Handed Axe",
+	"mace":      "One-Handed Mace",
+	"sword":     "One-Handed Sword",
+	"dagger":    "Dagger",
+	"scepter":   "Scepter",
+	"axe2h":     "Two-Handed Axe",
+	"mace2h":    "Two-Handed Mace",
+	"sword2h":   "Two-Handed Sword",
+	"spear2h":   "Spear",
+	"ranged1h":  "One-Handed Gun",
+	"ranged2h":  "Two-Handed Ranged",
+	"relic":     "Relic",
+	"blueprint": "Blueprint",
+	"component": "Component",
+	"augment":   "Augment",
+}
+
+// Builds tooltips from the game data.
+type Builder struct {
+	r *resolve.Resolver
+	// Nil if set bonuses are not shown.
+	sets *sets.Index
+}
+
+// Build tooltips with `r`, showing the set bonuses of the sets in `sets`,
+// which may be nil.
+func New(r *resolve.Resolver, sets *sets.Index) *Builder {
+	return &Builder{r: r, sets: sets}
+}
+
+// Describe `item` the way the game does. Stats are the values of the records,
+// see `resolve.Details`.
+func (b *Builder) Build(item *stash.Item) Tooltip {
+	d := b.r.Details(item)
+	rarity := strings.ToLower(d.Rarity)
+	t := Tooltip{Name: d.Name, Rarity: rarity, Color: colors["common"], Sections: []Section{}}
+	if color, ok := colors[rarity]; ok {
+		t.Color = color
+	}
+	t.Type = typeName(rarity, resolve.Slot(d.Class))
+	t.Requirements = resolve.Requirements{Level: d.Level, Physique: d.Physique, Cunning: d.Cunning, Spirit: d.Spirit}
+
+	affixName := func(record string) string {
+		return b.r.Text(record, "lootRandomizerName")
+	}
+	records := []struct {
+		kind, record, title string
+	}{
+		{Base, item.Base, ""},
+		{Prefix, item.Prefix, affixName(item.Prefix)},
+		{Suffix, item.Suffix, affixName(item.Suffix)},
+		{Modifier, item.Modifier, affixName(item.Modifier)},
+		{Transmute, item.Transmute, affixName(item.Transmute)},
+		{Component, item.Material, b.r.BaseName(item.Material)},
+		{Completion, item.RelicCompletionBonus, affixName(item.RelicCompletionBonus)},
+		{Augment, item.Enchantment, b.r.BaseName(item.Enchantment)},
+	}
+	for _, record := range records {
+		lines := formatStats(b.r.Stats(record.record))
+		if len(lines) > 0 {
+			t.Sections = append(t.Sections, Section{Kind: record.kind, Title: record.title, Lines: lines})
+		}
+	}
+
+	t.Sections = append(t.Sections, b.grantedSkills(item)...)
+
+	if b.sets != nil {
+		if set, ok := b.sets.SetOf(item.Base); ok {
+			t.Set = b.setInfo(set)
+		}
+	}
+	t.Flavor = b.r.Text(item.Base, "itemText")
+	return t
+}
+
+func typeName(rarity string, slot string) string {
+	name := typeNames[slot]
+	if name == "" || rarity == "" {
+		return name
+	}
+	return strings.ToUpper(rarity[:1]) + rarity[1:] + " " + name
+}
+
+// Describe `set`. Its record holds arrays of stats, indexed by the number of
+// equipped pieces minus one.
+func (b *Builder) setInfo(set *sets.Set) *SetInfo {
+	info := &SetInfo{Name: set.Name, Members: []string{}, Bonuses: []SetBonus{}}
+	for _, member := range set.Members {
+		name := b.r.BaseName(member)
+		if name == "" {
+			name = member
+		}
+		info.Members = append(info.Members, name)
+	}
+
+	entry, ok := b.r.DB.Get(set.Record)
+	if !ok {
+		return info
+	}
+	var byPieces [][]resolve.Stat
+	seen := make(map[string]bool)
+	for _, stat := range b.r.Stats(set.Record) {
+		if seen[stat.Name] {
+			continue
+		}
+		seen[stat.Name] = true
+		for i, value := range entry.All(stat.Name) {
+			v := database.ToFloat(value)
+			if v == 0 {
+				continue
+			}
+			for len(byPieces) <= i {
+				byPieces = append(byPieces, nil)
+			}
+			byPieces[i] = append(byPieces[i], resolve.Stat{Name: stat.Name, Value: v, Record: set.Record})
+		}
+	}
+	for i, stats := range byPieces {
+		if len(stats) > 0 {
+			info.Bonuses = append(info.Bonuses, SetBonus{Pieces: i + 1, Lines: formatStats(stats)})
+		}
+	}
+	return info
+}
+
+// The skills granted by the records of `item`, with their stats at the first
+// skill level.
+func (b *Builder) grantedSkills(item *stash.Item) []Section {
+	var sections []Section
+	for _, node := range b.r.Expand(item) {
+		for _, ref := range node.Refs {
+			if ref.Field != "itemSkillName" || ref.Entry == nil {
+				continue
+			}
+			name := b.r.Text(ref.Key, "skillDisplayName")
+			if name == "" {
+				name = ref.Key
+			}
+			var stats []resolve.Stat
+			seen := make(map[string]bool)
+			for _, stat := range b.r.Stats(ref.Key) {
+				if !seen[stat.Name] {
+					seen[stat.Name] = true
+					stats = append(stats, stat)
+				}
+			}
+			sections = append(sections, Section{Kind: Skill, Title: "Grants Skill: " + name, Lines: formatStats(stats)})
+		}
+	}
+	return sections
+}
@@ -0,0 +1,118 @@
+package tooltip
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kenranunderscore/grimvault/backend/arc"
+	"github.com/kenranunderscore/grimvault/backend/database"
+	"github.com/kenranunderscore/grimvault/backend/resolve"
+	"github.com/kenranunderscore/grimvault/backend/sets"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+const (
+	ring   = "records/items/gearaccessories/rings/d001_ring.dbr"
+	prefix = "records/items/lootaffixes/prefix/a.dbr"
+	set    = "records/items/sets/itemset_d001.dbr"
+)
+
+func testBuilder() *Builder {
+	db := database.New([]database.Entry{
+		{Key: ring, Stats: []database.Stat{
+			{Name: "Class", Value: "ArmorJewelry_Ring"},
+			{Name: "itemClassification", Value: "Legendary"},
+			{Name: "itemNameTag", Value: "tagRing"},
+			{Name: "itemText", Value: "tagRingText"},
+			{Name: "levelRequirement", Value: float32(94)},
+			{Name: "offensiveFireMin", Value: float32(10)},
+			{Name: "offensiveFireMax", Value: float32(15)},
+			{Name: "characterStrength", Value: float32(12)},
+			{Name: "characterStrengthModifier", Value: float32(3)},
+			{Name: "defensiveCold", Value: float32(-5)},
+			{Name: "skillCooldownReduction", Value: float32(4.5)},
+			{Name: "itemSkillName", Value: "records/skills/items/nova.dbr"},
+		}},
+		{Key: "records/skills/items/nova.dbr", Stats: []database.Stat{
+			{Name: "skillDisplayName", Value: "tagNova"},
+			{Name: "offensiveColdMin", Value: float32(50)},
+			{Name: "offensiveColdMin", Value: float32(60)},
+		}},
+		{Key: prefix, Stats: []database.Stat{
+			{Name: "lootRandomizerName", Value: "tagPrefix"},
+			{Name: "offensivePoisonModifier", Value: float32(20)},
+		}},
+		{Key: set, Stats: []database.Stat{
+			{Name: "setName", Value: "tagSet"},
+			{Name: "setMembers", Value: ring},
+			{Name: "characterLife", Value: float32(0)},
+			{Name: "characterLife", Value: float32(100)},
+			{Name: "characterLife", Value: float32(200)},
+		}},
+	})
+	tags := arc.NewTags([]arc.Tag{
+		{Tag: "tagRing", Name: "Ring of <Embers>"},
+		{Tag: "tagRingText", Name: "Still warm."},
+		{Tag: "tagPrefix", Name: "Corrosive"},
+		{Tag: "tagSet", Name: "Embers"},
+		{Tag: "tagNova", Name: "Frost Nova"},
+	})
+	r := resolve.New(db, tags)
+	return New(r, sets.NewIndex(r))
+}
+
+func TestBuild(t *testing.T) {
+	t.Parallel()
+
+	tip := testBuilder().Build(&stash.Item{Base: ring, Prefix: prefix})
+	if tip.Name != "Corrosive Ring of <Embers>" || tip.Color != colors["legendary"] || tip.Type != "Legendary Ring" || tip.Requirements.Level != 94 {
+		t.Errorf("unexpected header %+v", tip)
+	}
+	if len(tip.Sections) != 3 {
+		t.Fatalf("expected a base, a prefix and a skill section, got %+v", tip.Sections)
+	}
+	expected := []string{"10-15 Fire Damage", "+12 Physique", "+3% Physique", "-5% Cold Resistance", "skillCooldownReduction: 4.5"}
+	if base := tip.Sections[0]; base.Kind != Base || !slices.Equal(base.Lines, expected) {
+		t.Errorf("expected base stats %q, got %+v", expected, base)
+	}
+	if p := tip.Sections[1]; p.Kind != Prefix || p.Title != "Corrosive" || !slices.Equal(p.Lines, []string{"+20% Acid Damage"}) {
+		t.Errorf("unexpected prefix section %+v", p)
+	}
+	if s := tip.Sections[2]; s.Kind != Skill || s.Title != "Grants Skill: Frost Nova" || !slices.Equal(s.Lines, []string{"+50 Cold Damage"}) {
+		t.Errorf("expected the granted skill at its first level, got %+v", s)
+	}
+	if tip.Set == nil || tip.Set.Name != "Embers" || len(tip.Set.Bonuses) != 2 || tip.Set.Bonuses[0].Pieces != 2 || tip.Set.Bonuses[1].Lines[0] != "+200 Health" {
+		t.Errorf("unexpected set %+v", tip.Set)
+	}
+	if tip.Flavor != "Still warm." {
+		t.Errorf("unexpected flavor text %q", tip.Flavor)
+	}
+}
+
+func TestStatLines(t *testing.T) {
+	t.Parallel()
+
+	b := testBuilder()
+	lines := StatLines(b.r, &stash.Item{Base: ring, Prefix: prefix})
+	if len(lines) != 6 || lines[1] != "+12 Physique" || lines[5] != "+20% Acid Damage" {
+		t.Errorf("expected the base and prefix stats, got %q", lines)
+	}
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	tip := testBuilder().Build(&stash.Item{Base: ring})
+	text := tip.Text()
+	if !strings.HasPrefix(text, "Ring of <Embers>\nLegendary Ring\nRequired Level: 94\n10-15 Fire Damage\n") || !strings.Contains(text, "(3) +200 Health\n") {
+		t.Errorf("unexpected text %q", text)
+	}
+	if ansi := tip.ANSI(); !strings.HasPrefix(ansi, "\x1b[1;38;2;217;110;255mRing of <Embers>\x1b[0m\n") {
+		t.Errorf("expected the name in the legendary colour, got %q", ansi)
+	}
+	html := tip.HTML()
+	if !strings.Contains(html, `<div class="tooltip-name" style="color: #D96EFF">Ring of &lt;Embers&gt;</div>`) || !strings.Contains(html, `<div class="tooltip-flavor">Still warm.</div>`) {
+		t.Errorf("unexpected HTML %q", html)
+	}
+}
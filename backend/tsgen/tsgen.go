@@ -0,0 +1,237 @@
+package tsgen
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Emits TypeScript declarations matching how `encoding/json` serializes Go
+// types.
+//
+// Struct types are declared as interfaces. Nested struct types have to be
+// added as well, so that they are declared under a chosen name; this avoids
+// clashes between types of the same name from different packages. The TS type
+// of a field can be overridden with a `ts` struct tag, e.g. for fields of type
+// `any` whose values are known to be restricted.
+type Generator struct {
+	names map[reflect.Type]string
+	decls []decl
+}
+
+// The print width of the frontend's prettier config.
+const maxLineLength = 100
+
+type decl struct {
+	name   string
+	typ    reflect.Type
+	values []string
+}
+
+func New() *Generator {
+	return &Generator{names: make(map[reflect.Type]string)}
+}
+
+// Declare the struct type of `value` as interface `name`.
+func (g *Generator) Add(name string, value any) {
+	typ := reflect.TypeOf(value)
+	g.names[typ] = name
+	g.decls = append(g.decls, decl{name: name, typ: typ})
+}
+
+// Declare `name` as the union of the given string literals.
+func (g *Generator) AddUnion(name string, values ...string) {
+	g.decls = append(g.decls, decl{name: name, values: values})
+}
+
+var (
+	timeType          = reflect.TypeFor[time.Time]()
+	rawMessageType    = reflect.TypeFor[json.RawMessage]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// The TS type of values of type `typ`. Values that can be encoded as null,
+// like nil slices, include null in their type.
+func (g *Generator) tsType(typ reflect.Type) (string, error) {
+	if name, ok := g.names[typ]; ok {
+		return name, nil
+	}
+	switch {
+	case typ == timeType:
+		return "string", nil
+	case typ == rawMessageType:
+		return "unknown", nil
+	case typ.Implements(jsonMarshalerType):
+		return "", fmt.Errorf("type %s has a custom JSON encoding", typ)
+	case typ.Implements(textMarshalerType):
+		return "string", nil
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "boolean", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number", nil
+	case reflect.String:
+		return "string", nil
+	case reflect.Interface:
+		return "unknown", nil
+	case reflect.Pointer:
+		elem, err := g.tsType(typ.Elem())
+		if err != nil {
+			return "", err
+		}
+		return elem + " | null", nil
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 && typ.Kind() == reflect.Slice {
+			// Encoded as base64.
+			return "string | null", nil
+		}
+		elem, err := g.tsType(typ.Elem())
+		if err != nil {
+			return "", err
+		}
+		if strings.Contains(elem, " ") {
+			elem = "(" + elem + ")"
+		}
+		if typ.Kind() == reflect.Array {
+			return elem + "[]", nil
+		}
+		return elem + "[] | null", nil
+	case reflect.Map:
+		elem, err := g.tsType(typ.Elem())
+		if err != nil {
+			return "", err
+		}
+		return "Record<string, " + elem + "> | null", nil
+	case reflect.Struct:
+		// Structs without any encoded fields are always encoded as `{}`, so
+		// they do not need a declaration.
+		if fields, err := g.fields(typ); err == nil && len(fields) == 0 {
+			return "Record<string, never>", nil
+		}
+		return "", fmt.Errorf("struct type %s has not been added", typ)
+	}
+	return "", fmt.Errorf("type %s cannot be encoded as JSON", typ)
+}
+
+type field struct {
+	name     string
+	tsType   string
+	optional bool
+}
+
+// The fields of struct `typ` as `encoding/json` encodes them. Fields of
+// embedded structs are promoted, unless the embedding field has a name.
+func (g *Generator) fields(typ reflect.Type) ([]field, error) {
+	var res []field
+	for i := range typ.NumField() {
+		f := typ.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				promoted, err := g.fields(embedded)
+				if err != nil {
+					return nil, err
+				}
+				res = append(res, promoted...)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		tsType := f.Tag.Get("ts")
+		if tsType == "" {
+			var err error
+			if tsType, err = g.tsType(f.Type); err != nil {
+				return nil, fmt.Errorf("field %s of %s: %w", f.Name, typ, err)
+			}
+		}
+		if strings.Contains(","+opts+",", ",string,") {
+			tsType = "string"
+		}
+		// `omitempty` has no effect on structs, which are never empty.
+		omitempty := strings.Contains(","+opts+",", ",omitempty,") && f.Type.Kind() != reflect.Struct ||
+			strings.Contains(","+opts+",", ",omitzero,")
+		if omitempty {
+			// Omitted instead of null when empty.
+			tsType = strings.TrimSuffix(tsType, " | null")
+		}
+		res = append(res, field{name: name, tsType: tsType, optional: omitempty})
+	}
+	return res, nil
+}
+
+func quote(name string) string {
+	for _, r := range name {
+		if !(r == '_' || r == '$' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9') {
+			return fmt.Sprintf("%q", name)
+		}
+	}
+	return name
+}
+
+// Generate the declarations of all added types, in the order they have been
+// added.
+func (g *Generator) Generate() (string, error) {
+	var b strings.Builder
+	b.WriteString("// Code generated by tsgen. DO NOT EDIT.\n")
+	seen := make(map[string]bool)
+	for _, d := range g.decls {
+		if seen[d.name] {
+			return "", fmt.Errorf("type %s is declared twice", d.name)
+		}
+		seen[d.name] = true
+		b.WriteString("\n")
+		if d.typ == nil {
+			values := make([]string, 0, len(d.values))
+			for _, value := range d.values {
+				values = append(values, fmt.Sprintf("%q", value))
+			}
+			line := fmt.Sprintf("export type %s = %s;\n", d.name, strings.Join(values, " | "))
+			if len(line) > maxLineLength {
+				// Formatted the way prettier does it.
+				line = fmt.Sprintf("export type %s =\n  | %s;\n", d.name, strings.Join(values, "\n  | "))
+			}
+			b.WriteString(line)
+			continue
+		}
+
+		if d.typ.Kind() != reflect.Struct {
+			return "", fmt.Errorf("%s: type %s is not a struct", d.name, d.typ)
+		}
+		fields, err := g.fields(d.typ)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", d.name, err)
+		}
+		fmt.Fprintf(&b, "export interface %s {\n", d.name)
+		for _, f := range fields {
+			optional := ""
+			if f.optional {
+				optional = "?"
+			}
+			fmt.Fprintf(&b, "  %s%s: %s;\n", quote(f.name), optional, f.tsType)
+		}
+		b.WriteString("}\n")
+	}
+	return b.String(), nil
+}
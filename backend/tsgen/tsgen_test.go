@@ -0,0 +1,85 @@
+package tsgen
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+type inner struct {
+	Value int `json:"value"`
+}
+
+type Embedded struct {
+	Promoted string `json:"promoted"`
+}
+
+type outer struct {
+	Embedded
+	Name     string            `json:"name"`
+	Count    uint32            `json:"count,omitempty"`
+	Id       uint64            `json:"id,string"`
+	Inner    inner             `json:"inner,omitempty"`
+	Pointer  *inner            `json:"pointer"`
+	Optional *inner            `json:"optional,omitempty"`
+	List     []inner           `json:"list"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	Raw      json.RawMessage   `json:"raw"`
+	When     time.Time         `json:"when"`
+	Any      any               `json:"any" ts:"string | number"`
+	Skipped  string            `json:"-"`
+	Untagged bool
+	hidden   bool
+}
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	g := New()
+	g.Add("Inner", inner{})
+	g.Add("Outer", outer{})
+	g.AddUnion("Kind", "a", "b")
+	ts, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `// Code generated by tsgen. DO NOT EDIT.
+
+export interface Inner {
+  value: number;
+}
+
+export interface Outer {
+  promoted: string;
+  name: string;
+  count?: number;
+  id: string;
+  inner: Inner;
+  pointer: Inner | null;
+  optional?: Inner;
+  list: Inner[] | null;
+  tags?: Record<string, string>;
+  raw: unknown;
+  when: string;
+  any: string | number;
+  Untagged: boolean;
+}
+
+export type Kind = "a" | "b";
+`
+	if ts != expected {
+		t.Errorf("unexpected output:\n%s", ts)
+	}
+}
+
+func TestGenerateRequiresNestedStructs(t *testing.T) {
+	t.Parallel()
+
+	g := New()
+	g.Add("Outer", outer{})
+	if _, err := g.Generate(); err == nil || !strings.Contains(err.Error(), "has not been added") {
+		t.Errorf("expected an error about the missing declaration of inner, got %v", err)
+	}
+}
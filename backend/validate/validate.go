@@ -0,0 +1,273 @@
+// Checks items against the game database for things the game would not
+// produce, e.g. a component socketed into an item it does not fit, to flag
+// items imported from elsewhere before they are written into a stash.
+package validate
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/kenranunderscore/grimvault/backend/affixes"
+	"github.com/kenranunderscore/grimvault/backend/database"
+	"github.com/kenranunderscore/grimvault/backend/resolve"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+// What is wrong with an item.
+type Kind string
+
+const (
+	// A record that does not exist in the database.
+	MissingRecord Kind = "missing-record"
+	// A prefix or suffix that no known loot table rolls on the base. Only a
+	// warning, as crafting and the loot tables of mods are not checked.
+	AffixNotAllowed Kind = "affix-not-allowed"
+	// An affix that only rolls at other levels than the base drops at.
+	AffixLevel Kind = "affix-level"
+	// More items in a stack than the game stacks.
+	StackSize Kind = "stack-size"
+	// A record of the wrong class, e.g. an augment stored as component.
+	WrongClass Kind = "wrong-class"
+	// A component that cannot be socketed into the item.
+	ComponentSlot Kind = "component-slot"
+	// More components combined than it takes to complete one.
+	ComponentCombines Kind = "component-combines"
+	// A completion bonus that the relic or component cannot roll.
+	CompletionBonus Kind = "completion-bonus"
+	// An augment that cannot be applied to the item.
+	AugmentSlot Kind = "augment-slot"
+)
+
+// How sure it is that the game would not produce an item.
+type Severity string
+
+const (
+	// The item cannot exist in the game, and writing it into a stash may
+	// break the stash.
+	Error Severity = "error"
+	// The item is unlikely, but may come from a source that is not checked,
+	// e.g. a crafting recipe or a loot table of a mod.
+	Warning Severity = "warning"
+)
+
+// Something wrong with an item.
+type Finding struct {
+	Kind     Kind     `json:"kind"`
+	Severity Severity `json:"severity"`
+	// The item field at fault, named as in the JSON export, e.g. "material".
+	Field   string `json:"field"`
+	Record  string `json:"record"`
+	Message string `json:"message"`
+}
+
+type Validator struct {
+	r       *resolve.Resolver
+	affixes *affixes.Index
+}
+
+// Create a validator for the database of `r`, which looks up allowed affixes
+// in `ix`.
+func New(r *resolve.Resolver, ix *affixes.Index) *Validator {
+	return &Validator{r: r, affixes: ix}
+}
+
+// Whether any of `findings` is an error.
+func HasErrors(findings []Finding) bool {
+	return slices.ContainsFunc(findings, func(f Finding) bool { return f.Severity == Error })
+}
+
+// Check everything that can be checked about `item`: whether its records
+// exist, whether its affixes roll on its base, whether its stack is not too
+// large, and its `Compatibility`.
+func (v *Validator) Check(item *stash.Item) []Finding {
+	var findings []Finding
+	fields := []struct {
+		name   string
+		record string
+	}{
+		{"base", item.Base},
+		{"prefix", item.Prefix},
+		{"suffix", item.Suffix},
+		{"modifier", item.Modifier},
+		{"transmute", item.Transmute},
+		{"material", item.Material},
+		{"relicCompletionBonus", item.RelicCompletionBonus},
+		{"enchantment", item.Enchantment},
+	}
+	for _, field := range fields {
+		if _, ok := v.r.DB.Get(field.record); field.record != "" && !ok {
+			findings = append(findings, Finding{
+				Kind:     MissingRecord,
+				Severity: Error,
+				Field:    field.name,
+				Record:   database.NormalizeKey(field.record),
+				Message:  fmt.Sprintf("%s does not exist in the game database", field.record),
+			})
+		}
+	}
+	if item.Base == "" {
+		findings = append(findings, Finding{Kind: MissingRecord, Severity: Error, Field: "base", Message: "the item has no base record"})
+	}
+
+	findings = append(findings, v.affixFindings(item)...)
+	if limit := v.r.MaxStack(item.Base); item.StackSize > limit {
+		findings = append(findings, Finding{
+			Kind:     StackSize,
+			Severity: Error,
+			Field:    "stackSize",
+			Record:   database.NormalizeKey(item.Base),
+			Message:  fmt.Sprintf("%s stacks up to %d, not %d", v.name(item.Base), limit, item.StackSize),
+		})
+	}
+	return append(findings, v.Compatibility(item)...)
+}
+
+// Check whether the affixes of `item` roll on its base at its level. Bases
+// that no known loot table rolls affixes on cannot be checked.
+func (v *Validator) affixFindings(item *stash.Item) []Finding {
+	pool := v.affixes.Pool(item.Base, 0)
+	if len(pool.Prefixes) == 0 && len(pool.Suffixes) == 0 {
+		return nil
+	}
+	level := uint32(0)
+	if base, ok := v.r.DB.Get(item.Base); ok {
+		level = uint32(base.Float("itemLevel"))
+	}
+
+	var findings []Finding
+	check := func(field string, affix string, rolls []affixes.Roll) {
+		affix = database.NormalizeKey(affix)
+		if affix == "" {
+			return
+		}
+		i := slices.IndexFunc(rolls, func(r affixes.Roll) bool { return r.Affix == affix })
+		switch {
+		case i < 0:
+			findings = append(findings, Finding{
+				Kind:     AffixNotAllowed,
+				Severity: Warning,
+				Field:    field,
+				Record:   affix,
+				Message:  fmt.Sprintf("%s does not roll as %s on %s", v.name(affix), field, v.name(item.Base)),
+			})
+		case rolls[i].LevelMax != 0 && rolls[i].LevelMax < level:
+			findings = append(findings, Finding{
+				Kind:     AffixLevel,
+				Severity: Warning,
+				Field:    field,
+				Record:   affix,
+				Message:  fmt.Sprintf("%s only rolls up to level %d, but %s is level %d", v.name(affix), rolls[i].LevelMax, v.name(item.Base), level),
+			})
+		case level != 0 && level < rolls[i].LevelMin:
+			findings = append(findings, Finding{
+				Kind:     AffixLevel,
+				Severity: Warning,
+				Field:    field,
+				Record:   affix,
+				Message:  fmt.Sprintf("%s only rolls from level %d, but %s is level %d", v.name(affix), rolls[i].LevelMin, v.name(item.Base), level),
+			})
+		}
+	}
+	check("prefix", item.Prefix, pool.Prefixes)
+	check("suffix", item.Suffix, pool.Suffixes)
+	return findings
+}
+
+func (v *Validator) name(record string) string {
+	if name := v.r.BaseName(record); name != "" {
+		return name
+	}
+	return record
+}
+
+// Whether the relic, component or augment `entry` can be applied to items of
+// slot `slot`. They list the item types they fit in fields named by the
+// slots, e.g. `ring` or `axe2h`.
+func fits(entry *database.Entry, slot string) bool {
+	return slot != "" && entry.Float(slot) != 0
+}
+
+// The completion bonuses the relic or component `record` can roll, listed in
+// the table named by its `bonusTableName`, or false if there is no such
+// table.
+func (v *Validator) CompletionBonuses(record string) ([]string, bool) {
+	owner, ok := v.r.DB.Get(record)
+	if !ok {
+		return nil, false
+	}
+	table, ok := v.r.DB.Get(owner.String("bonusTableName"))
+	if !ok {
+		return nil, false
+	}
+	var res []string
+	for _, n := range table.Numbered("randomizerName") {
+		if name := table.String("randomizerName" + strconv.Itoa(n)); name != "" {
+			res = append(res, database.NormalizeKey(name))
+		}
+	}
+	return res, true
+}
+
+// Check whether the component, relic completion bonus and augment of `item`
+// can belong to it. Records missing from the database cannot be checked and
+// are skipped.
+func (v *Validator) Compatibility(item *stash.Item) []Finding {
+	var findings []Finding
+	add := func(kind Kind, field string, record string, format string, args ...any) {
+		findings = append(findings, Finding{Kind: kind, Severity: Error, Field: field, Record: record, Message: fmt.Sprintf(format, args...)})
+	}
+
+	slot := ""
+	base, hasBase := v.r.DB.Get(item.Base)
+	if hasBase {
+		slot = resolve.Slot(base.String("Class"))
+	}
+	itemName := v.name(item.Base)
+
+	// The record rolling the completion bonus: the component socketed into
+	// the item, or the item itself if it is a relic or a component.
+	owner := ""
+
+	if item.Material != "" {
+		if material, ok := v.r.DB.Get(item.Material); ok {
+			switch {
+			case material.String("Class") != "ItemRelic":
+				add(WrongClass, "material", item.Material, "%s is not a component", v.name(item.Material))
+			case hasBase && !fits(material, slot):
+				add(ComponentSlot, "material", item.Material, "%s cannot be socketed into %s", v.name(item.Material), itemName)
+			default:
+				owner = item.Material
+			}
+		}
+	} else if hasBase && (slot == "relic" || slot == "component") {
+		owner = item.Base
+		if complete := base.Float("completedRelicLevel"); complete > 0 && float64(item.MaterialCombines) > complete {
+			add(ComponentCombines, "materialCombines", item.Base, "%s is complete with %v pieces, not %d", itemName, complete, item.MaterialCombines)
+		}
+	}
+
+	if bonus := database.NormalizeKey(item.RelicCompletionBonus); bonus != "" {
+		switch {
+		case owner == "" && item.Material == "" && hasBase:
+			add(CompletionBonus, "relicCompletionBonus", bonus, "%s has no relic or component to complete", itemName)
+		case owner != "":
+			bonuses, ok := v.CompletionBonuses(owner)
+			if ok && !slices.Contains(bonuses, bonus) {
+				add(CompletionBonus, "relicCompletionBonus", bonus, "%s is not a completion bonus of %s", v.name(bonus), v.name(owner))
+			}
+		}
+	}
+
+	if item.Enchantment != "" {
+		if augment, ok := v.r.DB.Get(item.Enchantment); ok {
+			switch {
+			case augment.String("Class") != "ItemEnchantment":
+				add(WrongClass, "enchantment", item.Enchantment, "%s is not an augment", v.name(item.Enchantment))
+			case hasBase && !fits(augment, slot):
+				add(AugmentSlot, "enchantment", item.Enchantment, "%s cannot be applied to %s", v.name(item.Enchantment), itemName)
+			}
+		}
+	}
+	return findings
+}
@@ -0,0 +1,114 @@
+package validate
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/kenranunderscore/grimvault/backend/affixes"
+	"github.com/kenranunderscore/grimvault/backend/internal/testdb"
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+const (
+	ring      = testdb.Ring
+	sword     = testdb.Sword
+	relic     = testdb.Relic
+	component = testdb.Component
+	augment   = testdb.Augment
+	bonus     = testdb.Bonus
+	other     = testdb.OtherBonus
+	fiery     = testdb.Fiery
+	ancient   = testdb.Ancient
+	mighty    = testdb.Mighty
+	valor     = testdb.Valor
+)
+
+func testValidator() *Validator {
+	r := testdb.Resolver()
+	return New(r, affixes.NewIndex(r))
+}
+
+func kinds(findings []Finding) []Kind {
+	var res []Kind
+	for _, f := range findings {
+		res = append(res, f.Kind)
+	}
+	return res
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	v := testValidator()
+	cases := []struct {
+		name     string
+		item     stash.Item
+		expected []Kind
+	}{
+		{"legit", stash.Item{Base: ring, Prefix: fiery, Suffix: valor, Material: component, RelicCompletionBonus: bonus, StackSize: 1}, nil},
+		{"missing records", stash.Item{Base: ring, Suffix: "records/items/lootaffixes/suffix/unknown.dbr", Enchantment: "records/items/enchants/unknown.dbr"}, []Kind{MissingRecord, MissingRecord, AffixNotAllowed}},
+		{"no base", stash.Item{}, []Kind{MissingRecord}},
+		{"suffix as prefix", stash.Item{Base: ring, Prefix: valor}, []Kind{AffixNotAllowed}},
+		{"low level affix", stash.Item{Base: ring, Prefix: ancient}, []Kind{AffixLevel}},
+		{"high level affix", stash.Item{Base: ring, Prefix: mighty}, []Kind{AffixLevel}},
+		{"unchecked base", stash.Item{Base: sword, Prefix: fiery}, nil},
+		{"stack", stash.Item{Base: component, StackSize: 100}, nil},
+		{"overfull stack", stash.Item{Base: component, StackSize: 101}, []Kind{StackSize}},
+		{"stacked ring", stash.Item{Base: ring, StackSize: 2}, []Kind{StackSize}},
+		{"incompatible", stash.Item{Base: sword, Material: component}, []Kind{ComponentSlot}},
+	}
+	for _, c := range cases {
+		if findings := v.Check(&c.item); !slices.Equal(kinds(findings), c.expected) {
+			t.Errorf("%s: expected %v, got %+v", c.name, c.expected, findings)
+		}
+	}
+
+	if findings := v.Check(&stash.Item{Base: ring, Prefix: valor}); HasErrors(findings) {
+		t.Errorf("expected an affix of another slot to be a warning, got %+v", findings)
+	}
+	findings := v.Check(&stash.Item{Base: ring, Prefix: ancient, StackSize: 2})
+	if len(findings) != 2 || findings[0].Severity != Warning || findings[1].Severity != Error || !HasErrors(findings) {
+		t.Errorf("expected a warning and an error, got %+v", findings)
+	}
+	if HasErrors(findings[:1]) {
+		t.Error("expected a warning not to be an error")
+	}
+}
+
+func TestCompatibility(t *testing.T) {
+	t.Parallel()
+
+	v := testValidator()
+	cases := []struct {
+		name     string
+		item     stash.Item
+		expected []Kind
+	}{
+		{"fitting", stash.Item{Base: ring, Material: component, RelicCompletionBonus: bonus}, nil},
+		{"augmented", stash.Item{Base: sword, Enchantment: augment}, nil},
+		{"component slot", stash.Item{Base: sword, Material: component}, []Kind{ComponentSlot}},
+		{"augment slot", stash.Item{Base: ring, Enchantment: augment}, []Kind{AugmentSlot}},
+		{"wrong classes", stash.Item{Base: ring, Material: augment, Enchantment: component}, []Kind{WrongClass, WrongClass}},
+		{"foreign bonus", stash.Item{Base: ring, Material: component, RelicCompletionBonus: other}, []Kind{CompletionBonus}},
+		{"bonus without component", stash.Item{Base: ring, RelicCompletionBonus: bonus}, []Kind{CompletionBonus}},
+		{"component stack", stash.Item{Base: component, MaterialCombines: 2, RelicCompletionBonus: bonus}, nil},
+		{"overfull component", stash.Item{Base: component, MaterialCombines: 4}, []Kind{ComponentCombines}},
+		{"unknown bonus table", stash.Item{Base: relic, RelicCompletionBonus: other}, nil},
+		{"unknown records", stash.Item{Base: "records/items/unknown.dbr", Material: "records/items/materia/unknown.dbr"}, nil},
+	}
+	for _, c := range cases {
+		if findings := v.Compatibility(&c.item); !slices.Equal(kinds(findings), c.expected) {
+			t.Errorf("%s: expected %v, got %+v", c.name, c.expected, findings)
+		}
+	}
+}
+
+func TestCompatibilityMessage(t *testing.T) {
+	t.Parallel()
+
+	findings := testValidator().Compatibility(&stash.Item{Base: sword, Material: component})
+	expected := Finding{Kind: ComponentSlot, Severity: Error, Field: "material", Record: component, Message: "Component cannot be socketed into " + sword}
+	if len(findings) != 1 || findings[0] != expected {
+		t.Errorf("expected %+v, got %+v", expected, findings)
+	}
+}
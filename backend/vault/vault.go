@@ -0,0 +1,187 @@
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+// An item stored in the vault, together with where it came from. `Owner` and
+// `Hardcore` are only known for items imported from other tools that track
+// them, like GDStash.
+type Entry struct {
+	Id       uint64
+	Item     stash.Item
+	Source   string
+	Owner    string `json:",omitempty"`
+	Hardcore bool   `json:",omitempty"`
+	Added    time.Time
+}
+
+// The item storage that is independent of the game's transfer stash. It is
+// persisted as a single JSON file.
+type Vault struct {
+	File    string
+	Entries []Entry
+	NextId  uint64
+	// Saved search queries by name.
+	Searches map[string]string
+	// Items that have been thrown away, e.g. duplicates, kept until the user
+	// deletes them for good.
+	Discarded []Entry
+}
+
+type persisted struct {
+	NextId    uint64
+	Entries   []Entry
+	Searches  map[string]string `json:",omitempty"`
+	Discarded []Entry           `json:",omitempty"`
+}
+
+// The default location of the vault file, in the user's config directory.
+func DefaultFile() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "grimvault", "vault.json"), nil
+}
+
+// Open the vault stored in `file`. A missing file yields an empty vault that is
+// created on the first `Save`.
+func Open(file string) (*Vault, error) {
+	v := &Vault{File: file, NextId: 1, Searches: make(map[string]string)}
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return v, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read vault '%s': %w", file, err)
+	}
+
+	var p persisted
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("could not parse vault '%s': %w", file, err)
+	}
+	v.Entries = p.Entries
+	v.NextId = max(p.NextId, 1)
+	if p.Searches != nil {
+		v.Searches = p.Searches
+	}
+	v.Discarded = p.Discarded
+	return v, nil
+}
+
+// Write the vault back to its file.
+func (v *Vault) Save() error {
+	data, err := json.MarshalIndent(persisted{NextId: v.NextId, Entries: v.Entries, Searches: v.Searches, Discarded: v.Discarded}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not serialize vault: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(v.File), 0755); err != nil {
+		return fmt.Errorf("could not create vault directory: %w", err)
+	}
+	tmp := v.File + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("could not write vault '%s': %w", v.File, err)
+	}
+	return os.Rename(tmp, v.File)
+}
+
+// Store `item` in the vault.
+func (v *Vault) Add(item stash.Item, source string) Entry {
+	return v.Insert(Entry{Item: item, Source: source})
+}
+
+// Store `entry` in the vault, assigning it a new id and the current time. The
+// item's position is meaningless outside of a stash tab, so it is reset.
+func (v *Vault) Insert(entry Entry) Entry {
+	entry.Item.X, entry.Item.Y = 0, 0
+	entry.Id = v.NextId
+	entry.Added = time.Now().UTC()
+	v.NextId++
+	v.Entries = append(v.Entries, entry)
+	return entry
+}
+
+// Look up the entry with the given `id`.
+func (v *Vault) Get(id uint64) (Entry, bool) {
+	for _, entry := range v.Entries {
+		if entry.Id == id {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Take the entry with the given `id` out of the vault.
+func (v *Vault) Remove(id uint64) (Entry, bool) {
+	for i, entry := range v.Entries {
+		if entry.Id == id {
+			v.Entries = append(v.Entries[:i], v.Entries[i+1:]...)
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Move the entry with the given `id` to the discarded items.
+func (v *Vault) Discard(id uint64) (Entry, bool) {
+	entry, ok := v.Remove(id)
+	if ok {
+		v.Discarded = append(v.Discarded, entry)
+	}
+	return entry, ok
+}
+
+// Put `entry` into the discarded items right away, assigning it a new id like
+// `Insert` does.
+func (v *Vault) InsertDiscarded(entry Entry) Entry {
+	entry = v.Insert(entry)
+	v.Entries = v.Entries[:len(v.Entries)-1]
+	v.Discarded = append(v.Discarded, entry)
+	return entry
+}
+
+// Move the discarded entry with the given `id` back into the vault.
+func (v *Vault) Restore(id uint64) (Entry, bool) {
+	for i, entry := range v.Discarded {
+		if entry.Id == id {
+			v.Discarded = append(v.Discarded[:i], v.Discarded[i+1:]...)
+			v.Entries = append(v.Entries, entry)
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Whether any of the item's record paths contains `text`, ignoring case.
+func (entry *Entry) Matches(text string) bool {
+	text = strings.ToLower(text)
+	for _, record := range entry.Item.Records() {
+		if strings.Contains(strings.ToLower(record), text) {
+			return true
+		}
+	}
+	return false
+}
+
+// Find all entries where any of the item's record paths contains `text`,
+// ignoring case.
+func (v *Vault) Search(text string) []Entry {
+	var found []Entry
+	for _, entry := range v.Entries {
+		if entry.Matches(text) {
+			found = append(found, entry)
+		}
+	}
+	return found
+}
@@ -0,0 +1,102 @@
+package vault
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/kenranunderscore/grimvault/backend/stash"
+)
+
+func TestAddSaveAndReopen(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "vault.json")
+	v, err := Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := stash.ReadStash("../test_data/stashes/transfer.gst")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range st.Tabs[2].Items {
+		v.Add(item, "transfer.gst")
+	}
+	if err := v.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(reopened.Entries); n != 99 {
+		t.Fatalf("expected 99 entries, got %d", n)
+	}
+	if reopened.NextId != 100 {
+		t.Errorf("expected next id 100, got %d", reopened.NextId)
+	}
+	if base := reopened.Entries[0].Item.Base; base != "records/items/materia/compa_bristlyfur.dbr" {
+		t.Errorf("unexpected base of first entry: %s", base)
+	}
+}
+
+func TestSearchAndRemove(t *testing.T) {
+	t.Parallel()
+
+	v, err := Open(filepath.Join(t.TempDir(), "vault.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Add(stash.Item{Base: "records/items/materia/compa_bristlyfur.dbr"}, "test")
+	amulet := v.Add(stash.Item{Base: "records/items/gearaccessories/necklaces/a01_necklace01.dbr"}, "test")
+
+	found := v.Search("NECKLACE")
+	if len(found) != 1 || found[0].Id != amulet.Id {
+		t.Fatalf("expected to find the amulet, got %v", found)
+	}
+
+	if _, ok := v.Remove(amulet.Id); !ok {
+		t.Fatal("expected to remove the amulet")
+	}
+	if found := v.Search("necklace"); len(found) != 0 {
+		t.Errorf("expected no results after removal, got %v", found)
+	}
+}
+
+func TestDiscardAndRestore(t *testing.T) {
+	t.Parallel()
+
+	file := filepath.Join(t.TempDir(), "vault.json")
+	v, err := Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kept := v.Add(stash.Item{Base: "records/items/gearhead/a.dbr"}, "a")
+	thrown := v.Add(stash.Item{Base: "records/items/gearhead/b.dbr"}, "b")
+	fromStash := v.InsertDiscarded(Entry{Item: stash.Item{Base: "records/items/gearhead/c.dbr", X: 5}})
+	if _, ok := v.Discard(thrown.Id); !ok {
+		t.Fatal("expected the entry to be discarded")
+	}
+	if err := v.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err = Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Entries) != 1 || v.Entries[0].Id != kept.Id || len(v.Discarded) != 2 {
+		t.Fatalf("expected one entry and two discarded ones, got %+v and %+v", v.Entries, v.Discarded)
+	}
+	if fromStash.Id != 3 || v.Discarded[0].Item.X != 0 {
+		t.Errorf("expected discarded stash items to be inserted, got %+v", v.Discarded[0])
+	}
+	if _, ok := v.Get(thrown.Id); ok {
+		t.Error("expected discarded entries not to be found")
+	}
+	if _, ok := v.Restore(thrown.Id); !ok || len(v.Entries) != 2 || len(v.Discarded) != 1 {
+		t.Errorf("expected the entry to be restored, got %+v and %+v", v.Entries, v.Discarded)
+	}
+}
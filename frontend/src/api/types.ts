@@ -0,0 +1,402 @@
+// Code generated by tsgen. DO NOT EDIT.
+
+export interface Item {
+  Base: string;
+  Prefix: string;
+  Suffix: string;
+  Modifier: string;
+  Transmute: string;
+  Material: string;
+  RelicCompletionBonus: string;
+  Enchantment: string;
+  Seed: number;
+  RelicSeed: number;
+  EnchantmentSeed: number;
+  MaterialCombines: number;
+  StackSize: number;
+  X: number;
+  Y: number;
+}
+
+export interface StashTab {
+  Items: Item[] | null;
+  Width: number;
+  Height: number;
+  Block: Record<string, never>;
+}
+
+export interface Stash {
+  Version: number;
+  Mod: string;
+  Expansions: number;
+  Tabs: StashTab[] | null;
+}
+
+export interface ExportItem {
+  name?: string;
+  base: string;
+  prefix?: string;
+  suffix?: string;
+  modifier?: string;
+  transmute?: string;
+  material?: string;
+  relicCompletionBonus?: string;
+  enchantment?: string;
+  seed: number;
+  relicSeed?: number;
+  enchantmentSeed?: number;
+  materialCombines?: number;
+  stackSize: number;
+  x: number;
+  y: number;
+}
+
+export interface ExportTab {
+  width: number;
+  height: number;
+  items: ExportItem[] | null;
+}
+
+export interface ExportDocument {
+  schema: number;
+  version: number;
+  mod?: string;
+  expansions: number;
+  tabs: ExportTab[] | null;
+}
+
+export interface DatabaseStat {
+  Name: string;
+  Value: string | number;
+}
+
+export interface DatabaseEntry {
+  Key: string;
+  Stats: DatabaseStat[] | null;
+}
+
+export interface VaultEntry {
+  Id: number;
+  Item: Item;
+  Source: string;
+  Owner?: string;
+  Hardcore?: boolean;
+  Added: string;
+}
+
+export interface ItemStat {
+  name: string;
+  value: number;
+  record: string;
+}
+
+export interface Details {
+  name: string;
+  class: string;
+  rarity?: string;
+  level: number;
+  physique?: number;
+  cunning?: number;
+  spirit?: number;
+  stats: ItemStat[] | null;
+}
+
+export interface Requirements {
+  level: number;
+  physique?: number;
+  cunning?: number;
+  spirit?: number;
+}
+
+export interface ItemSet {
+  record: string;
+  name: string;
+  members: string[] | null;
+}
+
+export interface ItemLocation {
+  kind: string;
+  name?: string;
+  place?: string;
+  tab: number;
+  index: number;
+  vaultId?: number;
+}
+
+export interface SetPiece {
+  record: string;
+  name: string;
+  locations: ItemLocation[] | null;
+}
+
+export interface SetCompletion {
+  set: ItemSet;
+  owned: SetPiece[] | null;
+  missing: SetPiece[] | null;
+}
+
+export interface CollectionItem {
+  name: string;
+  slot: string;
+  rarity: string;
+  level: number;
+  records: string[] | null;
+}
+
+export interface CollectionEntry {
+  name: string;
+  slot: string;
+  rarity: string;
+  level: number;
+  records: string[] | null;
+  owned: boolean;
+  locations: ItemLocation[] | null;
+}
+
+export interface CollectionGroup {
+  slot: string;
+  rarity: string;
+  owned: number;
+  items: CollectionEntry[] | null;
+}
+
+export interface OwnedItem {
+  item: Item;
+  at: ItemLocation;
+  hardcore: boolean;
+}
+
+export interface DuplicateGroup {
+  kind: string;
+  copies: OwnedItem[] | null;
+  keep: number;
+}
+
+export interface AffixRoll {
+  affix: string;
+  name: string;
+  rare: boolean;
+  chance: number;
+  levelMin: number;
+  levelMax: number;
+}
+
+export interface AffixPool {
+  base: string;
+  name: string;
+  prefixes: AffixRoll[] | null;
+  suffixes: AffixRoll[] | null;
+}
+
+export interface AffixSource {
+  base: string;
+  name: string;
+  roll: AffixRoll;
+}
+
+export interface DropSource {
+  record: string;
+  name: string;
+  class: string;
+  classification?: string;
+  chance: number;
+  path: string[] | null;
+}
+
+export interface Finding {
+  kind: string;
+  severity: string;
+  field: string;
+  record: string;
+  message: string;
+}
+
+export interface EditorChoice {
+  record: string;
+  name: string;
+}
+
+export interface EditorOptions {
+  base: string;
+  name: string;
+  prefixes: AffixRoll[] | null;
+  suffixes: AffixRoll[] | null;
+  components: EditorChoice[] | null;
+  completions: Record<string, EditorChoice[] | null> | null;
+  augments: EditorChoice[] | null;
+  maxStack: number;
+}
+
+export interface ItemSpec {
+  base: string;
+  prefix?: string;
+  suffix?: string;
+  material?: string;
+  relicCompletionBonus?: string;
+  enchantment?: string;
+  seed?: number;
+  relicSeed?: number;
+  enchantmentSeed?: number;
+  stackSize?: number;
+  reroll?: boolean;
+}
+
+export interface TooltipSection {
+  kind: string;
+  title?: string;
+  lines: string[] | null;
+}
+
+export interface SetBonus {
+  pieces: number;
+  lines: string[] | null;
+}
+
+export interface SetInfo {
+  name: string;
+  members: string[] | null;
+  bonuses: SetBonus[] | null;
+}
+
+export interface Tooltip {
+  name: string;
+  rarity?: string;
+  color: string;
+  type?: string;
+  requirements: Requirements;
+  sections: TooltipSection[] | null;
+  set?: SetInfo;
+  flavor?: string;
+}
+
+export interface StashInfo {
+  id: string;
+  mod?: string;
+  hardcore: boolean;
+  tabs: number;
+}
+
+export interface ItemDetails {
+  item: ExportItem;
+  details?: Details;
+}
+
+export interface VaultItem {
+  id: number;
+  item: ExportItem;
+  source: string;
+  owner?: string;
+  hardcore?: boolean;
+  added: string;
+  details?: Details;
+}
+
+export interface ToVaultRequest {
+  stash: string;
+  tab: number;
+  item: number;
+}
+
+export interface ToStashRequest {
+  id: number;
+  stash: string;
+  tab: number;
+  x: number;
+  y: number;
+  force?: boolean;
+}
+
+export interface StashLocation {
+  tab: number;
+  x: number;
+  y: number;
+}
+
+export interface ItemEvent {
+  stash: string;
+  tab: number;
+  item: ExportItem;
+  from?: StashLocation;
+}
+
+export interface ItemPosition {
+  stash: string;
+  tab: number;
+  index: number;
+}
+
+export interface SearchResult {
+  item: ExportItem;
+  vaultId?: number;
+  at?: ItemPosition;
+}
+
+export interface SavedSearch {
+  query: string;
+}
+
+export interface SyntaxErrorPosition {
+  pos: number;
+  length: number;
+}
+
+export interface DiscardRequest {
+  near: boolean;
+}
+
+export interface DiscardResult {
+  discarded: OwnedItem[] | null;
+}
+
+export interface TooltipResponse {
+  tooltip: Tooltip;
+  html: string;
+}
+
+export interface ItemOdds {
+  id: number;
+  odds: number;
+}
+
+export interface ItemCheck {
+  id: number;
+  findings: Finding[] | null;
+}
+
+export interface CreateItemRequest {
+  item: ItemSpec;
+  stash: string;
+  tab: number;
+  x?: number;
+  y?: number;
+}
+
+export interface ConsolidateRequest {
+  stash: string;
+  base?: string;
+}
+
+export interface ConsolidateResult {
+  freed: number;
+  removed: VaultItem[] | null;
+}
+
+export interface SplitRequest {
+  count: number;
+  x?: number;
+  y?: number;
+}
+
+export interface ErrorBody {
+  status: number;
+  error: string;
+  syntax?: SyntaxErrorPosition;
+}
+
+export type EventType =
+  | "item-added"
+  | "item-removed"
+  | "item-moved"
+  | "vault-added"
+  | "vault-removed"
+  | "reset";
@@ -3,7 +3,8 @@ module github.com/kenranunderscore/grimvault
 go 1.24.1
 
 require (
-	github.com/fxamacker/cbor/v2 v2.8.0 // indirect
-	github.com/pierrec/lz4 v2.6.1+incompatible // indirect
-	github.com/x448/float16 v0.8.4 // indirect
+	github.com/fxamacker/cbor/v2 v2.8.0
+	github.com/pierrec/lz4 v2.6.1+incompatible
 )
+
+require github.com/x448/float16 v0.8.4 // indirect